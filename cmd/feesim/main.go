@@ -0,0 +1,203 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// feesim replays a synthetic or recorded load against the dynamic fee rules
+// and prints the base fee, block gas cost and minimum required tip per block.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/ava-labs/avalanchego/upgrade"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/coreth/cmd/utils"
+	"github.com/ava-labs/coreth/consensus/dummy"
+	"github.com/ava-labs/coreth/consensus/dummy/feesim"
+	"github.com/ava-labs/coreth/internal/flags"
+	"github.com/ava-labs/coreth/params"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	networkFlag = &cli.StringFlag{
+		Name:  "network",
+		Usage: "Network whose upgrade schedule is used (mainnet, fuji or local)",
+		Value: constants.MainnetName,
+	}
+	chainConfigFlag = &cli.StringFlag{
+		Name:  "chain-config",
+		Usage: "Path to a chain config JSON file, overrides --network",
+	}
+	inputFlag = &cli.StringFlag{
+		Name:  "input",
+		Usage: "Path to a CSV file of timestamp,gasUsed[,extDataGasUsed] records, - for STDIN (default = synthetic load)",
+	}
+	blocksFlag = &cli.IntFlag{
+		Name:  "blocks",
+		Usage: "Number of blocks of the synthetic load",
+		Value: 100,
+	}
+	startFlag = &cli.Uint64Flag{
+		Name:  "start",
+		Usage: "Timestamp of the first block of the synthetic load (default = now)",
+	}
+	intervalFlag = &cli.Uint64Flag{
+		Name:  "interval",
+		Usage: "Seconds between the blocks of the synthetic load",
+		Value: 2,
+	}
+	gasUsedFlag = &cli.Uint64Flag{
+		Name:  "gas-used",
+		Usage: "Gas used by each block of the synthetic load",
+		Value: params.ApricotPhase5TargetGas / 10,
+	}
+	extDataGasUsedFlag = &cli.Uint64Flag{
+		Name:  "ext-data-gas-used",
+		Usage: "Atomic transaction gas used by each block of the synthetic load",
+	}
+	baseFeeFlag = &cli.StringFlag{
+		Name:  "base-fee",
+		Usage: "Base fee (wei) of the block preceding the load (default = minimum base fee)",
+	}
+	jsonFlag = &cli.BoolFlag{
+		Name:  "json",
+		Usage: "Print the results as JSON instead of CSV",
+	}
+)
+
+var app = flags.NewApp("Dynamic fee market simulator")
+
+func init() {
+	app.Name = "feesim"
+	app.Flags = []cli.Flag{
+		networkFlag,
+		chainConfigFlag,
+		inputFlag,
+		blocksFlag,
+		startFlag,
+		intervalFlag,
+		gasUsedFlag,
+		extDataGasUsedFlag,
+		baseFeeFlag,
+		jsonFlag,
+	}
+	app.Action = feesimAction
+}
+
+func feesimAction(c *cli.Context) error {
+	config, err := chainConfig(c)
+	if err != nil {
+		utils.Fatalf("Failed to load chain config: %v", err)
+	}
+
+	var blocks []feesim.Block
+	if c.IsSet(inputFlag.Name) {
+		blocks, err = readBlocks(c.String(inputFlag.Name))
+		if err != nil {
+			utils.Fatalf("Failed to read blocks: %v", err)
+		}
+	} else {
+		start := c.Uint64(startFlag.Name)
+		if !c.IsSet(startFlag.Name) {
+			start = uint64(time.Now().Unix())
+		}
+		blocks = feesim.Synthetic(
+			c.Int(blocksFlag.Name),
+			start,
+			c.Uint64(intervalFlag.Name),
+			c.Uint64(gasUsedFlag.Name),
+			c.Uint64(extDataGasUsedFlag.Name),
+		)
+	}
+	if len(blocks) == 0 {
+		utils.Fatalf("No blocks to simulate")
+	}
+
+	baseFee := minBaseFee(config, blocks[0].Timestamp)
+	if c.IsSet(baseFeeFlag.Name) {
+		var ok bool
+		if baseFee, ok = new(big.Int).SetString(c.String(baseFeeFlag.Name), 10); !ok {
+			utils.Fatalf("Invalid base fee %q", c.String(baseFeeFlag.Name))
+		}
+	}
+	parent := feesim.NewParent(config, blocks[0].Timestamp, baseFee)
+	results, err := feesim.Simulate(config, parent, blocks)
+	if err != nil {
+		utils.Fatalf("Failed to simulate: %v", err)
+	}
+
+	if c.Bool(jsonFlag.Name) {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	}
+	return feesim.WriteCSV(os.Stdout, results)
+}
+
+// chainConfig returns the chain config read from --chain-config, or the one
+// of the network selected by --network.
+func chainConfig(c *cli.Context) (*params.ChainConfig, error) {
+	if path := c.String(chainConfigFlag.Name); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		config := new(params.ChainConfig)
+		if err := json.Unmarshal(data, config); err != nil {
+			return nil, err
+		}
+		return config, nil
+	}
+	networkID, err := constants.NetworkID(c.String(networkFlag.Name))
+	if err != nil {
+		return nil, err
+	}
+	chainID := params.AvalancheLocalChainID
+	switch networkID {
+	case constants.MainnetID:
+		chainID = params.AvalancheMainnetChainID
+	case constants.FujiID:
+		chainID = params.AvalancheFujiChainID
+	}
+	return params.GetChainConfig(upgrade.GetConfig(networkID), chainID), nil
+}
+
+func readBlocks(path string) ([]feesim.Block, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	return feesim.ReadCSV(r)
+}
+
+// minBaseFee returns the minimum base fee of the rules active at [timestamp].
+func minBaseFee(config *params.ChainConfig, timestamp uint64) *big.Int {
+	switch {
+	case config.IsEtna(timestamp):
+		return dummy.EtnaMinBaseFee
+	case config.IsApricotPhase4(timestamp):
+		return dummy.ApricotPhase4MinBaseFee
+	default:
+		return dummy.ApricotPhase3MinBaseFee
+	}
+}
+
+func main() {
+	log.SetDefault(log.NewLogger(log.NewTerminalHandlerWithLevel(os.Stderr, log.LevelInfo, true)))
+
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
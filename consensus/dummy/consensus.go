@@ -36,6 +36,7 @@ type Mode struct {
 	ModeSkipHeader   bool
 	ModeSkipBlockFee bool
 	ModeSkipCoinbase bool
+	ModeAllowBlobs   bool // Allow blocks to include blob transactions (test networks only)
 }
 
 type (
@@ -89,8 +90,9 @@ func NewFakerWithMode(cb ConsensusCallbacks, mode Mode) *DummyEngine {
 	}
 }
 
-func NewFakerWithModeAndClock(mode Mode, clock *mockable.Clock) *DummyEngine {
+func NewFakerWithModeAndClock(cb ConsensusCallbacks, mode Mode, clock *mockable.Clock) *DummyEngine {
 	return &DummyEngine{
+		cb:            cb,
 		clock:         clock,
 		consensusMode: mode,
 	}
@@ -178,18 +180,7 @@ func (eng *DummyEngine) verifyHeaderGasFields(config *params.ChainConfig, header
 	}
 
 	// Enforce BlockGasCost constraints
-	blockGasCostStep := ApricotPhase4BlockGasCostStep
-	if config.IsApricotPhase5(header.Time) {
-		blockGasCostStep = ApricotPhase5BlockGasCostStep
-	}
-	expectedBlockGasCost := calcBlockGasCost(
-		ApricotPhase4TargetBlockRate,
-		ApricotPhase4MinBlockGasCost,
-		ApricotPhase4MaxBlockGasCost,
-		blockGasCostStep,
-		parent.BlockGasCost,
-		parent.Time, header.Time,
-	)
+	expectedBlockGasCost := BlockGasCost(config, parent, header.Time)
 	if header.BlockGasCost == nil {
 		return errBlockGasCostNil
 	}
@@ -270,7 +261,7 @@ func (eng *DummyEngine) verifyHeader(chain consensus.ChainHeaderReader, header *
 		if err := eip4844.VerifyEIP4844Header(parent, header); err != nil {
 			return err
 		}
-		if *header.BlobGasUsed > 0 { // VerifyEIP4844Header ensures BlobGasUsed is non-nil
+		if *header.BlobGasUsed > 0 && !eng.consensusMode.ModeAllowBlobs { // VerifyEIP4844Header ensures BlobGasUsed is non-nil
 			return fmt.Errorf("blobs not enabled on avalanche networks: used %d blob gas, expected 0", *header.BlobGasUsed)
 		}
 	}
@@ -403,20 +394,9 @@ func (eng *DummyEngine) Finalize(chain consensus.ChainHeaderReader, block *types
 		if blockExtDataGasUsed := block.ExtDataGasUsed(); blockExtDataGasUsed == nil || !blockExtDataGasUsed.IsUint64() || blockExtDataGasUsed.Cmp(extDataGasUsed) != 0 {
 			return fmt.Errorf("invalid extDataGasUsed: have %d, want %d", blockExtDataGasUsed, extDataGasUsed)
 		}
-		blockGasCostStep := ApricotPhase4BlockGasCostStep
-		if chain.Config().IsApricotPhase5(block.Time()) {
-			blockGasCostStep = ApricotPhase5BlockGasCostStep
-		}
 		// Calculate the expected blockGasCost for this block.
 		// Note: this is a deterministic transtion that defines an exact block fee for this block.
-		blockGasCost := calcBlockGasCost(
-			ApricotPhase4TargetBlockRate,
-			ApricotPhase4MinBlockGasCost,
-			ApricotPhase4MaxBlockGasCost,
-			blockGasCostStep,
-			parent.BlockGasCost,
-			parent.Time, block.Time(),
-		)
+		blockGasCost := BlockGasCost(chain.Config(), parent, block.Time())
 		// Verify the BlockGasCost set in the header matches the calculated value.
 		if blockBlockGasCost := block.BlockGasCost(); blockBlockGasCost == nil || !blockBlockGasCost.IsUint64() || blockBlockGasCost.Cmp(blockGasCost) != 0 {
 			return fmt.Errorf("invalid blockGasCost: have %d, want %d", blockBlockGasCost, blockGasCost)
@@ -455,19 +435,8 @@ func (eng *DummyEngine) FinalizeAndAssemble(chain consensus.ChainHeaderReader, h
 		if header.ExtDataGasUsed == nil {
 			header.ExtDataGasUsed = new(big.Int).Set(common.Big0)
 		}
-		blockGasCostStep := ApricotPhase4BlockGasCostStep
-		if chain.Config().IsApricotPhase5(header.Time) {
-			blockGasCostStep = ApricotPhase5BlockGasCostStep
-		}
 		// Calculate the required block gas cost for this block.
-		header.BlockGasCost = calcBlockGasCost(
-			ApricotPhase4TargetBlockRate,
-			ApricotPhase4MinBlockGasCost,
-			ApricotPhase4MaxBlockGasCost,
-			blockGasCostStep,
-			parent.BlockGasCost,
-			parent.Time, header.Time,
-		)
+		header.BlockGasCost = BlockGasCost(chain.Config(), parent, header.Time)
 		// Verify that this block covers the block fee.
 		if err := eng.verifyBlockFee(
 			header.BaseFee,
@@ -285,6 +285,24 @@ func updateLongWindow(window []byte, start uint64, gasConsumed uint64) {
 	binary.BigEndian.PutUint64(window[start:], totalGasConsumed)
 }
 
+// BlockGasCost calculates the required block gas cost of the child of [parent]
+// built at [timestamp].
+// BlockGasCost should only be called if [timestamp] >= [config.ApricotPhase4Timestamp]
+func BlockGasCost(config *params.ChainConfig, parent *types.Header, timestamp uint64) *big.Int {
+	blockGasCostStep := ApricotPhase4BlockGasCostStep
+	if config.IsApricotPhase5(timestamp) {
+		blockGasCostStep = ApricotPhase5BlockGasCostStep
+	}
+	return calcBlockGasCost(
+		ApricotPhase4TargetBlockRate,
+		ApricotPhase4MinBlockGasCost,
+		ApricotPhase4MaxBlockGasCost,
+		blockGasCostStep,
+		parent.BlockGasCost,
+		parent.Time, timestamp,
+	)
+}
+
 // calcBlockGasCost calculates the required block gas cost. If [parentTime]
 // > [currentTime], the timeElapsed will be treated as 0.
 func calcBlockGasCost(
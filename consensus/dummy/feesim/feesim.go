@@ -0,0 +1,191 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// Package feesim replays a series of blocks against the dynamic fee rules of
+// the dummy consensus engine, to model the base fee, block gas cost and
+// minimum required tip resulting from a given load.
+package feesim
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ava-labs/coreth/consensus/dummy"
+	"github.com/ava-labs/coreth/core/types"
+	"github.com/ava-labs/coreth/params"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var errNoBlocks = errors.New("no blocks to simulate")
+
+// Block is the load of a simulated block.
+type Block struct {
+	Timestamp      uint64 `json:"timestamp"`
+	GasUsed        uint64 `json:"gasUsed"`
+	ExtDataGasUsed uint64 `json:"extDataGasUsed"`
+}
+
+// Result is the fee state of a simulated block. The fields that are not
+// defined by the rules active at the block's timestamp are nil.
+type Result struct {
+	Number         uint64   `json:"number"`
+	Timestamp      uint64   `json:"timestamp"`
+	GasUsed        uint64   `json:"gasUsed"`
+	ExtDataGasUsed uint64   `json:"extDataGasUsed"`
+	BaseFee        *big.Int `json:"baseFee"`
+	BlockGasCost   *big.Int `json:"blockGasCost"`
+	MinRequiredTip *big.Int `json:"minRequiredTip"`
+}
+
+// NewParent returns a header with an empty fee window, the given base fee and
+// the minimum block gas cost to start a simulation from, as if the network
+// was idle before [timestamp].
+func NewParent(config *params.ChainConfig, timestamp uint64, baseFee *big.Int) *types.Header {
+	header := &types.Header{
+		Number:  common.Big1,
+		Time:    timestamp,
+		Extra:   make([]byte, params.DynamicFeeExtraDataSize),
+		BaseFee: baseFee,
+	}
+	if config.IsApricotPhase4(timestamp) {
+		header.BlockGasCost = new(big.Int).Set(dummy.ApricotPhase4MinBlockGasCost)
+		header.ExtDataGasUsed = new(big.Int)
+	}
+	return header
+}
+
+// Simulate builds [blocks] in order on top of [parent] under [config] and
+// returns the resulting fee state of each block.
+func Simulate(config *params.ChainConfig, parent *types.Header, blocks []Block) ([]Result, error) {
+	results := make([]Result, 0, len(blocks))
+	for i, block := range blocks {
+		if block.Timestamp < parent.Time {
+			return nil, fmt.Errorf("block %d: timestamp %d prior to parent timestamp %d", i, block.Timestamp, parent.Time)
+		}
+		header := &types.Header{
+			Number:  new(big.Int).Add(parent.Number, common.Big1),
+			Time:    block.Timestamp,
+			GasUsed: block.GasUsed,
+		}
+		if config.IsApricotPhase3(block.Timestamp) {
+			extra, baseFee, err := dummy.CalcBaseFee(config, parent, block.Timestamp)
+			if err != nil {
+				return nil, fmt.Errorf("block %d: %w", i, err)
+			}
+			header.Extra, header.BaseFee = extra, baseFee
+		}
+		result := Result{
+			Number:         header.Number.Uint64(),
+			Timestamp:      block.Timestamp,
+			GasUsed:        block.GasUsed,
+			ExtDataGasUsed: block.ExtDataGasUsed,
+			BaseFee:        header.BaseFee,
+		}
+		if config.IsApricotPhase4(block.Timestamp) {
+			header.ExtDataGasUsed = new(big.Int).SetUint64(block.ExtDataGasUsed)
+			header.BlockGasCost = dummy.BlockGasCost(config, parent, block.Timestamp)
+			result.BlockGasCost = header.BlockGasCost
+
+			// The minimum tip is undefined for empty blocks.
+			if block.GasUsed != 0 || block.ExtDataGasUsed != 0 {
+				tip, err := dummy.MinRequiredTip(config, header)
+				if err != nil {
+					return nil, fmt.Errorf("block %d: %w", i, err)
+				}
+				result.MinRequiredTip = tip
+			}
+		}
+		results = append(results, result)
+		parent = header
+	}
+	return results, nil
+}
+
+// Synthetic returns [n] blocks produced every [interval] seconds from [start],
+// each using [gasUsed] gas and [extDataGasUsed] atomic gas.
+func Synthetic(n int, start, interval, gasUsed, extDataGasUsed uint64) []Block {
+	blocks := make([]Block, n)
+	for i := range blocks {
+		blocks[i] = Block{
+			Timestamp:      start + uint64(i)*interval,
+			GasUsed:        gasUsed,
+			ExtDataGasUsed: extDataGasUsed,
+		}
+	}
+	return blocks
+}
+
+// ReadCSV reads blocks from CSV records of the form
+// "timestamp,gasUsed[,extDataGasUsed]". A leading header record is skipped.
+func ReadCSV(r io.Reader) ([]Block, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) > 0 && len(records[0]) > 0 && strings.EqualFold(records[0][0], "timestamp") {
+		records = records[1:]
+	}
+	if len(records) == 0 {
+		return nil, errNoBlocks
+	}
+	blocks := make([]Block, len(records))
+	for i, record := range records {
+		if len(record) < 2 || len(record) > 3 {
+			return nil, fmt.Errorf("record %d: expected 2 or 3 fields, found %d", i, len(record))
+		}
+		values := make([]uint64, 3)
+		for j, field := range record {
+			if values[j], err = strconv.ParseUint(field, 10, 64); err != nil {
+				return nil, fmt.Errorf("record %d: %w", i, err)
+			}
+		}
+		blocks[i] = Block{
+			Timestamp:      values[0],
+			GasUsed:        values[1],
+			ExtDataGasUsed: values[2],
+		}
+	}
+	return blocks, nil
+}
+
+// WriteCSV writes [results] as CSV records with a header record. Undefined
+// values are left empty.
+func WriteCSV(w io.Writer, results []Result) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"number", "timestamp", "gasUsed", "extDataGasUsed", "baseFee", "blockGasCost", "minRequiredTip"}); err != nil {
+		return err
+	}
+	for _, result := range results {
+		record := []string{
+			strconv.FormatUint(result.Number, 10),
+			strconv.FormatUint(result.Timestamp, 10),
+			strconv.FormatUint(result.GasUsed, 10),
+			strconv.FormatUint(result.ExtDataGasUsed, 10),
+			formatBig(result.BaseFee),
+			formatBig(result.BlockGasCost),
+			formatBig(result.MinRequiredTip),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatBig(v *big.Int) string {
+	if v == nil {
+		return ""
+	}
+	return v.String()
+}
@@ -0,0 +1,123 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package feesim
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ava-labs/coreth/consensus/dummy"
+	"github.com/ava-labs/coreth/params"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *params.ChainConfig
+		gasUsed uint64
+		check   func(*testing.T, []Result)
+	}{
+		{
+			name:    "idle network stays at min base fee",
+			config:  params.TestChainConfig,
+			gasUsed: 21_000,
+			check: func(t *testing.T, results []Result) {
+				for _, result := range results {
+					require.Equal(t, dummy.EtnaMinBaseFee, result.BaseFee)
+					require.Zero(t, result.BlockGasCost.Sign())
+					require.Zero(t, result.MinRequiredTip.Sign())
+				}
+			},
+		},
+		{
+			name:    "load above target raises base fee",
+			config:  params.TestChainConfig,
+			gasUsed: params.ApricotPhase5TargetGas,
+			check: func(t *testing.T, results []Result) {
+				for i := 2; i < len(results); i++ {
+					require.Positive(t, results[i].BaseFee.Cmp(results[i-1].BaseFee))
+				}
+			},
+		},
+		{
+			name:    "no block gas cost before apricot phase 4",
+			config:  params.TestApricotPhase3Config,
+			gasUsed: 21_000,
+			check: func(t *testing.T, results []Result) {
+				for _, result := range results {
+					require.NotNil(t, result.BaseFee)
+					require.Nil(t, result.BlockGasCost)
+					require.Nil(t, result.MinRequiredTip)
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			blocks := Synthetic(20, 102, 2, test.gasUsed, 0)
+			parent := NewParent(test.config, 100, dummy.EtnaMinBaseFee)
+			results, err := Simulate(test.config, parent, blocks)
+			require.NoError(t, err)
+			require.Len(t, results, len(blocks))
+			for i, result := range results {
+				require.Equal(t, uint64(i+2), result.Number)
+				require.Equal(t, blocks[i].Timestamp, result.Timestamp)
+			}
+			test.check(t, results)
+		})
+	}
+}
+
+func TestSimulateBlockGasCost(t *testing.T) {
+	require := require.New(t)
+
+	// Blocks produced faster than the target block rate increase the block gas
+	// cost, which decreases again once blocks are produced slower.
+	blocks := []Block{
+		{Timestamp: 100, GasUsed: 1_000_000},
+		{Timestamp: 100, GasUsed: 1_000_000},
+		{Timestamp: 110, GasUsed: 1_000_000},
+	}
+	parent := NewParent(params.TestChainConfig, 100, dummy.EtnaMinBaseFee)
+	results, err := Simulate(params.TestChainConfig, parent, blocks)
+	require.NoError(err)
+	require.Equal(big.NewInt(400_000), results[0].BlockGasCost)
+	require.Equal(big.NewInt(800_000), results[1].BlockGasCost)
+	require.Equal(big.NewInt(0), results[2].BlockGasCost)
+
+	// minTip = blockGasCost * baseFee / gasUsed
+	want := new(big.Int).Mul(results[1].BlockGasCost, results[1].BaseFee)
+	want.Div(want, big.NewInt(1_000_000))
+	require.Equal(want, results[1].MinRequiredTip)
+
+	_, err = Simulate(params.TestChainConfig, parent, []Block{{Timestamp: 99}})
+	require.ErrorContains(err, "prior to parent timestamp")
+}
+
+func TestCSV(t *testing.T) {
+	require := require.New(t)
+
+	blocks, err := ReadCSV(strings.NewReader("timestamp,gasUsed,extDataGasUsed\n# comment\n100,21000\n102, 42000, 11000\n"))
+	require.NoError(err)
+	require.Equal([]Block{
+		{Timestamp: 100, GasUsed: 21_000},
+		{Timestamp: 102, GasUsed: 42_000, ExtDataGasUsed: 11_000},
+	}, blocks)
+
+	_, err = ReadCSV(strings.NewReader("100\n"))
+	require.ErrorContains(err, "expected 2 or 3 fields")
+	_, err = ReadCSV(strings.NewReader("timestamp,gasUsed\n"))
+	require.ErrorIs(err, errNoBlocks)
+
+	results, err := Simulate(params.TestApricotPhase3Config, NewParent(params.TestApricotPhase3Config, 100, dummy.ApricotPhase3MinBaseFee), blocks)
+	require.NoError(err)
+	var buf bytes.Buffer
+	require.NoError(WriteCSV(&buf, results))
+	require.Equal("number,timestamp,gasUsed,extDataGasUsed,baseFee,blockGasCost,minRequiredTip\n"+
+		"2,100,21000,0,75000000000,,\n"+
+		"3,102,42000,11000,75000000000,,\n", buf.String())
+}
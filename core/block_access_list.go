@@ -0,0 +1,36 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package core
+
+import (
+	"github.com/ava-labs/coreth/core/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// blockAccessList returns the accounts and storage slots known to be accessed
+// when processing [block] ahead of executing it: the coinbase, the sender and
+// recipient of every transaction and the entries of their access lists.
+func blockAccessList(block *types.Block, signer types.Signer) map[common.Address][]common.Hash {
+	accesses := map[common.Address][]common.Hash{
+		block.Coinbase(): nil,
+	}
+	for _, tx := range block.Transactions() {
+		// Senders are normally already cached by the sender cacher, a failure
+		// is reported when the transaction is executed.
+		if from, err := types.Sender(signer, tx); err == nil {
+			if _, ok := accesses[from]; !ok {
+				accesses[from] = nil
+			}
+		}
+		if to := tx.To(); to != nil {
+			if _, ok := accesses[*to]; !ok {
+				accesses[*to] = nil
+			}
+		}
+		for _, tuple := range tx.AccessList() {
+			accesses[tuple.Address] = append(accesses[tuple.Address], tuple.StorageKeys...)
+		}
+	}
+	return accesses
+}
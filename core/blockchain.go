@@ -79,6 +79,7 @@ var (
 	blockInsertCount            = metrics.NewRegisteredCounter("chain/block/inserts/count", nil)
 	blockContentValidationTimer = metrics.NewRegisteredCounter("chain/block/validations/content", nil)
 	blockStateInitTimer         = metrics.NewRegisteredCounter("chain/block/inits/state", nil)
+	blockStateWarmupTimer       = metrics.NewRegisteredCounter("chain/block/inits/warmup", nil)
 	blockExecutionTimer         = metrics.NewRegisteredCounter("chain/block/executions", nil)
 	blockTrieOpsTimer           = metrics.NewRegisteredCounter("chain/block/trie", nil)
 	blockValidationTimer        = metrics.NewRegisteredCounter("chain/block/validations/state", nil)
@@ -100,6 +101,9 @@ var (
 
 	ErrRefuseToCorruptArchiver = errors.New("node has operated with pruning disabled, shutting down to prevent missing tries")
 
+	ErrAssetTransferIndexingDisabled = errors.New("asset transfer indexing is disabled")
+	ErrOpcodeStatsDisabled           = errors.New("opcode statistics collection is disabled")
+
 	errFutureBlockUnsupported  = errors.New("future block insertion not supported")
 	errCacheConfigNotSpecified = errors.New("must specify cache config")
 	errInvalidOldChain         = errors.New("invalid old chain")
@@ -154,6 +158,7 @@ type CacheConfig struct {
 	TrieDirtyLimit                  int     // Memory limit (MB) at which to block on insert and force a flush of dirty trie nodes to disk
 	TrieDirtyCommitTarget           int     // Memory limit (MB) to target for the dirties cache before invoking commit
 	TriePrefetcherParallelism       int     // Max concurrent disk reads trie prefetcher should perform at once
+	TriePrefetcherAccessLists       bool    // Whether to warm the accounts and slots accessed by a block's transactions before processing it
 	CommitInterval                  uint64  // Commit the trie every [CommitInterval] blocks.
 	Pruning                         bool    // Whether to disable trie write caching and GC altogether (archive node)
 	AcceptorQueueLimit              int     // Blocks to queue before blocking during acceptance
@@ -167,6 +172,9 @@ type CacheConfig struct {
 	AcceptedCacheSize               int     // Depth of accepted headers cache and accepted logs cache at the accepted tip
 	TransactionHistory              uint64  // Number of recent blocks for which to maintain transaction lookup indices
 	SkipTxIndexing                  bool    // Whether to skip transaction indexing
+	AssetTransferIndexing           bool    // Whether to index multi-coin transfers by address and asset ID
+	OpcodeStats                     bool    // Whether to collect opcode execution statistics of accepted blocks
+	ParallelTxExecutionWorkers      int     // Number of workers to optimistically execute block transactions in parallel with, sequential if less than 2
 	StateHistory                    uint64  // Number of blocks from head whose state histories are reserved.
 	StateScheme                     string  // Scheme used to store ethereum states and merkle tree nodes on top
 
@@ -280,6 +288,8 @@ type BlockChain struct {
 	processor Processor // Block transaction processor interface
 	vmConfig  vm.Config
 
+	opcodeStats *opcodeStatsCollector // Nil if opcode statistics collection is disabled
+
 	lastAccepted *types.Block // Prevents reorgs past this height
 
 	senderCacher *TxSenderCacher
@@ -376,6 +386,9 @@ func NewBlockChain(
 		quit:              make(chan struct{}),
 		acceptedLogsCache: NewFIFOCache[common.Hash, [][]*types.Log](cacheConfig.AcceptedCacheSize),
 	}
+	if cacheConfig.OpcodeStats {
+		bc.opcodeStats = newOpcodeStatsCollector()
+	}
 	bc.stateCache = state.NewDatabaseWithNodeDB(bc.db, bc.triedb)
 	bc.validator = NewBlockValidator(chainConfig, bc, engine)
 	bc.processor = NewStateProcessor(chainConfig, bc, engine)
@@ -468,6 +481,9 @@ func (bc *BlockChain) batchBlockAcceptedIndices(batch ethdb.Batch, b *types.Bloc
 	if !bc.cacheConfig.SkipTxIndexing {
 		rawdb.WriteTxLookupEntriesByBlock(batch, b)
 	}
+	if bc.cacheConfig.AssetTransferIndexing {
+		rawdb.WriteAssetTransferIndex(batch, b.NumberU64(), rawdb.ReadAssetTransfers(bc.db, b.Hash(), b.NumberU64()))
+	}
 	if err := rawdb.WriteAcceptorTip(batch, b.Hash()); err != nil {
 		return fmt.Errorf("%w: failed to write acceptor tip key", err)
 	}
@@ -1057,6 +1073,9 @@ func (bc *BlockChain) Accept(block *types.Block) error {
 	// Enqueue block in the acceptor
 	bc.lastAccepted = block
 	bc.addAcceptorQueue(block)
+	if bc.opcodeStats != nil {
+		bc.opcodeStats.accept(block.Hash())
+	}
 	acceptedBlockGasUsedCounter.Inc(int64(block.GasUsed()))
 	acceptedTxsCounter.Inc(int64(len(block.Transactions())))
 	return nil
@@ -1077,9 +1096,16 @@ func (bc *BlockChain) Reject(block *types.Block) error {
 		}
 	}
 
+	if bc.opcodeStats != nil {
+		bc.opcodeStats.reject(block.Hash())
+	}
+
 	// Remove the block since its data is no longer needed
 	batch := bc.db.NewBatch()
 	rawdb.DeleteBlock(batch, block.Hash(), block.NumberU64())
+	if bc.cacheConfig.AssetTransferIndexing {
+		rawdb.DeleteAssetTransfers(batch, block.Hash(), block.NumberU64())
+	}
 	if err := batch.Write(); err != nil {
 		return fmt.Errorf("failed to write delete block batch: %w", err)
 	}
@@ -1152,6 +1178,11 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	rawdb.WriteBlock(blockBatch, block)
 	rawdb.WriteReceipts(blockBatch, block.Hash(), block.NumberU64(), receipts)
 	rawdb.WritePreimages(blockBatch, state.Preimages())
+	if bc.cacheConfig.AssetTransferIndexing {
+		if transfers := state.AssetTransfers(); len(transfers) > 0 {
+			rawdb.WriteAssetTransfers(blockBatch, block.Hash(), block.NumberU64(), transfers)
+		}
+	}
 	if err := blockBatch.Write(); err != nil {
 		log.Crit("Failed to write block into disk", "err", err)
 	}
@@ -1318,9 +1349,23 @@ func (bc *BlockChain) insertBlock(block *types.Block, writes bool) error {
 	statedb.StartPrefetcher("chain", bc.cacheConfig.TriePrefetcherParallelism)
 	activeState = statedb
 
+	// Warm the state accessed by the block before processing it
+	if bc.cacheConfig.TriePrefetcherAccessLists {
+		substart = time.Now()
+		signer := types.MakeSigner(bc.chainConfig, block.Number(), block.Time())
+		statedb.PrefetchAccessList(blockAccessList(block, signer), bc.cacheConfig.TriePrefetcherParallelism)
+		blockStateWarmupTimer.Inc(time.Since(substart).Milliseconds())
+	}
+
+	// Collect opcode statistics only for blocks that will be accepted or rejected.
+	vmConfig := bc.vmConfig
+	if bc.opcodeStats != nil && writes {
+		vmConfig.OpcodeStats = new(vm.OpcodeStats)
+	}
+
 	// Process block using the parent state as reference point
 	pstart := time.Now()
-	receipts, logs, usedGas, err := bc.processor.Process(block, parent, statedb, bc.vmConfig)
+	receipts, logs, usedGas, err := bc.processor.Process(block, parent, statedb, vmConfig)
 	if serr := statedb.Error(); serr != nil {
 		log.Error("statedb error encountered", "err", serr, "number", block.Number(), "hash", block.Hash())
 	}
@@ -1370,6 +1415,9 @@ func (bc *BlockChain) insertBlock(block *types.Block, writes bool) error {
 	if err := bc.writeBlockAndSetHead(block, receipts, logs, statedb); err != nil {
 		return err
 	}
+	if vmConfig.OpcodeStats != nil {
+		bc.opcodeStats.add(block.Hash(), vmConfig.OpcodeStats)
+	}
 	// Update the metrics touched during block commit
 	accountCommitTimer.Inc(statedb.AccountCommits.Milliseconds())   // Account commits are complete, we can mark them
 	storageCommitTimer.Inc(statedb.StorageCommits.Milliseconds())   // Storage commits are complete, we can mark them
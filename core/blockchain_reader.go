@@ -192,6 +192,47 @@ func (bc *BlockChain) GetReceiptsByHash(hash common.Hash) types.Receipts {
 	return receipts
 }
 
+// GetAssetTransfers returns the transfers of [assetID] to or from [address]
+// made in accepted blocks in the inclusive range [from, to].
+func (bc *BlockChain) GetAssetTransfers(address common.Address, assetID common.Hash, from uint64, to uint64) ([]*types.AssetTransfer, error) {
+	if !bc.cacheConfig.AssetTransferIndexing {
+		return nil, ErrAssetTransferIndexingDisabled
+	}
+	numbers, err := rawdb.ReadAssetTransferBlockNumbers(bc.db, address, assetID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	transfers := []*types.AssetTransfer{}
+	for _, number := range numbers {
+		hash := rawdb.ReadCanonicalHash(bc.db, number)
+		for _, transfer := range rawdb.ReadAssetTransfers(bc.db, hash, number) {
+			if transfer.Address == address && transfer.AssetID == assetID {
+				transfers = append(transfers, transfer)
+			}
+		}
+	}
+	return transfers, nil
+}
+
+// OpcodeStats returns the opcode execution statistics aggregated over the
+// accepted blocks, along with the number of blocks they cover.
+func (bc *BlockChain) OpcodeStats() (vm.OpcodeStats, uint64, error) {
+	if bc.opcodeStats == nil {
+		return vm.OpcodeStats{}, 0, ErrOpcodeStatsDisabled
+	}
+	stats, blocks := bc.opcodeStats.get()
+	return stats, blocks, nil
+}
+
+// ResetOpcodeStats clears the aggregated opcode execution statistics.
+func (bc *BlockChain) ResetOpcodeStats() error {
+	if bc.opcodeStats == nil {
+		return ErrOpcodeStatsDisabled
+	}
+	bc.opcodeStats.reset()
+	return nil
+}
+
 // GetCanonicalHash returns the canonical hash for a given block number
 func (bc *BlockChain) GetCanonicalHash(number uint64) common.Hash {
 	return bc.hc.GetCanonicalHash(number)
@@ -116,6 +116,19 @@ func TestPruningBlockChain(t *testing.T) {
 	}
 }
 
+func TestAccessListPrefetchBlockChain(t *testing.T) {
+	create := func(db ethdb.Database, gspec *Genesis, lastAcceptedHash common.Hash) (*BlockChain, error) {
+		cacheConfig := *pruningConfig
+		cacheConfig.TriePrefetcherAccessLists = true
+		return createBlockChain(db, &cacheConfig, gspec, lastAcceptedHash)
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			tt.testFunc(t, create)
+		})
+	}
+}
+
 func TestPruningBlockChainSnapsDisabled(t *testing.T) {
 	create := func(db ethdb.Database, gspec *Genesis, lastAcceptedHash common.Hash) (*BlockChain, error) {
 		return createBlockChain(
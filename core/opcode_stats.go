@@ -0,0 +1,93 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package core
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ava-labs/coreth/core/vm"
+	"github.com/ava-labs/coreth/metrics"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// opcodeStatsCollector aggregates the opcode execution statistics of accepted
+// blocks. The statistics of a processed block are held until the block is
+// either accepted or rejected, so only accepted blocks are counted.
+type opcodeStatsCollector struct {
+	lock     sync.Mutex
+	pending  map[common.Hash]*vm.OpcodeStats
+	accepted vm.OpcodeStats
+	blocks   uint64 // Number of accepted blocks included in [accepted]
+
+	countCounters [256]metrics.Counter
+	gasCounters   [256]metrics.Counter
+}
+
+func newOpcodeStatsCollector() *opcodeStatsCollector {
+	return &opcodeStatsCollector{
+		pending: make(map[common.Hash]*vm.OpcodeStats),
+	}
+}
+
+// add holds the statistics of the processed block [hash] until it is decided.
+func (c *opcodeStatsCollector) add(hash common.Hash, stats *vm.OpcodeStats) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.pending[hash] = stats
+}
+
+// accept adds the statistics of block [hash] to the aggregate and the metrics.
+func (c *opcodeStatsCollector) accept(hash common.Hash) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	stats, ok := c.pending[hash]
+	if !ok {
+		return
+	}
+	delete(c.pending, hash)
+	c.accepted.Merge(stats)
+	c.blocks++
+
+	for op, count := range stats.Counts {
+		if count == 0 {
+			continue
+		}
+		if c.countCounters[op] == nil {
+			name := vm.OpCode(op).String()
+			c.countCounters[op] = metrics.GetOrRegisterCounter(fmt.Sprintf("chain/opcodes/%s/count", name), nil)
+			c.gasCounters[op] = metrics.GetOrRegisterCounter(fmt.Sprintf("chain/opcodes/%s/gas", name), nil)
+		}
+		c.countCounters[op].Inc(int64(count))
+		c.gasCounters[op].Inc(int64(stats.Gas[op]))
+	}
+}
+
+// reject discards the statistics of block [hash].
+func (c *opcodeStatsCollector) reject(hash common.Hash) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.pending, hash)
+}
+
+// get returns a copy of the aggregated statistics and the number of accepted
+// blocks they cover.
+func (c *opcodeStatsCollector) get() (vm.OpcodeStats, uint64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.accepted, c.blocks
+}
+
+// reset clears the aggregated statistics. The metrics are left untouched.
+func (c *opcodeStatsCollector) reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.accepted = vm.OpcodeStats{}
+	c.blocks = 0
+}
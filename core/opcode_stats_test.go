@@ -0,0 +1,37 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/ava-labs/coreth/core/vm"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpcodeStatsCollector(t *testing.T) {
+	require := require.New(t)
+	c := newOpcodeStatsCollector()
+
+	accepted, rejected := new(vm.OpcodeStats), new(vm.OpcodeStats)
+	accepted.Counts[vm.ADD], accepted.Gas[vm.ADD] = 2, 6
+	rejected.Counts[vm.SSTORE], rejected.Gas[vm.SSTORE] = 1, 20000
+	c.add(common.Hash{1}, accepted)
+	c.add(common.Hash{2}, rejected)
+
+	c.reject(common.Hash{2})
+	c.accept(common.Hash{1})
+	c.accept(common.Hash{1}) // accepting an unknown block is a no-op
+
+	stats, blocks := c.get()
+	require.Equal(uint64(1), blocks)
+	require.Equal(*accepted, stats)
+	require.Empty(c.pending)
+
+	c.reset()
+	stats, blocks = c.get()
+	require.Zero(blocks)
+	require.Equal(vm.OpcodeStats{}, stats)
+}
@@ -0,0 +1,88 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package rawdb
+
+import (
+	"encoding/binary"
+
+	"github.com/ava-labs/coreth/core/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// ReadAssetTransfers retrieves the multi-coin transfers made in the block
+// corresponding to [hash] and [number], annotated with the block number and
+// hash. Returns nil if the block did not contain any multi-coin transfers.
+func ReadAssetTransfers(db ethdb.KeyValueReader, hash common.Hash, number uint64) []*types.AssetTransfer {
+	data, _ := db.Get(assetTransfersKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	var transfers []*types.AssetTransfer
+	if err := rlp.DecodeBytes(data, &transfers); err != nil {
+		log.Error("Invalid asset transfers RLP", "hash", hash, "err", err)
+		return nil
+	}
+	for _, transfer := range transfers {
+		transfer.BlockNumber = number
+		transfer.BlockHash = hash
+	}
+	return transfers
+}
+
+// WriteAssetTransfers stores the multi-coin transfers made in the block
+// corresponding to [hash] and [number].
+func WriteAssetTransfers(db ethdb.KeyValueWriter, hash common.Hash, number uint64, transfers []*types.AssetTransfer) {
+	bytes, err := rlp.EncodeToBytes(transfers)
+	if err != nil {
+		log.Crit("Failed to encode asset transfers", "err", err)
+	}
+	if err := db.Put(assetTransfersKey(number, hash), bytes); err != nil {
+		log.Crit("Failed to store asset transfers", "err", err)
+	}
+}
+
+// DeleteAssetTransfers removes the multi-coin transfers made in the block
+// corresponding to [hash] and [number].
+func DeleteAssetTransfers(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
+	if err := db.Delete(assetTransfersKey(number, hash)); err != nil {
+		log.Crit("Failed to delete asset transfers", "err", err)
+	}
+}
+
+// WriteAssetTransferIndex marks block [number] as containing a transfer for
+// each (address, asset ID) pair in [transfers]. The index should only be
+// written for accepted blocks.
+func WriteAssetTransferIndex(db ethdb.KeyValueWriter, number uint64, transfers []*types.AssetTransfer) {
+	for _, transfer := range transfers {
+		if err := db.Put(assetTransferIndexKey(transfer.Address, transfer.AssetID, number), nil); err != nil {
+			log.Crit("Failed to store asset transfer index", "err", err)
+		}
+	}
+}
+
+// ReadAssetTransferBlockNumbers returns the numbers of the accepted blocks in
+// the inclusive range [from, to] that contain a transfer of [assetID] to or
+// from [address], in ascending order.
+func ReadAssetTransferBlockNumbers(db ethdb.Iteratee, address common.Address, assetID common.Hash, from uint64, to uint64) ([]uint64, error) {
+	prefix := assetTransferIndexKeyPrefix(address, assetID)
+	it := db.NewIterator(prefix, encodeBlockNumber(from))
+	defer it.Release()
+
+	var numbers []uint64
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(prefix)+8 {
+			continue
+		}
+		number := binary.BigEndian.Uint64(key[len(prefix):])
+		if number > to {
+			break
+		}
+		numbers = append(numbers, number)
+	}
+	return numbers, it.Error()
+}
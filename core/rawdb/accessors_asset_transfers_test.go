@@ -0,0 +1,70 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package rawdb
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ava-labs/coreth/core/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssetTransfersStorage(t *testing.T) {
+	require := require.New(t)
+	db := NewMemoryDatabase()
+
+	var (
+		addr      = common.Address{1}
+		other     = common.Address{2}
+		assetID   = common.Hash{3}
+		blockHash = common.Hash{4}
+	)
+	transfers := []*types.AssetTransfer{
+		{Address: addr, AssetID: assetID, Amount: big.NewInt(10), Incoming: true, TxHash: common.Hash{5}, TxIndex: 1},
+		{Address: other, AssetID: assetID, Amount: big.NewInt(10), TxHash: common.Hash{6}, Atomic: true},
+	}
+	require.Nil(ReadAssetTransfers(db, blockHash, 7))
+
+	WriteAssetTransfers(db, blockHash, 7, transfers)
+	read := ReadAssetTransfers(db, blockHash, 7)
+	require.Len(read, len(transfers))
+	for i, transfer := range read {
+		require.Equal(uint64(7), transfer.BlockNumber)
+		require.Equal(blockHash, transfer.BlockHash)
+		transfer.BlockNumber, transfer.BlockHash = 0, common.Hash{}
+		require.Equal(transfers[i], transfer)
+	}
+
+	DeleteAssetTransfers(db, blockHash, 7)
+	require.Nil(ReadAssetTransfers(db, blockHash, 7))
+}
+
+func TestAssetTransferIndex(t *testing.T) {
+	require := require.New(t)
+	db := NewMemoryDatabase()
+
+	var (
+		addr    = common.Address{1}
+		assetID = common.Hash{2}
+	)
+	for _, number := range []uint64{1, 5, 256, 1000} {
+		WriteAssetTransferIndex(db, number, []*types.AssetTransfer{{Address: addr, AssetID: assetID}})
+	}
+	// A transfer of another asset must not show up in the index for [assetID].
+	WriteAssetTransferIndex(db, 6, []*types.AssetTransfer{{Address: addr, AssetID: common.Hash{9}}})
+
+	numbers, err := ReadAssetTransferBlockNumbers(db, addr, assetID, 0, 2000)
+	require.NoError(err)
+	require.Equal([]uint64{1, 5, 256, 1000}, numbers)
+
+	numbers, err = ReadAssetTransferBlockNumbers(db, addr, assetID, 5, 256)
+	require.NoError(err)
+	require.Equal([]uint64{5, 256}, numbers)
+
+	numbers, err = ReadAssetTransferBlockNumbers(db, common.Address{9}, assetID, 0, 2000)
+	require.NoError(err)
+	require.Empty(numbers)
+}
@@ -38,6 +38,12 @@ func AddCodeToFetch(db ethdb.KeyValueWriter, hash common.Hash) {
 	}
 }
 
+// HasCodeToFetch returns whether there is a marker that we need to fetch the code for [hash].
+func HasCodeToFetch(db ethdb.KeyValueReader, hash common.Hash) bool {
+	ok, _ := db.Has(codeToFetchKey(hash))
+	return ok
+}
+
 // DeleteCodeToFetch removes the marker that the code corresponding to [hash] needs to be fetched.
 func DeleteCodeToFetch(db ethdb.KeyValueWriter, hash common.Hash) {
 	if err := db.Delete(codeToFetchKey(hash)); err != nil {
@@ -191,3 +197,23 @@ func GetLatestSyncPerformed(db ethdb.Iteratee) uint64 {
 	}
 	return latestSyncPerformed
 }
+
+// ReadStateBackfillHeight returns the height of the last block whose state was
+// reconstructed by the state backfill, and false if none was.
+func ReadStateBackfillHeight(db ethdb.KeyValueReader) (uint64, bool, error) {
+	has, err := db.Has(stateBackfillHeightKey)
+	if err != nil || !has {
+		return 0, false, err
+	}
+	height, err := db.Get(stateBackfillHeightKey)
+	if err != nil {
+		return 0, false, err
+	}
+	return binary.BigEndian.Uint64(height), true, nil
+}
+
+// WriteStateBackfillHeight writes [height] as the height of the last block
+// whose state was reconstructed by the state backfill.
+func WriteStateBackfillHeight(db ethdb.KeyValueWriter, height uint64) error {
+	return db.Put(stateBackfillHeightKey, encodeBlockNumber(height))
+}
@@ -300,6 +300,8 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		preimages       stat
 		bloomBits       stat
 		cliqueSnaps     stat
+		assetTransfers  stat
+		assetIndex      stat
 
 		// State sync statistics
 		codeToFetch   stat
@@ -356,6 +358,10 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 			preimages.Add(size)
 		case bytes.HasPrefix(key, configPrefix) && len(key) == (len(configPrefix)+common.HashLength):
 			metadata.Add(size)
+		case bytes.HasPrefix(key, assetTransfersPrefix) && len(key) == (len(assetTransfersPrefix)+8+common.HashLength):
+			assetTransfers.Add(size)
+		case bytes.HasPrefix(key, assetTransferIndexPrefix) && len(key) == (len(assetTransferIndexPrefix)+common.AddressLength+common.HashLength+8):
+			assetIndex.Add(size)
 		case bytes.HasPrefix(key, bloomBitsPrefix) && len(key) == (len(bloomBitsPrefix)+10+common.HashLength):
 			bloomBits.Add(size)
 		case bytes.HasPrefix(key, BloomBitsIndexPrefix):
@@ -401,6 +407,8 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		{"Key-Value store", "Block hash->number", hashNumPairings.Size(), hashNumPairings.Count()},
 		{"Key-Value store", "Transaction index", txLookups.Size(), txLookups.Count()},
 		{"Key-Value store", "Bloombit index", bloomBits.Size(), bloomBits.Count()},
+		{"Key-Value store", "Asset transfers", assetTransfers.Size(), assetTransfers.Count()},
+		{"Key-Value store", "Asset transfer index", assetIndex.Size(), assetIndex.Count()},
 		{"Key-Value store", "Contract codes", codes.Size(), codes.Count()},
 		{"Key-Value store", "Hash trie nodes", legacyTries.Size(), legacyTries.Count()},
 		{"Key-Value store", "Path trie state lookups", stateLookups.Size(), stateLookups.Count()},
@@ -96,6 +96,9 @@ var (
 	SnapshotStoragePrefix = []byte("o") // SnapshotStoragePrefix + account hash + storage hash -> storage trie value
 	CodePrefix            = []byte("c") // CodePrefix + code hash -> account code
 
+	assetTransfersPrefix     = []byte("x") // assetTransfersPrefix + num (uint64 big endian) + hash -> block multi-coin transfers
+	assetTransferIndexPrefix = []byte("X") // assetTransferIndexPrefix + address + asset id + num (uint64 big endian) -> empty value
+
 	// Path-based storage scheme of merkle patricia trie.
 	trieNodeAccountPrefix = []byte("A") // trieNodeAccountPrefix + hexPath -> trie node
 	trieNodeStoragePrefix = []byte("O") // trieNodeStoragePrefix + accountHash + hexPath -> trie node
@@ -124,6 +127,9 @@ var (
 	// State sync metadata
 	syncPerformedPrefix    = []byte("sync_performed")
 	syncPerformedKeyLength = len(syncPerformedPrefix) + wrappers.LongLen // prefix + block number as uint64
+
+	// stateBackfillHeightKey tracks the last block whose state was reconstructed below the state synced height
+	stateBackfillHeightKey = []byte("state_backfill_height")
 )
 
 // LegacyTxLookupEntry is the legacy TxLookupEntry definition with some unnecessary
@@ -171,6 +177,21 @@ func blockReceiptsKey(number uint64, hash common.Hash) []byte {
 	return append(append(blockReceiptsPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
 }
 
+// assetTransfersKey = assetTransfersPrefix + num (uint64 big endian) + hash
+func assetTransfersKey(number uint64, hash common.Hash) []byte {
+	return append(append(assetTransfersPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// assetTransferIndexKeyPrefix = assetTransferIndexPrefix + address + asset id
+func assetTransferIndexKeyPrefix(address common.Address, assetID common.Hash) []byte {
+	return append(append(assetTransferIndexPrefix, address.Bytes()...), assetID.Bytes()...)
+}
+
+// assetTransferIndexKey = assetTransferIndexPrefix + address + asset id + num (uint64 big endian)
+func assetTransferIndexKey(address common.Address, assetID common.Hash, number uint64) []byte {
+	return append(assetTransferIndexKeyPrefix(address, assetID), encodeBlockNumber(number)...)
+}
+
 // txLookupKey = txLookupPrefix + hash
 func txLookupKey(hash common.Hash) []byte {
 	return append(txLookupPrefix, hash.Bytes()...)
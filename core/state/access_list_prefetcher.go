@@ -0,0 +1,157 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package state
+
+import (
+	"github.com/ava-labs/coreth/core/types"
+	"github.com/ava-labs/coreth/metrics"
+	"github.com/ava-labs/coreth/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	accessListAccountLoadMeter = metrics.NewRegisteredMeter(triePrefetchMetricsPrefix+"accesslist/account/load", nil)
+	accessListAccountHitMeter  = metrics.NewRegisteredMeter(triePrefetchMetricsPrefix+"accesslist/account/hit", nil)
+	accessListAccountMissMeter = metrics.NewRegisteredMeter(triePrefetchMetricsPrefix+"accesslist/account/miss", nil)
+	accessListStorageLoadMeter = metrics.NewRegisteredMeter(triePrefetchMetricsPrefix+"accesslist/storage/load", nil)
+	accessListStorageHitMeter  = metrics.NewRegisteredMeter(triePrefetchMetricsPrefix+"accesslist/storage/hit", nil)
+	accessListStorageMissMeter = metrics.NewRegisteredMeter(triePrefetchMetricsPrefix+"accesslist/storage/miss", nil)
+)
+
+// accessListWarmup tracks the accounts and storage slots warmed by
+// [StateDB.PrefetchAccessList], so that the hit rate of the warm-up can be
+// reported once the state is no longer mutated.
+type accessListWarmup struct {
+	accounts map[common.Address]struct{}
+	slots    map[common.Address]map[common.Hash]struct{}
+}
+
+// accessListWarmupStats reports how the warmed accounts and storage slots
+// compare to the ones actually loaded while executing.
+type accessListWarmupStats struct {
+	accountHits, accountMisses int // Loaded accounts that were / were not warmed
+	storageHits, storageMisses int // Loaded storage slots that were / were not warmed
+}
+
+// PrefetchAccessList concurrently loads the accounts and storage slots in
+// [accesses], with their storage keys normalized, from the snapshot, using up
+// to [maxConcurrency] goroutines, so that the caches are warm when they are
+// accessed during execution. The accounts and slots are also scheduled on the
+// trie prefetcher, if one is running, to pull in the trie nodes needed when
+// hashing.
+//
+// PrefetchAccessList blocks until all the entries are loaded and is a no-op
+// when the state is not backed by a snapshot.
+func (s *StateDB) PrefetchAccessList(accesses map[common.Address][]common.Hash, maxConcurrency int) {
+	if s.snap == nil || len(accesses) == 0 {
+		return
+	}
+	var (
+		warmup = &accessListWarmup{
+			accounts: make(map[common.Address]struct{}, len(accesses)),
+			slots:    make(map[common.Address]map[common.Hash]struct{}),
+		}
+		roots   = make(map[common.Address]*common.Hash, len(accesses))
+		workers = utils.NewBoundedWorkers(maxConcurrency)
+		slots   int
+	)
+	for addr, keys := range accesses {
+		var (
+			addrHash = crypto.Keccak256Hash(addr.Bytes())
+			root     = new(common.Hash)
+		)
+		roots[addr] = root
+		warmup.accounts[addr] = struct{}{}
+		workers.Execute(func() {
+			// Errors are ignored, the account is loaded again during execution.
+			acc, err := s.snap.Account(addrHash)
+			if err == nil && acc != nil {
+				*root = common.BytesToHash(acc.Root)
+			}
+		})
+		if len(keys) == 0 {
+			continue
+		}
+		warmed := make(map[common.Hash]struct{}, len(keys))
+		for _, key := range keys {
+			// Storage keys are normalized when accessed by [StateDB.GetState],
+			// so the normalized key is the slot loaded during execution.
+			NormalizeStateKey(&key)
+			if _, ok := warmed[key]; ok {
+				continue
+			}
+			warmed[key] = struct{}{}
+			slotHash := crypto.Keccak256Hash(key.Bytes())
+			workers.Execute(func() {
+				s.snap.Storage(addrHash, slotHash) //nolint:errcheck
+			})
+		}
+		warmup.slots[addr] = warmed
+		slots += len(warmed)
+	}
+	workers.Wait()
+
+	accessListAccountLoadMeter.Mark(int64(len(warmup.accounts)))
+	accessListStorageLoadMeter.Mark(int64(slots))
+	s.accessListWarmup = warmup
+
+	if s.prefetcher == nil {
+		return
+	}
+	addressesToPrefetch := make([][]byte, 0, len(accesses))
+	for addr := range accesses {
+		addressesToPrefetch = append(addressesToPrefetch, common.CopyBytes(addr[:])) // Copy needed for closure
+	}
+	s.prefetcher.prefetch(common.Hash{}, s.originalRoot, common.Address{}, addressesToPrefetch)
+	for addr, keys := range warmup.slots {
+		root := *roots[addr]
+		if root == (common.Hash{}) || root == types.EmptyRootHash {
+			continue
+		}
+		slotsToPrefetch := make([][]byte, 0, len(keys))
+		for key := range keys {
+			slotsToPrefetch = append(slotsToPrefetch, common.CopyBytes(key[:])) // Copy needed for closure
+		}
+		s.prefetcher.prefetch(crypto.Keccak256Hash(addr.Bytes()), root, addr, slotsToPrefetch)
+	}
+}
+
+// accessListWarmupStats compares the accounts and storage slots warmed by
+// [PrefetchAccessList] with the ones loaded into the state.
+func (s *StateDB) accessListWarmupStats() accessListWarmupStats {
+	var stats accessListWarmupStats
+	warmup := s.accessListWarmup
+	for addr, obj := range s.stateObjects {
+		if _, ok := warmup.accounts[addr]; ok {
+			stats.accountHits++
+		} else {
+			stats.accountMisses++
+		}
+		warmed := warmup.slots[addr]
+		for key := range obj.originStorage {
+			if _, ok := warmed[key]; ok {
+				stats.storageHits++
+			} else {
+				stats.storageMisses++
+			}
+		}
+	}
+	return stats
+}
+
+// reportAccessListWarmup publishes the hit rate of the access list warm-up,
+// if there was one.
+func (s *StateDB) reportAccessListWarmup() {
+	if s.accessListWarmup == nil {
+		return
+	}
+	stats := s.accessListWarmupStats()
+	s.accessListWarmup = nil
+
+	accessListAccountHitMeter.Mark(int64(stats.accountHits))
+	accessListAccountMissMeter.Mark(int64(stats.accountMisses))
+	accessListStorageHitMeter.Mark(int64(stats.storageHits))
+	accessListStorageMissMeter.Mark(int64(stats.storageMisses))
+}
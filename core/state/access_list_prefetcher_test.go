@@ -0,0 +1,80 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ava-labs/coreth/core/rawdb"
+	"github.com/ava-labs/coreth/core/state/snapshot"
+	"github.com/ava-labs/coreth/core/types"
+	"github.com/ava-labs/coreth/triedb"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefetchAccessList(t *testing.T) {
+	require := require.New(t)
+
+	var (
+		disk     = rawdb.NewMemoryDatabase()
+		tdb      = triedb.NewDatabase(disk, nil)
+		db       = NewDatabaseWithNodeDB(disk, tdb)
+		snaps, _ = snapshot.New(snapshot.Config{CacheSize: 10}, disk, tdb, common.Hash{}, types.EmptyRootHash)
+		state, _ = New(types.EmptyRootHash, db, snaps)
+		addrA    = common.HexToAddress("0xa")
+		addrB    = common.HexToAddress("0xb")
+		addrC    = common.HexToAddress("0xc")
+		slot1    = common.HexToHash("0x1")
+		slot2    = common.HexToHash("0x2")
+		// [slot3] is normalized to [slot2] when accessed.
+		slot3 = common.HexToHash("0x0100000000000000000000000000000000000000000000000000000000000002")
+	)
+	state.SetBalance(addrA, uint256.NewInt(1))
+	state.SetState(addrA, slot1, common.HexToHash("0x11"))
+	state.SetState(addrA, slot2, common.HexToHash("0x22"))
+	state.SetBalance(addrB, uint256.NewInt(2))
+	state.SetBalance(addrC, uint256.NewInt(3))
+	root, err := state.CommitWithSnap(0, true, snaps, common.HexToHash("0x1"), common.Hash{}, false)
+	require.NoError(err)
+
+	state, err = New(root, db, snaps)
+	require.NoError(err)
+	state.StartPrefetcher("test", 4)
+	defer state.StopPrefetcher()
+
+	state.PrefetchAccessList(map[common.Address][]common.Hash{
+		addrA: {slot1, slot1},
+		addrB: {slot3},
+	}, 4)
+	require.NotNil(state.accessListWarmup)
+	require.Len(state.accessListWarmup.accounts, 2)
+	require.Len(state.accessListWarmup.slots[addrA], 1)
+	require.Contains(state.accessListWarmup.slots[addrB], slot2)
+
+	// The storage trie of [addrA] is scheduled on the trie prefetcher.
+	storageRoot := state.GetStorageRoot(addrA)
+	require.NotEqual(types.EmptyRootHash, storageRoot)
+	require.Eventually(func() bool {
+		return state.prefetcher.trie(crypto.Keccak256Hash(addrA.Bytes()), storageRoot) != nil
+	}, time.Second, 10*time.Millisecond)
+
+	require.Equal(common.HexToHash("0x11"), state.GetState(addrA, slot1))
+	require.Equal(common.HexToHash("0x22"), state.GetState(addrA, slot2))
+	require.Equal(uint256.NewInt(3), state.GetBalance(addrC))
+
+	// [addrB] was warmed but not accessed, so it is neither a hit nor a miss.
+	require.Equal(accessListWarmupStats{
+		accountHits:   1,
+		accountMisses: 1,
+		storageHits:   1,
+		storageMisses: 1,
+	}, state.accessListWarmupStats())
+
+	state.StopPrefetcher()
+	require.Nil(state.accessListWarmup)
+}
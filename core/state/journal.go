@@ -143,6 +143,9 @@ type (
 	addLogChange struct {
 		txhash common.Hash
 	}
+	addAssetTransferChange struct {
+		prev int // number of asset transfers before the change
+	}
 	addPreimageChange struct {
 		hash common.Hash
 	}
@@ -287,6 +290,14 @@ func (ch addLogChange) dirtied() *common.Address {
 	return nil
 }
 
+func (ch addAssetTransferChange) revert(s *StateDB) {
+	s.assetTransfers = s.assetTransfers[:ch.prev]
+}
+
+func (ch addAssetTransferChange) dirtied() *common.Address {
+	return nil
+}
+
 func (ch addPreimageChange) revert(s *StateDB) {
 	delete(s.preimages, ch.hash)
 }
@@ -141,3 +141,10 @@ func loadSnapshot(diskdb ethdb.KeyValueStore, triedb *triedb.Database, cache int
 func ResetSnapshotGeneration(db ethdb.KeyValueWriter) {
 	journalProgress(db, nil, nil)
 }
+
+// RestartSnapshotGeneration writes a snapshot generator marker to [db] so the
+// snapshot is generated again from the start when it is loaded. Entries that
+// match the trie are kept and the others are rewritten.
+func RestartSnapshotGeneration(db ethdb.KeyValueWriter) {
+	journalProgress(db, []byte{}, nil)
+}
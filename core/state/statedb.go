@@ -81,6 +81,10 @@ type StateDB struct {
 	// It will be updated when the Commit is called.
 	originalRoot common.Hash
 
+	// accessListWarmup holds the accounts and storage slots warmed by
+	// PrefetchAccessList, to report the warm-up hit rate.
+	accessListWarmup *accessListWarmup
+
 	// These maps hold the state changes (including the corresponding
 	// original value) that occurred in this **block**.
 	accounts       map[common.Hash][]byte                    // The mutated accounts in 'slim RLP' encoding
@@ -113,6 +117,11 @@ type StateDB struct {
 	logs    map[common.Hash][]*types.Log
 	logSize uint
 
+	// The atomic tx context and all multi-coin balance changes occurred in
+	// the scope of block.
+	atomicTxID     *common.Hash
+	assetTransfers []*types.AssetTransfer
+
 	// Preimages occurred seen by VM in the scope of block.
 	preimages map[common.Hash][]byte
 
@@ -217,6 +226,7 @@ func (s *StateDB) StartPrefetcher(namespace string, maxConcurrency int) {
 // StopPrefetcher terminates a running prefetcher and reports any leftover stats
 // from the gathered metrics.
 func (s *StateDB) StopPrefetcher() {
+	s.reportAccessListWarmup()
 	if s.prefetcher != nil {
 		s.prefetcher.close()
 		s.prefetcher = nil
@@ -474,6 +484,7 @@ func (s *StateDB) AddBalanceMultiCoin(addr common.Address, coinID common.Hash, a
 	stateObject := s.getOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.AddBalanceMultiCoin(coinID, amount, s.db)
+		s.addAssetTransfer(addr, coinID, amount, true)
 	}
 }
 
@@ -482,7 +493,37 @@ func (s *StateDB) SubBalanceMultiCoin(addr common.Address, coinID common.Hash, a
 	stateObject := s.getOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.SubBalanceMultiCoin(coinID, amount, s.db)
+		s.addAssetTransfer(addr, coinID, amount, false)
+	}
+}
+
+// addAssetTransfer records a non-zero change to the multi-coin balance of
+// [addr] and attributes it to the current tx context.
+func (s *StateDB) addAssetTransfer(addr common.Address, coinID common.Hash, amount *big.Int, incoming bool) {
+	if amount.Sign() == 0 {
+		return
+	}
+	transfer := &types.AssetTransfer{
+		Address:  addr,
+		AssetID:  coinID,
+		Amount:   new(big.Int).Set(amount),
+		Incoming: incoming,
+		TxHash:   s.thash,
+		TxIndex:  uint(s.txIndex),
 	}
+	if s.atomicTxID != nil {
+		transfer.TxHash = *s.atomicTxID
+		transfer.TxIndex = 0
+		transfer.Atomic = true
+	}
+	s.journal.append(addAssetTransferChange{prev: len(s.assetTransfers)})
+	s.assetTransfers = append(s.assetTransfers, transfer)
+}
+
+// AssetTransfers returns the multi-coin balance changes occurred in the
+// scope of block, in the order they were made.
+func (s *StateDB) AssetTransfers() []*types.AssetTransfer {
+	return s.assetTransfers
 }
 
 func (s *StateDB) SetBalanceMultiCoin(addr common.Address, coinID common.Hash, amount *big.Int) {
@@ -875,6 +916,19 @@ func (s *StateDB) Copy() *StateDB {
 		}
 		state.logs[hash] = cpy
 	}
+	// Deep copy the multi-coin balance changes occurred in the scope of block
+	if s.atomicTxID != nil {
+		atomicTxID := *s.atomicTxID
+		state.atomicTxID = &atomicTxID
+	}
+	if len(s.assetTransfers) > 0 {
+		state.assetTransfers = make([]*types.AssetTransfer, len(s.assetTransfers))
+		for i, transfer := range s.assetTransfers {
+			cpy := *transfer
+			cpy.Amount = new(big.Int).Set(transfer.Amount)
+			state.assetTransfers[i] = &cpy
+		}
+	}
 	// Deep copy the preimages occurred in the scope of block
 	for hash, preimage := range s.preimages {
 		state.preimages[hash] = preimage
@@ -1047,6 +1101,14 @@ func (s *StateDB) IntermediateRoot(deleteEmptyObjects bool) common.Hash {
 func (s *StateDB) SetTxContext(thash common.Hash, ti int) {
 	s.thash = thash
 	s.txIndex = ti
+	s.atomicTxID = nil
+}
+
+// SetAtomicTxContext sets the ID of the atomic transaction being applied to
+// the state. Multi-coin balance changes made afterwards are attributed to it
+// until the next call to SetTxContext.
+func (s *StateDB) SetAtomicTxContext(txID common.Hash) {
+	s.atomicTxID = &txID
 }
 
 func (s *StateDB) clearJournalAndRefund() {
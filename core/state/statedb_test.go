@@ -1064,6 +1064,36 @@ func TestMultiCoinOperations(t *testing.T) {
 	}
 }
 
+func TestMultiCoinAssetTransfers(t *testing.T) {
+	s := newStateEnv()
+	addr := common.Address{1}
+	other := common.Address{2}
+	assetID := common.Hash{3}
+
+	s.state.SetTxContext(common.Hash{4}, 1)
+	s.state.AddBalanceMultiCoin(addr, assetID, big.NewInt(10))
+	s.state.AddBalanceMultiCoin(addr, assetID, new(big.Int)) // zero amounts are not recorded
+
+	snapshot := s.state.Snapshot()
+	s.state.SubBalanceMultiCoin(addr, assetID, big.NewInt(3))
+	s.state.RevertToSnapshot(snapshot)
+
+	s.state.SetAtomicTxContext(common.Hash{5})
+	s.state.SubBalanceMultiCoin(addr, assetID, big.NewInt(4))
+	s.state.SetBalanceMultiCoin(other, assetID, big.NewInt(1)) // direct writes are not recorded
+
+	expected := []*types.AssetTransfer{
+		{Address: addr, AssetID: assetID, Amount: big.NewInt(10), Incoming: true, TxHash: common.Hash{4}, TxIndex: 1},
+		{Address: addr, AssetID: assetID, Amount: big.NewInt(4), TxHash: common.Hash{5}, Atomic: true},
+	}
+	if !reflect.DeepEqual(s.state.AssetTransfers(), expected) {
+		t.Fatalf("unexpected asset transfers: %v", s.state.AssetTransfers())
+	}
+	if !reflect.DeepEqual(s.state.Copy().AssetTransfers(), expected) {
+		t.Fatal("copy does not contain asset transfers")
+	}
+}
+
 func TestMultiCoinSnapshot(t *testing.T) {
 	db := rawdb.NewMemoryDatabase()
 	sdb := NewDatabase(db)
@@ -0,0 +1,420 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ava-labs/coreth/core/state"
+	"github.com/ava-labs/coreth/core/types"
+	"github.com/ava-labs/coreth/core/vm"
+	"github.com/ava-labs/coreth/metrics"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+)
+
+var (
+	parallelTxCommittedCounter  = metrics.NewRegisteredCounter("chain/parallel/txs/committed", nil)
+	parallelTxReexecutedCounter = metrics.NewRegisteredCounter("chain/parallel/txs/reexecuted", nil)
+)
+
+var _ vm.StateDB = (*recordingStateDB)(nil)
+
+// stateKey identifies a piece of state read or written by a transaction.
+type stateKey struct {
+	kind stateKeyKind
+	addr common.Address
+	slot common.Hash
+}
+
+type stateKeyKind uint8
+
+const (
+	accountKey   stateKeyKind = iota // balance, nonce, code and existence of an account
+	lifecycleKey                     // creation and destruction of an account's storage
+	slotKey                          // a single storage slot
+)
+
+// recordingStateDB wraps the StateDB a transaction is executed against. It
+// records the state observed by the transaction and the writes it makes, so
+// that the writes can be replayed on another StateDB as long as the observed
+// state is unchanged there.
+type recordingStateDB struct {
+	*state.StateDB
+
+	reads     map[stateKey]struct{}
+	writes    map[stateKey]struct{}
+	ops       []func(*state.StateDB)
+	revisions map[int]int // Snapshot id -> number of [ops] when it was taken
+
+	// untracked is set if the transaction observed state that is not tracked
+	// by [reads], in which case its writes must not be replayed.
+	untracked bool
+}
+
+func newRecordingStateDB(statedb *state.StateDB) *recordingStateDB {
+	return &recordingStateDB{
+		StateDB:   statedb,
+		reads:     make(map[stateKey]struct{}),
+		writes:    make(map[stateKey]struct{}),
+		revisions: make(map[int]int),
+	}
+}
+
+func (r *recordingStateDB) read(kind stateKeyKind, addr common.Address, slot common.Hash) {
+	r.reads[stateKey{kind: kind, addr: addr, slot: slot}] = struct{}{}
+}
+
+// write records a write to the given key and the operation replaying it, if
+// any. Operations touching several keys are only appended once.
+func (r *recordingStateDB) write(kind stateKeyKind, addr common.Address, slot common.Hash, op func(*state.StateDB)) {
+	r.writes[stateKey{kind: kind, addr: addr, slot: slot}] = struct{}{}
+	if op != nil {
+		r.ops = append(r.ops, op)
+	}
+}
+
+// conflicts returns true if the transaction observed any state in [written].
+func (r *recordingStateDB) conflicts(written map[stateKey]struct{}) bool {
+	if r.untracked {
+		return true
+	}
+	for key := range r.reads {
+		if _, ok := written[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// replay applies the writes made by the transaction to [statedb].
+func (r *recordingStateDB) replay(statedb *state.StateDB) {
+	for _, op := range r.ops {
+		op(statedb)
+	}
+}
+
+// multiCoinSlot returns the storage slot holding the balance of [coinID].
+func multiCoinSlot(coinID common.Hash) common.Hash {
+	state.NormalizeCoinID(&coinID)
+	return coinID
+}
+
+// normalizedSlot returns the storage slot accessed by GetState and SetState.
+func normalizedSlot(key common.Hash) common.Hash {
+	state.NormalizeStateKey(&key)
+	return key
+}
+
+func (r *recordingStateDB) GetBalance(addr common.Address) *uint256.Int {
+	r.read(accountKey, addr, common.Hash{})
+	return r.StateDB.GetBalance(addr)
+}
+
+func (r *recordingStateDB) GetBalanceMultiCoin(addr common.Address, coinID common.Hash) *big.Int {
+	r.read(accountKey, addr, common.Hash{})
+	r.read(lifecycleKey, addr, common.Hash{})
+	r.read(slotKey, addr, multiCoinSlot(coinID))
+	return r.StateDB.GetBalanceMultiCoin(addr, coinID)
+}
+
+func (r *recordingStateDB) GetNonce(addr common.Address) uint64 {
+	r.read(accountKey, addr, common.Hash{})
+	return r.StateDB.GetNonce(addr)
+}
+
+func (r *recordingStateDB) GetCodeHash(addr common.Address) common.Hash {
+	r.read(accountKey, addr, common.Hash{})
+	return r.StateDB.GetCodeHash(addr)
+}
+
+func (r *recordingStateDB) GetCode(addr common.Address) []byte {
+	r.read(accountKey, addr, common.Hash{})
+	return r.StateDB.GetCode(addr)
+}
+
+func (r *recordingStateDB) GetCodeSize(addr common.Address) int {
+	r.read(accountKey, addr, common.Hash{})
+	return r.StateDB.GetCodeSize(addr)
+}
+
+func (r *recordingStateDB) GetCommittedState(addr common.Address, key common.Hash) common.Hash {
+	r.read(lifecycleKey, addr, common.Hash{})
+	r.read(slotKey, addr, key)
+	return r.StateDB.GetCommittedState(addr, key)
+}
+
+func (r *recordingStateDB) GetCommittedStateAP1(addr common.Address, key common.Hash) common.Hash {
+	r.read(lifecycleKey, addr, common.Hash{})
+	r.read(slotKey, addr, normalizedSlot(key))
+	return r.StateDB.GetCommittedStateAP1(addr, key)
+}
+
+func (r *recordingStateDB) GetState(addr common.Address, key common.Hash) common.Hash {
+	r.read(lifecycleKey, addr, common.Hash{})
+	r.read(slotKey, addr, normalizedSlot(key))
+	return r.StateDB.GetState(addr, key)
+}
+
+func (r *recordingStateDB) HasSelfDestructed(addr common.Address) bool {
+	r.read(accountKey, addr, common.Hash{})
+	return r.StateDB.HasSelfDestructed(addr)
+}
+
+func (r *recordingStateDB) Exist(addr common.Address) bool {
+	r.read(accountKey, addr, common.Hash{})
+	return r.StateDB.Exist(addr)
+}
+
+func (r *recordingStateDB) Empty(addr common.Address) bool {
+	r.read(accountKey, addr, common.Hash{})
+	return r.StateDB.Empty(addr)
+}
+
+// GetLogData returns the logs of every transaction in the block so far, which
+// is not tracked.
+func (r *recordingStateDB) GetLogData() ([][]common.Hash, [][]byte) {
+	r.untracked = true
+	return r.StateDB.GetLogData()
+}
+
+func (r *recordingStateDB) CreateAccount(addr common.Address) {
+	r.write(accountKey, addr, common.Hash{}, nil)
+	r.write(lifecycleKey, addr, common.Hash{}, func(s *state.StateDB) { s.CreateAccount(addr) })
+	r.StateDB.CreateAccount(addr)
+}
+
+func (r *recordingStateDB) SubBalance(addr common.Address, amount *uint256.Int) {
+	amount = amount.Clone()
+	r.write(accountKey, addr, common.Hash{}, func(s *state.StateDB) { s.SubBalance(addr, amount) })
+	r.StateDB.SubBalance(addr, amount)
+}
+
+func (r *recordingStateDB) AddBalance(addr common.Address, amount *uint256.Int) {
+	amount = amount.Clone()
+	r.write(accountKey, addr, common.Hash{}, func(s *state.StateDB) { s.AddBalance(addr, amount) })
+	r.StateDB.AddBalance(addr, amount)
+}
+
+func (r *recordingStateDB) SubBalanceMultiCoin(addr common.Address, coinID common.Hash, amount *big.Int) {
+	amount = new(big.Int).Set(amount)
+	r.write(accountKey, addr, common.Hash{}, nil)
+	r.write(slotKey, addr, multiCoinSlot(coinID), func(s *state.StateDB) { s.SubBalanceMultiCoin(addr, coinID, amount) })
+	r.StateDB.SubBalanceMultiCoin(addr, coinID, amount)
+}
+
+func (r *recordingStateDB) AddBalanceMultiCoin(addr common.Address, coinID common.Hash, amount *big.Int) {
+	amount = new(big.Int).Set(amount)
+	r.write(accountKey, addr, common.Hash{}, nil)
+	r.write(slotKey, addr, multiCoinSlot(coinID), func(s *state.StateDB) { s.AddBalanceMultiCoin(addr, coinID, amount) })
+	r.StateDB.AddBalanceMultiCoin(addr, coinID, amount)
+}
+
+func (r *recordingStateDB) SetNonce(addr common.Address, nonce uint64) {
+	r.write(accountKey, addr, common.Hash{}, func(s *state.StateDB) { s.SetNonce(addr, nonce) })
+	r.StateDB.SetNonce(addr, nonce)
+}
+
+func (r *recordingStateDB) SetCode(addr common.Address, code []byte) {
+	code = common.CopyBytes(code)
+	r.write(accountKey, addr, common.Hash{}, func(s *state.StateDB) { s.SetCode(addr, code) })
+	r.StateDB.SetCode(addr, code)
+}
+
+func (r *recordingStateDB) SetState(addr common.Address, key common.Hash, value common.Hash) {
+	r.write(slotKey, addr, normalizedSlot(key), func(s *state.StateDB) { s.SetState(addr, key, value) })
+	r.StateDB.SetState(addr, key, value)
+}
+
+func (r *recordingStateDB) SelfDestruct(addr common.Address) {
+	r.write(accountKey, addr, common.Hash{}, nil)
+	r.write(lifecycleKey, addr, common.Hash{}, func(s *state.StateDB) { s.SelfDestruct(addr) })
+	r.StateDB.SelfDestruct(addr)
+}
+
+func (r *recordingStateDB) Selfdestruct6780(addr common.Address) {
+	r.write(accountKey, addr, common.Hash{}, nil)
+	r.write(lifecycleKey, addr, common.Hash{}, func(s *state.StateDB) { s.Selfdestruct6780(addr) })
+	r.StateDB.Selfdestruct6780(addr)
+}
+
+func (r *recordingStateDB) AddLog(addr common.Address, topics []common.Hash, data []byte, blockNumber uint64) {
+	r.ops = append(r.ops, func(s *state.StateDB) { s.AddLog(addr, topics, data, blockNumber) })
+	r.StateDB.AddLog(addr, topics, data, blockNumber)
+}
+
+func (r *recordingStateDB) AddPreimage(hash common.Hash, preimage []byte) {
+	r.ops = append(r.ops, func(s *state.StateDB) { s.AddPreimage(hash, preimage) })
+	r.StateDB.AddPreimage(hash, preimage)
+}
+
+func (r *recordingStateDB) Snapshot() int {
+	id := r.StateDB.Snapshot()
+	r.revisions[id] = len(r.ops)
+	return id
+}
+
+func (r *recordingStateDB) RevertToSnapshot(id int) {
+	// Drop the writes made after the snapshot. Their keys are kept in
+	// [writes], which can only cause unnecessary re-executions.
+	r.ops = r.ops[:r.revisions[id]]
+	r.StateDB.RevertToSnapshot(id)
+}
+
+// speculativeResult is the outcome of executing a transaction against a copy
+// of the state at the start of the parallel section of a block.
+type speculativeResult struct {
+	msg    *Message
+	evm    *vm.EVM
+	state  *recordingStateDB
+	result *ExecutionResult
+	stats  *vm.OpcodeStats
+	err    error
+}
+
+// parallelStats reports how the transactions of a block were executed.
+type parallelStats struct {
+	committed  int // Transactions whose speculative writes were replayed
+	reexecuted int // Transactions executed again after a conflict
+}
+
+// applyTransactionsParallel applies the transactions of [block] to [statedb]
+// using optimistic concurrency. The first transaction is applied directly, then
+// the remaining ones are speculatively executed in parallel by [workers]
+// goroutines against copies of the resulting state. The speculative writes are
+// replayed on [statedb] in block order, unless the transaction observed state
+// written by an earlier transaction in the block, in which case it is executed
+// again. The receipts, logs and state are identical to sequential execution.
+func (p *StateProcessor) applyTransactionsParallel(block *types.Block, workers int, signer types.Signer, gp *GasPool, statedb *state.StateDB, usedGas *uint64, vmenv *vm.EVM, cfg vm.Config) (types.Receipts, []*types.Log, parallelStats, error) {
+	var (
+		txs         = block.Transactions()
+		header      = block.Header()
+		blockHash   = block.Hash()
+		blockNumber = block.Number()
+		receipts    = make(types.Receipts, 0, len(txs))
+		allLogs     []*types.Log
+		stats       parallelStats
+		written     = make(map[stateKey]struct{})
+	)
+	// apply executes the transaction at [i] directly on [statedb], recording
+	// its writes so that later transactions can be checked against them.
+	apply := func(i int, msg *Message) (*types.Receipt, error) {
+		statedb.SetTxContext(txs[i].Hash(), i)
+		recorder := newRecordingStateDB(statedb)
+		vmenv.Reset(NewEVMTxContext(msg), recorder)
+		result, err := ApplyMessage(vmenv, msg, gp)
+		if err != nil {
+			return nil, err
+		}
+		for key := range recorder.writes {
+			written[key] = struct{}{}
+		}
+		return finaliseTransaction(msg, p.config, result, statedb, blockNumber, blockHash, txs[i], usedGas, vmenv), nil
+	}
+
+	msg, err := TransactionToMessage(txs[0], signer, header.BaseFee)
+	if err != nil {
+		return nil, nil, stats, fmt.Errorf("could not apply tx %d [%v]: %w", 0, txs[0].Hash().Hex(), err)
+	}
+	receipt, err := apply(0, msg)
+	if err != nil {
+		return nil, nil, stats, fmt.Errorf("could not apply tx %d [%v]: %w", 0, txs[0].Hash().Hex(), err)
+	}
+	receipts = append(receipts, receipt)
+	allLogs = append(allLogs, receipt.Logs...)
+	// The speculative executions start from the state after the first
+	// transaction, so its writes cannot conflict with them.
+	clear(written)
+
+	// Speculatively execute the remaining transactions. The copies are taken
+	// upfront since [statedb] is not safe to copy concurrently.
+	results := make([]*speculativeResult, len(txs))
+	copies := make([]*state.StateDB, len(txs))
+	for i := 1; i < len(txs); i++ {
+		copies[i] = statedb.Copy()
+		copies[i].StopPrefetcher()
+	}
+	var (
+		wg    sync.WaitGroup
+		tasks = make(chan int)
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			blockContext := NewEVMBlockContext(header, p.bc, nil)
+			for i := range tasks {
+				results[i] = p.speculate(txs[i], i, signer, header.BaseFee, blockContext, copies[i], cfg)
+				copies[i] = nil
+			}
+		}()
+	}
+	for i := 1; i < len(txs); i++ {
+		tasks <- i
+	}
+	close(tasks)
+	wg.Wait()
+
+	// Commit the transactions in block order.
+	for i := 1; i < len(txs); i++ {
+		tx, spec := txs[i], results[i]
+		if spec.err == nil && !spec.state.conflicts(written) && gp.Gas() >= spec.msg.GasLimit {
+			statedb.SetTxContext(tx.Hash(), i)
+			spec.state.replay(statedb)
+			if err := gp.SubGas(spec.result.UsedGas); err != nil {
+				return nil, nil, stats, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+			}
+			for key := range spec.state.writes {
+				written[key] = struct{}{}
+			}
+			if cfg.OpcodeStats != nil {
+				cfg.OpcodeStats.Merge(spec.stats)
+			}
+			receipt = finaliseTransaction(spec.msg, p.config, spec.result, statedb, blockNumber, blockHash, tx, usedGas, spec.evm)
+			stats.committed++
+		} else {
+			msg := spec.msg
+			if msg == nil {
+				msg, err = TransactionToMessage(tx, signer, header.BaseFee)
+				if err != nil {
+					return nil, nil, stats, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+				}
+			}
+			receipt, err = apply(i, msg)
+			if err != nil {
+				return nil, nil, stats, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+			}
+			stats.reexecuted++
+		}
+		receipts = append(receipts, receipt)
+		allLogs = append(allLogs, receipt.Logs...)
+	}
+	parallelTxCommittedCounter.Inc(int64(stats.committed))
+	parallelTxReexecutedCounter.Inc(int64(stats.reexecuted))
+	return receipts, allLogs, stats, nil
+}
+
+// speculate executes [tx] against [statedb], recording the state it observes
+// and the writes it makes.
+func (p *StateProcessor) speculate(tx *types.Transaction, i int, signer types.Signer, baseFee *big.Int, blockContext vm.BlockContext, statedb *state.StateDB, cfg vm.Config) *speculativeResult {
+	msg, err := TransactionToMessage(tx, signer, baseFee)
+	if err != nil {
+		return &speculativeResult{err: err}
+	}
+	statedb.SetTxContext(tx.Hash(), i)
+	spec := &speculativeResult{
+		msg:   msg,
+		state: newRecordingStateDB(statedb),
+	}
+	if cfg.OpcodeStats != nil {
+		spec.stats = new(vm.OpcodeStats)
+		cfg.OpcodeStats = spec.stats
+	}
+	spec.evm = vm.NewEVM(blockContext, NewEVMTxContext(msg), spec.state, p.config, cfg)
+	// The gas pool is checked against the block when the result is committed.
+	spec.result, spec.err = ApplyMessage(spec.evm, msg, new(GasPool).AddGas(msg.GasLimit))
+	return spec
+}
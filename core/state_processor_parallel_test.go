@@ -0,0 +1,130 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ava-labs/coreth/consensus/dummy"
+	"github.com/ava-labs/coreth/core/rawdb"
+	"github.com/ava-labs/coreth/core/types"
+	"github.com/ava-labs/coreth/core/vm"
+	"github.com/ava-labs/coreth/params"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParallelTxExecution is a differential test checking that the optimistic
+// parallel execution of block transactions produces the same receipts and
+// state roots as the sequential execution.
+func TestParallelTxExecution(t *testing.T) {
+	require := require.New(t)
+
+	var (
+		engine    = dummy.NewCoinbaseFaker()
+		funds     = new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether))
+		gasPrice  = big.NewInt(300 * params.GWei)
+		recipient = common.HexToAddress("0xdeadbeef")
+
+		// increments slot 0
+		counter = common.HexToAddress("0x1000")
+		// stores the block number at the slot of the caller and emits a log
+		store = common.HexToAddress("0x2000")
+		// always reverts
+		reverter = common.HexToAddress("0x3000")
+		// stores the balance of [recipient] at slot 0
+		balanceReader = common.HexToAddress("0x4000")
+
+		keys  []*ecdsa.PrivateKey
+		alloc = types.GenesisAlloc{
+			counter:       {Code: common.FromHex("60005460010160005500")},
+			store:         {Code: common.FromHex("433355600060006000a000")},
+			reverter:      {Code: common.FromHex("600080fd")},
+			balanceReader: {Code: common.FromHex("73" + common.Bytes2Hex(recipient.Bytes()) + "3160005500")},
+		}
+	)
+	for i := 0; i < 8; i++ {
+		key, err := crypto.GenerateKey()
+		require.NoError(err)
+		keys = append(keys, key)
+		alloc[crypto.PubkeyToAddress(key.PublicKey)] = types.GenesisAccount{Balance: funds}
+	}
+	gspec := &Genesis{
+		Config:  params.TestChainConfig,
+		Alloc:   alloc,
+		BaseFee: big.NewInt(params.ApricotPhase3InitialBaseFee),
+	}
+	signer := types.LatestSigner(gspec.Config)
+
+	_, blocks, _, err := GenerateChainWithGenesis(gspec, engine, 4, 10, func(i int, b *BlockGen) {
+		// Every sender issues two transactions per block, so that the second
+		// one conflicts with the first through the sender's nonce and balance.
+		for j := 0; j < 2; j++ {
+			for k, key := range keys {
+				var (
+					nonce = b.TxNonce(crypto.PubkeyToAddress(key.PublicKey))
+					tx    *types.Transaction
+				)
+				switch (i + j + k) % 6 {
+				case 0:
+					tx = types.NewTransaction(nonce, recipient, big.NewInt(1), params.TxGas, gasPrice, nil)
+				case 1:
+					tx = types.NewTransaction(nonce, counter, common.Big0, 100_000, gasPrice, nil)
+				case 2:
+					tx = types.NewTransaction(nonce, store, common.Big0, 100_000, gasPrice, nil)
+				case 3:
+					tx = types.NewTransaction(nonce, reverter, common.Big0, 100_000, gasPrice, nil)
+				case 4:
+					tx = types.NewTransaction(nonce, balanceReader, common.Big0, 100_000, gasPrice, nil)
+				case 5:
+					// deploys a contract returning empty code
+					tx = types.NewContractCreation(nonce, common.Big0, 100_000, gasPrice, common.FromHex("60006000f3"))
+				}
+				signedTx, err := types.SignTx(tx, signer, key)
+				require.NoError(err)
+				b.AddTx(signedTx)
+			}
+		}
+	})
+	require.NoError(err)
+
+	sequential, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfig, gspec, engine, vm.Config{}, common.Hash{}, false)
+	require.NoError(err)
+	defer sequential.Stop()
+
+	parallelConfig := *DefaultCacheConfig
+	parallelConfig.ParallelTxExecutionWorkers = 4
+	parallel, err := NewBlockChain(rawdb.NewMemoryDatabase(), &parallelConfig, gspec, engine, vm.Config{}, common.Hash{}, false)
+	require.NoError(err)
+	defer parallel.Stop()
+
+	// Inserting the blocks validates the state root and receipts root.
+	_, err = sequential.InsertChain(blocks)
+	require.NoError(err)
+	_, err = parallel.InsertChain(blocks)
+	require.NoError(err)
+
+	var committed, reexecuted int
+	for _, block := range blocks {
+		require.Equal(sequential.GetReceiptsByHash(block.Hash()), parallel.GetReceiptsByHash(block.Hash()))
+
+		parent := parallel.GetBlockByHash(block.ParentHash())
+		statedb, err := parallel.StateAt(parent.Root())
+		require.NoError(err)
+		vmenv := vm.NewEVM(NewEVMBlockContext(block.Header(), parallel, nil), vm.TxContext{}, statedb, gspec.Config, vm.Config{})
+		processor := parallel.processor.(*StateProcessor)
+		_, _, stats, err := processor.applyTransactionsParallel(block, 4, signer, new(GasPool).AddGas(block.GasLimit()), statedb, new(uint64), vmenv, vm.Config{})
+		require.NoError(err)
+		require.Equal(len(block.Transactions())-1, stats.committed+stats.reexecuted)
+		committed += stats.committed
+		reexecuted += stats.reexecuted
+	}
+	// The blocks must exercise both the replay of speculative executions and
+	// the re-execution of conflicting transactions.
+	require.Positive(committed)
+	require.Positive(reexecuted)
+}
@@ -1544,18 +1544,24 @@ func (p *BlobPool) Pending(filter txpool.PendingFilter) map[common.Address][]*tx
 // IteratePending iterates over [pool.pending] until [f] returns false.
 // The caller must not modify [tx]. Returns false if iteration was interrupted.
 func (pool *BlobPool) IteratePending(f func(tx *types.Transaction) bool) bool {
+	// Collect the hashes first, as [Get] acquires the read lock itself and
+	// recursive read locking may deadlock with a pending writer.
 	pool.lock.RLock()
-	defer pool.lock.RUnlock()
-
+	var hashes []common.Hash
 	for _, list := range pool.index {
 		for _, txId := range list {
-			tx := pool.Get(txId.hash)
-			if tx == nil {
-				continue
-			}
-			if !f(tx) {
-				return false
-			}
+			hashes = append(hashes, txId.hash)
+		}
+	}
+	pool.lock.RUnlock()
+
+	for _, hash := range hashes {
+		tx := pool.Get(hash)
+		if tx == nil {
+			continue
+		}
+		if !f(tx) {
+			return false
 		}
 	}
 	return true
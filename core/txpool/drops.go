@@ -0,0 +1,130 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package txpool
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/coreth/core/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DropReason records why a transaction was rejected or dropped by a subpool.
+type DropReason struct {
+	Hash   common.Hash
+	From   common.Address
+	Nonce  uint64
+	Reason string
+	Time   time.Time
+}
+
+// DropLog is a bounded ring of the most recently rejected or dropped
+// transactions, indexed by hash. It is safe for concurrent use.
+type DropLog struct {
+	lock    sync.RWMutex
+	entries []*DropReason // Ring buffer of the most recent drops
+	next    int           // Index of the next slot to overwrite
+	index   map[common.Hash]*DropReason
+}
+
+// NewDropLog returns a DropLog retaining the last [size] drops.
+func NewDropLog(size int) *DropLog {
+	return &DropLog{
+		entries: make([]*DropReason, size),
+		index:   make(map[common.Hash]*DropReason, size),
+	}
+}
+
+// Add records that [tx] sent by [from] was dropped for [reason], evicting the
+// oldest record if the log is full.
+func (l *DropLog) Add(tx *types.Transaction, from common.Address, reason string) {
+	if len(l.entries) == 0 {
+		return
+	}
+	drop := &DropReason{
+		Hash:   tx.Hash(),
+		From:   from,
+		Nonce:  tx.Nonce(),
+		Reason: reason,
+		Time:   time.Now(),
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if old := l.entries[l.next]; old != nil && l.index[old.Hash] == old {
+		delete(l.index, old.Hash)
+	}
+	l.entries[l.next] = drop
+	l.index[drop.Hash] = drop
+	l.next = (l.next + 1) % len(l.entries)
+}
+
+// Get returns the most recent drop record of the transaction with [hash], or
+// nil if it is not retained.
+func (l *DropLog) Get(hash common.Hash) *DropReason {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	return l.index[hash]
+}
+
+// Recent returns up to [limit] retained drop records, most recent first.
+func (l *DropLog) Recent(limit int) []*DropReason {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	var drops []*DropReason
+	for i := 1; i <= len(l.entries) && len(drops) < limit; i++ {
+		drop := l.entries[(l.next-i+len(l.entries))%len(l.entries)]
+		if drop == nil {
+			break
+		}
+		drops = append(drops, drop)
+	}
+	return drops
+}
+
+// dropReporter is implemented by subpools recording the transactions they
+// reject or drop.
+type dropReporter interface {
+	DropReason(hash common.Hash) *DropReason
+	RecentDrops(limit int) []*DropReason
+}
+
+// DropReason returns why the transaction with [hash] was recently rejected or
+// dropped by a subpool, or nil if it is not known to have been.
+func (p *TxPool) DropReason(hash common.Hash) *DropReason {
+	for _, subpool := range p.subpools {
+		if reporter, ok := subpool.(dropReporter); ok {
+			if drop := reporter.DropReason(hash); drop != nil {
+				return drop
+			}
+		}
+	}
+	return nil
+}
+
+// RecentDrops returns up to [limit] of the most recently rejected or dropped
+// transactions across all subpools, most recent first.
+func (p *TxPool) RecentDrops(limit int) []*DropReason {
+	if limit <= 0 {
+		return nil
+	}
+	var drops []*DropReason
+	for _, subpool := range p.subpools {
+		if reporter, ok := subpool.(dropReporter); ok {
+			drops = append(drops, reporter.RecentDrops(limit)...)
+		}
+	}
+	sort.SliceStable(drops, func(i, j int) bool {
+		return drops[i].Time.After(drops[j].Time)
+	})
+	if len(drops) > limit {
+		drops = drops[:limit]
+	}
+	return drops
+}
@@ -0,0 +1,56 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package txpool
+
+import (
+	"testing"
+
+	"github.com/ava-labs/coreth/core/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDropLog(t *testing.T) {
+	require := require.New(t)
+
+	txs := make([]*types.Transaction, 5)
+	for i := range txs {
+		txs[i] = types.NewTx(&types.LegacyTx{Nonce: uint64(i)})
+	}
+	from := common.Address{1}
+
+	log := NewDropLog(3)
+	require.Empty(log.Recent(10))
+	for _, tx := range txs[:4] {
+		log.Add(tx, from, "dropped")
+	}
+	// The oldest drop is evicted.
+	require.Nil(log.Get(txs[0].Hash()))
+	for _, tx := range txs[1:4] {
+		drop := log.Get(tx.Hash())
+		require.NotNil(drop)
+		require.Equal(tx.Nonce(), drop.Nonce)
+		require.Equal(from, drop.From)
+	}
+	recent := log.Recent(10)
+	require.Len(recent, 3)
+	for i, drop := range recent {
+		require.Equal(txs[3-i].Hash(), drop.Hash)
+	}
+	require.Len(log.Recent(2), 2)
+
+	// Dropping a transaction again keeps its latest reason, which is not
+	// forgotten when its previous record is evicted.
+	log.Add(txs[1], from, "again")
+	log.Add(txs[4], from, "dropped")
+	require.Equal("again", log.Get(txs[1].Hash()).Reason)
+	log.Add(txs[4], from, "dropped")
+	require.Equal("again", log.Get(txs[1].Hash()).Reason)
+}
+
+func TestTxPoolRecentDropsNonPositiveLimit(t *testing.T) {
+	pool := &TxPool{}
+	require.Empty(t, pool.RecentDrops(0))
+	require.Empty(t, pool.RecentDrops(-1))
+}
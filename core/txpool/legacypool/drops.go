@@ -0,0 +1,45 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package legacypool
+
+import (
+	"fmt"
+
+	"github.com/ava-labs/coreth/core/txpool"
+	"github.com/ava-labs/coreth/core/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Reasons recorded for transactions dropped after having been accepted into
+// the pool. Rejected transactions are recorded with the rejection error.
+const (
+	dropExpired           = "expired: queued longer than the pool lifetime"
+	dropBelowTip          = "underpriced: below the updated minimum tip"
+	dropUnpayable         = "insufficient funds for gas * price + value or gas limit exceeds block gas limit"
+	dropAccountQueueLimit = "account queue limit exceeded"
+	dropGlobalQueueLimit  = "global queue limit exceeded"
+	dropPendingLimit      = "global pending limit exceeded"
+)
+
+func replacedBy(hash common.Hash) string {
+	return fmt.Sprintf("replaced by %s", hash)
+}
+
+// recordDrop records [tx] as dropped for [reason], recovering its sender.
+func (pool *LegacyPool) recordDrop(tx *types.Transaction, reason string) {
+	from, _ := types.Sender(pool.signer, tx)
+	pool.drops.Add(tx, from, reason)
+}
+
+// DropReason returns why the transaction with [hash] was recently rejected or
+// dropped by the pool, or nil if it is not known to have been.
+func (pool *LegacyPool) DropReason(hash common.Hash) *txpool.DropReason {
+	return pool.drops.Get(hash)
+}
+
+// RecentDrops returns up to [limit] of the most recently rejected or dropped
+// transactions, most recent first.
+func (pool *LegacyPool) RecentDrops(limit int) []*txpool.DropReason {
+	return pool.drops.Recent(limit)
+}
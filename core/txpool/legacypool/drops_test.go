@@ -0,0 +1,62 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package legacypool
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ava-labs/coreth/core"
+	"github.com/ava-labs/coreth/core/txpool"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDropReasons(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	pool, key := setupPool()
+	defer pool.Close()
+
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	testAddBalance(pool, from, big.NewInt(1000000000))
+
+	// A replacement without the required price bump is rejected.
+	original := pricedTransaction(0, 100000, big.NewInt(1), key)
+	require.NoError(pool.addRemoteSync(original))
+	underpriced := pricedTransaction(0, 100001, big.NewInt(1), key)
+	require.ErrorIs(pool.addRemoteSync(underpriced), txpool.ErrReplaceUnderpriced)
+	drop := pool.DropReason(underpriced.Hash())
+	require.NotNil(drop)
+	require.Equal(from, drop.From)
+	require.Equal(txpool.ErrReplaceUnderpriced.Error(), drop.Reason)
+
+	// A replaced transaction records its replacement.
+	replacement := pricedTransaction(0, 100000, big.NewInt(2), key)
+	require.NoError(pool.addRemoteSync(replacement))
+	drop = pool.DropReason(original.Hash())
+	require.NotNil(drop)
+	require.Equal(replacedBy(replacement.Hash()), drop.Reason)
+
+	// Already known transactions and accepted transactions are not recorded.
+	require.ErrorIs(pool.addRemoteSync(replacement), txpool.ErrAlreadyKnown)
+	require.Nil(pool.DropReason(replacement.Hash()))
+
+	// A queued transaction is dropped once its nonce is used on chain.
+	queued := pricedTransaction(3, 100000, big.NewInt(1), key)
+	require.NoError(pool.addRemoteSync(queued))
+	testSetNonce(pool, from, 4)
+	<-pool.requestReset(nil, nil)
+	drop = pool.DropReason(queued.Hash())
+	require.NotNil(drop)
+	require.Equal(core.ErrNonceTooLow.Error(), drop.Reason)
+
+	drops := pool.RecentDrops(10)
+	require.Len(drops, 3)
+	require.Equal(queued.Hash(), drops[0].Hash)
+	require.Equal(original.Hash(), drops[1].Hash)
+	require.Equal(underpriced.Hash(), drops[2].Hash)
+	require.Len(pool.RecentDrops(1), 1)
+}
@@ -29,6 +29,7 @@ package legacypool
 
 import (
 	"errors"
+	"fmt"
 	"math"
 	"math/big"
 	"sort"
@@ -65,6 +66,10 @@ const (
 	//
 	// Note: the max contract size is 24KB
 	txMaxSize = 4 * txSlotSize // 128KB
+
+	// DropLogSize is the number of recently rejected or dropped transactions
+	// whose reason is retained for inspection.
+	DropLogSize = 4096
 )
 
 var (
@@ -137,10 +142,11 @@ type BlockChain interface {
 
 // Config are the configuration parameters of the transaction pool.
 type Config struct {
-	Locals    []common.Address // Addresses that should be treated by default as local
-	NoLocals  bool             // Whether local transaction handling should be disabled
-	Journal   string           // Journal of local transactions to survive node restarts
-	Rejournal time.Duration    // Time interval to regenerate the local transaction journal
+	Locals        []common.Address // Addresses that should be treated by default as local
+	NoLocals      bool             // Whether local transaction handling should be disabled
+	Journal       string           // Journal of local transactions to survive node restarts
+	RemoteJournal string           // Journal of remote transactions to survive node restarts
+	Rejournal     time.Duration    // Time interval to regenerate the transaction journals
 
 	PriceLimit uint64 // Minimum gas price to enforce for acceptance into the pool
 	PriceBump  uint64 // Minimum price bump percentage to replace an already existing transaction (nonce)
@@ -155,10 +161,11 @@ type Config struct {
 
 // DefaultConfig contains the default configurations for the transaction pool.
 var DefaultConfig = Config{
-	// If we re-enable txpool journaling, we should also add the saved local
-	// transactions to the p2p gossip on startup.
-	Journal:   "",
-	Rejournal: time.Hour,
+	// Journaling is disabled by default. Transactions restored from the
+	// journals must be added to the p2p gossip on startup, see Restored.
+	Journal:       "",
+	RemoteJournal: "",
+	Rejournal:     time.Hour,
 
 	PriceLimit: 1,
 	PriceBump:  10,
@@ -238,8 +245,12 @@ type LegacyPool struct {
 	currentState  *state.StateDB               // Current state in the blockchain head
 	pendingNonces *noncer                      // Pending state tracking virtual nonces
 
-	locals  *accountSet // Set of local transaction to exempt from eviction rules
-	journal *journal    // Journal of local transaction to back up to disk
+	locals        *accountSet            // Set of local transaction to exempt from eviction rules
+	journal       *journal               // Journal of local transaction to back up to disk
+	remoteJournal *journal               // Journal of remote transactions to back up to disk
+	restored      []common.Hash          // Transactions loaded from the journals, until retrieved by Restored
+	drops         *txpool.DropLog        // Reasons of recently rejected or dropped transactions
+	private       map[common.Hash]uint64 // Private transactions, mapped to the block number at which they expire
 
 	reserve txpool.AddressReserver       // Address reserver to ensure exclusivity across subpools
 	pending map[common.Address]*list     // All currently processable transactions
@@ -279,6 +290,8 @@ func New(config Config, chain BlockChain) *LegacyPool {
 		queue:               make(map[common.Address]*list),
 		beats:               make(map[common.Address]time.Time),
 		all:                 newLookup(),
+		drops:               txpool.NewDropLog(DropLogSize),
+		private:             make(map[common.Hash]uint64),
 		reqResetCh:          make(chan *txpoolResetRequest),
 		reqPromoteCh:        make(chan *accountSet),
 		queueTxEventCh:      make(chan *types.Transaction),
@@ -297,6 +310,9 @@ func New(config Config, chain BlockChain) *LegacyPool {
 	if !config.NoLocals && config.Journal != "" {
 		pool.journal = newTxJournal(config.Journal)
 	}
+	if config.RemoteJournal != "" {
+		pool.remoteJournal = newTxJournal(config.RemoteJournal)
+	}
 	return pool
 }
 
@@ -343,13 +359,24 @@ func (pool *LegacyPool) Init(gasTip uint64, head *types.Header, reserve txpool.A
 
 	// If local transactions and journaling is enabled, load from disk
 	if pool.journal != nil {
-		if err := pool.journal.load(pool.addLocals); err != nil {
+		if err := pool.journal.load(pool.restore(pool.addLocals)); err != nil {
 			log.Warn("Failed to load transaction journal", "err", err)
 		}
 		if err := pool.journal.rotate(pool.local()); err != nil {
 			log.Warn("Failed to rotate transaction journal", "err", err)
 		}
 	}
+	if pool.remoteJournal != nil {
+		if err := pool.remoteJournal.load(pool.restore(pool.addRemotesSync)); err != nil {
+			log.Warn("Failed to load remote transaction journal", "err", err)
+		}
+		pool.mu.RLock()
+		remotes := pool.remote()
+		pool.mu.RUnlock()
+		if err := pool.remoteJournal.rotate(remotes); err != nil {
+			log.Warn("Failed to rotate remote transaction journal", "err", err)
+		}
+	}
 	pool.wg.Add(1)
 	go pool.loop()
 
@@ -408,6 +435,7 @@ func (pool *LegacyPool) loop() {
 				if time.Since(pool.beats[addr]) > pool.config.Lifetime {
 					list := pool.queue[addr].Flatten()
 					for _, tx := range list {
+						pool.recordDrop(tx, dropExpired)
 						pool.removeTx(tx.Hash(), true, true)
 					}
 					queuedEvictionMeter.Mark(int64(len(list)))
@@ -424,6 +452,14 @@ func (pool *LegacyPool) loop() {
 				}
 				pool.mu.Unlock()
 			}
+			if pool.remoteJournal != nil {
+				pool.mu.RLock()
+				remotes := pool.remote()
+				pool.mu.RUnlock()
+				if err := pool.remoteJournal.rotate(remotes); err != nil {
+					log.Warn("Failed to rotate remote tx journal", "err", err)
+				}
+			}
 		}
 	}
 }
@@ -439,6 +475,17 @@ func (pool *LegacyPool) Close() error {
 	if pool.journal != nil {
 		pool.journal.close()
 	}
+	// Remote transactions are not journaled as they are added, so persist the
+	// current ones to survive the restart.
+	if pool.remoteJournal != nil {
+		pool.mu.RLock()
+		remotes := pool.remote()
+		pool.mu.RUnlock()
+		if err := pool.remoteJournal.rotate(remotes); err != nil {
+			log.Warn("Failed to rotate remote tx journal", "err", err)
+		}
+		pool.remoteJournal.close()
+	}
 	log.Info("Transaction pool stopped")
 	return nil
 }
@@ -476,6 +523,7 @@ func (pool *LegacyPool) SetGasTip(tip *big.Int) {
 		// pool.priced is sorted by GasFeeCap, so we have to iterate through pool.all instead
 		drop := pool.all.RemotesBelowTip(tip)
 		for _, tx := range drop {
+			pool.recordDrop(tx, dropBelowTip)
 			pool.removeTx(tx.Hash(), false, true)
 		}
 		pool.priced.Removed(len(drop))
@@ -650,15 +698,67 @@ func (pool *LegacyPool) local() map[common.Address]types.Transactions {
 	txs := make(map[common.Address]types.Transactions)
 	for addr := range pool.locals.accounts {
 		if pending := pool.pending[addr]; pending != nil {
-			txs[addr] = append(txs[addr], pending.Flatten()...)
+			txs[addr] = append(txs[addr], pool.public(pending.Flatten())...)
 		}
 		if queued := pool.queue[addr]; queued != nil {
-			txs[addr] = append(txs[addr], queued.Flatten()...)
+			txs[addr] = append(txs[addr], pool.public(queued.Flatten())...)
+		}
+	}
+	return txs
+}
+
+// remote retrieves all currently known remote transactions, grouped by origin
+// account and sorted by nonce. The returned transaction set is a copy and can be
+// freely modified by calling code.
+func (pool *LegacyPool) remote() map[common.Address]types.Transactions {
+	txs := make(map[common.Address]types.Transactions)
+	for addr, pending := range pool.pending {
+		if !pool.locals.contains(addr) {
+			txs[addr] = append(txs[addr], pool.public(pending.Flatten())...)
+		}
+	}
+	for addr, queued := range pool.queue {
+		if !pool.locals.contains(addr) {
+			txs[addr] = append(txs[addr], pool.public(queued.Flatten())...)
 		}
 	}
 	return txs
 }
 
+// restore wraps [add] to record the transactions loaded from a journal, so
+// they can be retrieved by Restored.
+func (pool *LegacyPool) restore(add func([]*types.Transaction) []error) func([]*types.Transaction) []error {
+	return func(txs []*types.Transaction) []error {
+		errs := add(txs)
+		pool.mu.Lock()
+		for i, err := range errs {
+			if err == nil {
+				pool.restored = append(pool.restored, txs[i].Hash())
+			}
+		}
+		pool.mu.Unlock()
+		return errs
+	}
+}
+
+// Restored returns the transactions loaded from the journals on startup that
+// are still in the pool. Restored transactions were not announced to peers,
+// so the caller is responsible for gossiping them. Subsequent calls return
+// nil.
+func (pool *LegacyPool) Restored() []*types.Transaction {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	var txs []*types.Transaction
+	for _, hash := range pool.restored {
+		if tx := pool.all.Get(hash); tx != nil {
+			txs = append(txs, tx)
+		}
+	}
+	pool.restored = nil
+	return txs
+}
+
 // validateTxBasics checks whether a transaction is valid according to the consensus
 // rules, but does not check state-dependent validation such as sufficient balance.
 // This check is meant as an early check which only needs to be performed once,
@@ -834,6 +934,7 @@ func (pool *LegacyPool) add(tx *types.Transaction, local bool) (replaced bool, e
 			underpricedTxMeter.Mark(1)
 
 			sender, _ := types.Sender(pool.signer, tx)
+			pool.drops.Add(tx, sender, fmt.Sprintf("%s: evicted by %s", txpool.ErrUnderpriced, hash))
 			dropped := pool.removeTx(tx.Hash(), false, sender != from) // Don't unreserve the sender of the tx being added if last from the acc
 
 			pool.changesSinceReorg += dropped
@@ -850,6 +951,7 @@ func (pool *LegacyPool) add(tx *types.Transaction, local bool) (replaced bool, e
 		}
 		// New transaction is better, replace old one
 		if old != nil {
+			pool.drops.Add(old, from, replacedBy(hash))
 			pool.all.Remove(old.Hash())
 			pool.priced.Removed(1)
 			pendingReplaceMeter.Mark(1)
@@ -925,6 +1027,7 @@ func (pool *LegacyPool) enqueueTx(hash common.Hash, tx *types.Transaction, local
 	}
 	// Discard any previous transaction and mark this
 	if old != nil {
+		pool.drops.Add(old, from, replacedBy(hash))
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
 		queuedReplaceMeter.Mark(1)
@@ -951,10 +1054,14 @@ func (pool *LegacyPool) enqueueTx(hash common.Hash, tx *types.Transaction, local
 // journalTx adds the specified transaction to the local disk journal if it is
 // deemed to have been sent from a local account.
 func (pool *LegacyPool) journalTx(from common.Address, tx *types.Transaction) {
-	// Only journal if it's enabled and the transaction is local
+	// Only journal if it's enabled and the transaction is local. Private
+	// transactions are not journaled, as restored transactions are gossiped.
 	if pool.journal == nil || !pool.locals.contains(from) {
 		return
 	}
+	if _, ok := pool.private[tx.Hash()]; ok {
+		return
+	}
 	if err := pool.journal.insert(tx); err != nil {
 		log.Warn("Failed to journal local transaction", "err", err)
 	}
@@ -974,6 +1081,7 @@ func (pool *LegacyPool) promoteTx(addr common.Address, hash common.Hash, tx *typ
 	inserted, old := list.Add(tx, pool.config.PriceBump)
 	if !inserted {
 		// An older transaction was better, discard this
+		pool.drops.Add(tx, addr, txpool.ErrReplaceUnderpriced.Error())
 		pool.all.Remove(hash)
 		pool.priced.Removed(1)
 		pendingDiscardMeter.Mark(1)
@@ -981,6 +1089,7 @@ func (pool *LegacyPool) promoteTx(addr common.Address, hash common.Hash, tx *typ
 	}
 	// Otherwise discard any previous transaction and mark this
 	if old != nil {
+		pool.drops.Add(old, addr, replacedBy(hash))
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
 		pendingReplaceMeter.Mark(1)
@@ -1070,6 +1179,11 @@ func (pool *LegacyPool) Add(txs []*types.Transaction, local, sync bool) []error
 		news = append(news, tx)
 	}
 	if len(news) == 0 {
+		for i, err := range errs {
+			if err != nil && !errors.Is(err, txpool.ErrAlreadyKnown) {
+				pool.recordDrop(txs[i], err.Error())
+			}
+		}
 		return errs
 	}
 
@@ -1086,6 +1200,11 @@ func (pool *LegacyPool) Add(txs []*types.Transaction, local, sync bool) []error
 		errs[nilSlot] = err
 		nilSlot++
 	}
+	for i, err := range errs {
+		if err != nil && !errors.Is(err, txpool.ErrAlreadyKnown) {
+			pool.recordDrop(txs[i], err.Error())
+		}
+	}
 	// Reorg the pool internals if needed and return
 	done := pool.requestPromoteExecutables(dirtyAddrs)
 	if sync {
@@ -1369,6 +1488,7 @@ func (pool *LegacyPool) runReorg(done chan struct{}, reset *txpoolResetRequest,
 	if reset != nil {
 		pool.demoteUnexecutables()
 		if reset.newHead != nil {
+			pool.expirePrivate(reset.newHead.Number.Uint64())
 			if pool.chainconfig.IsApricotPhase3(reset.newHead.Time) {
 				if err := pool.updateBaseFeeAt(reset.newHead); err != nil {
 					log.Error("error at updating base fee in tx pool", "error", err)
@@ -1532,6 +1652,7 @@ func (pool *LegacyPool) promoteExecutables(accounts []common.Address) []*types.T
 		forwards := list.Forward(pool.currentState.GetNonce(addr))
 		for _, tx := range forwards {
 			hash := tx.Hash()
+			pool.drops.Add(tx, addr, core.ErrNonceTooLow.Error())
 			pool.all.Remove(hash)
 		}
 		log.Trace("Removed old queued transactions", "count", len(forwards))
@@ -1539,6 +1660,7 @@ func (pool *LegacyPool) promoteExecutables(accounts []common.Address) []*types.T
 		drops, _ := list.Filter(pool.currentState.GetBalance(addr), gasLimit)
 		for _, tx := range drops {
 			hash := tx.Hash()
+			pool.drops.Add(tx, addr, dropUnpayable)
 			pool.all.Remove(hash)
 		}
 		log.Trace("Removed unpayable queued transactions", "count", len(drops))
@@ -1561,6 +1683,7 @@ func (pool *LegacyPool) promoteExecutables(accounts []common.Address) []*types.T
 			caps = list.Cap(int(pool.config.AccountQueue))
 			for _, tx := range caps {
 				hash := tx.Hash()
+				pool.drops.Add(tx, addr, dropAccountQueueLimit)
 				pool.all.Remove(hash)
 				log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
 			}
@@ -1626,6 +1749,7 @@ func (pool *LegacyPool) truncatePending() {
 					for _, tx := range caps {
 						// Drop the transaction from the global pools too
 						hash := tx.Hash()
+						pool.drops.Add(tx, offenders[i], dropPendingLimit)
 						pool.all.Remove(hash)
 
 						// Update the account nonce to the dropped transaction
@@ -1653,6 +1777,7 @@ func (pool *LegacyPool) truncatePending() {
 				for _, tx := range caps {
 					// Drop the transaction from the global pools too
 					hash := tx.Hash()
+					pool.drops.Add(tx, addr, dropPendingLimit)
 					pool.all.Remove(hash)
 
 					// Update the account nonce to the dropped transaction
@@ -1700,6 +1825,7 @@ func (pool *LegacyPool) truncateQueue() {
 		// Drop all transactions if they are less than the overflow
 		if size := uint64(list.Len()); size <= drop {
 			for _, tx := range list.Flatten() {
+				pool.drops.Add(tx, addr.address, dropGlobalQueueLimit)
 				pool.removeTx(tx.Hash(), true, true)
 			}
 			drop -= size
@@ -1709,6 +1835,7 @@ func (pool *LegacyPool) truncateQueue() {
 		// Otherwise drop only last few transactions
 		txs := list.Flatten()
 		for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
+			pool.drops.Add(txs[i], addr.address, dropGlobalQueueLimit)
 			pool.removeTx(txs[i].Hash(), true, true)
 			drop--
 			queuedRateLimitMeter.Mark(1)
@@ -1744,6 +1871,7 @@ func (pool *LegacyPool) demoteUnexecutables() {
 		for _, tx := range drops {
 			hash := tx.Hash()
 			log.Trace("Removed unpayable pending transaction", "hash", hash)
+			pool.drops.Add(tx, addr, dropUnpayable)
 			pool.all.Remove(hash)
 		}
 		pendingNofundsMeter.Mark(int64(len(drops)))
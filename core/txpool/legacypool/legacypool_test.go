@@ -34,6 +34,7 @@ import (
 	"math/big"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -2482,6 +2483,73 @@ func testJournaling(t *testing.T, nolocals bool) {
 	pool.Close()
 }
 
+// TestRemoteJournaling tests that remote transactions survive a restart when
+// remote journaling is enabled and are reported as restored.
+func TestRemoteJournaling(t *testing.T) {
+	t.Parallel()
+
+	journal := filepath.Join(t.TempDir(), "remote_transactions.rlp")
+
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := newTestBlockChain(params.TestChainConfig, 1000000, statedb, new(event.Feed))
+
+	config := testTxPoolConfig
+	config.NoLocals = true
+	config.RemoteJournal = journal
+
+	pool := New(config, blockchain)
+	pool.Init(config.PriceLimit, blockchain.CurrentBlock(), makeAddressReserver())
+
+	remote, _ := crypto.GenerateKey()
+	testAddBalance(pool, crypto.PubkeyToAddress(remote.PublicKey), big.NewInt(1000000000))
+
+	txs := []*types.Transaction{
+		pricedTransaction(0, 100000, big.NewInt(1), remote),
+		pricedTransaction(1, 100000, big.NewInt(1), remote),
+		pricedTransaction(3, 100000, big.NewInt(1), remote),
+	}
+	for _, err := range pool.addRemotesSync(txs) {
+		if err != nil {
+			t.Fatalf("failed to add remote transaction: %v", err)
+		}
+	}
+	if restored := pool.Restored(); len(restored) != 0 {
+		t.Fatalf("restored transactions mismatched: have %d, want %d", len(restored), 0)
+	}
+	// Terminate the old pool, bump the nonce, create a new pool and ensure the
+	// remaining transactions survive
+	pool.Close()
+	statedb.SetNonce(crypto.PubkeyToAddress(remote.PublicKey), 1)
+	blockchain = newTestBlockChain(params.TestChainConfig, 1000000, statedb, new(event.Feed))
+
+	pool = New(config, blockchain)
+	pool.Init(config.PriceLimit, blockchain.CurrentBlock(), makeAddressReserver())
+	defer pool.Close()
+
+	pending, queued := pool.Stats()
+	if pending != 1 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 1)
+	}
+	if queued != 1 {
+		t.Fatalf("queued transactions mismatched: have %d, want %d", queued, 1)
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	restored := pool.Restored()
+	if len(restored) != 2 {
+		t.Fatalf("restored transactions mismatched: have %d, want %d", len(restored), 2)
+	}
+	for _, tx := range restored {
+		if tx.Hash() != txs[1].Hash() && tx.Hash() != txs[2].Hash() {
+			t.Fatalf("unexpected restored transaction %x", tx.Hash())
+		}
+	}
+	if restored := pool.Restored(); restored != nil {
+		t.Fatalf("restored transactions returned twice: %d", len(restored))
+	}
+}
+
 // TestStatusCheck tests that the pool can correctly retrieve the
 // pending status of individual transactions.
 func TestStatusCheck(t *testing.T) {
@@ -0,0 +1,106 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package legacypool
+
+import (
+	"github.com/ava-labs/coreth/core/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// dropPrivateExpired is the reason recorded for private transactions which
+// were not included before their expiry.
+const dropPrivateExpired = "private transaction expired"
+
+// AddPrivate adds [tx] to the pool as a local transaction which must not be
+// gossiped, see IsPrivate. If it is not included within [blocks] blocks of the
+// current head, it is dropped.
+func (pool *LegacyPool) AddPrivate(tx *types.Transaction, blocks uint64) error {
+	hash := tx.Hash()
+	expiry := pool.currentHead.Load().Number.Uint64() + blocks
+
+	// Mark the transaction as private before adding it, so it is never
+	// observed as public.
+	pool.mu.Lock()
+	_, known := pool.private[hash]
+	if !known && pool.all.Get(hash) == nil {
+		pool.private[hash] = expiry
+	}
+	pool.mu.Unlock()
+
+	err := pool.Add([]*types.Transaction{tx}, true, false)[0]
+	if err != nil && !known {
+		pool.mu.Lock()
+		delete(pool.private, hash)
+		pool.mu.Unlock()
+	}
+	return err
+}
+
+// IsPrivate returns whether the transaction with [hash] was added with
+// AddPrivate and is still pooled.
+func (pool *LegacyPool) IsPrivate(hash common.Hash) bool {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	_, ok := pool.private[hash]
+	return ok
+}
+
+// IteratePublicPending iterates over the pending transactions which are not
+// private until [f] returns false. The caller must not modify [tx]. Returns
+// false if iteration was interrupted.
+func (pool *LegacyPool) IteratePublicPending(f func(tx *types.Transaction) bool) bool {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	for _, list := range pool.pending {
+		for _, tx := range list.txs.items {
+			if _, ok := pool.private[tx.Hash()]; ok {
+				continue
+			}
+			if !f(tx) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// public returns [txs] without the private transactions.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *LegacyPool) public(txs types.Transactions) types.Transactions {
+	if len(pool.private) == 0 {
+		return txs
+	}
+	public := txs[:0]
+	for _, tx := range txs {
+		if _, ok := pool.private[tx.Hash()]; !ok {
+			public = append(public, tx)
+		}
+	}
+	return public
+}
+
+// expirePrivate forgets the private transactions no longer pooled and drops
+// the ones expiring at or before the block [number].
+//
+// Note, this method assumes the pool lock is held!
+func (pool *LegacyPool) expirePrivate(number uint64) {
+	for hash, expiry := range pool.private {
+		tx := pool.all.Get(hash)
+		if tx == nil {
+			delete(pool.private, hash)
+			continue
+		}
+		if number < expiry {
+			continue
+		}
+		log.Debug("Dropping expired private transaction", "hash", hash, "expiry", expiry)
+		pool.recordDrop(tx, dropPrivateExpired)
+		pool.removeTx(hash, true, true)
+		delete(pool.private, hash)
+	}
+}
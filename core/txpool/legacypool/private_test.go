@@ -0,0 +1,73 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package legacypool
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ava-labs/coreth/core/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrivateTransactions(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	pool, key := setupPool()
+	defer pool.Close()
+
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	testAddBalance(pool, from, big.NewInt(1000000000))
+
+	public := pricedTransaction(0, 100000, big.NewInt(1), key)
+	private := pricedTransaction(1, 100000, big.NewInt(1), key)
+	require.NoError(pool.addRemoteSync(public))
+	require.NoError(pool.AddPrivate(private, 2))
+	<-pool.requestPromoteExecutables(newAccountSet(pool.signer, from))
+
+	require.False(pool.IsPrivate(public.Hash()))
+	require.True(pool.IsPrivate(private.Hash()))
+	pending, _ := pool.Stats()
+	require.Equal(2, pending)
+
+	var iterated []common.Hash
+	pool.IteratePublicPending(func(tx *types.Transaction) bool {
+		iterated = append(iterated, tx.Hash())
+		return true
+	})
+	require.Equal([]common.Hash{public.Hash()}, iterated)
+
+	// Private transactions are never journaled.
+	pool.mu.RLock()
+	for _, txs := range pool.local() {
+		for _, tx := range txs {
+			require.NotEqual(private.Hash(), tx.Hash())
+		}
+	}
+	pool.mu.RUnlock()
+
+	// A rejected private transaction is not tracked.
+	invalid := types.NewTx(&types.LegacyTx{Nonce: 5, Gas: 100000, GasPrice: big.NewInt(1)}) // unsigned
+	require.Error(pool.AddPrivate(invalid, 2))
+	require.False(pool.IsPrivate(invalid.Hash()))
+
+	// The private transaction is dropped once it expires.
+	head := func(number int64) *types.Header {
+		return &types.Header{Number: big.NewInt(number), GasLimit: pool.currentHead.Load().GasLimit}
+	}
+	<-pool.requestReset(nil, head(1))
+	require.True(pool.IsPrivate(private.Hash()))
+	require.NotNil(pool.all.Get(private.Hash()))
+
+	<-pool.requestReset(nil, head(2))
+	require.False(pool.IsPrivate(private.Hash()))
+	require.Nil(pool.all.Get(private.Hash()))
+	require.NotNil(pool.all.Get(public.Hash()))
+	drop := pool.DropReason(private.Hash())
+	require.NotNil(drop)
+	require.Equal(dropPrivateExpired, drop.Reason)
+}
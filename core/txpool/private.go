@@ -0,0 +1,57 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package txpool
+
+import (
+	"github.com/ava-labs/coreth/core"
+	"github.com/ava-labs/coreth/core/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// privatePool is implemented by subpools supporting private transactions.
+type privatePool interface {
+	AddPrivate(tx *types.Transaction, blocks uint64) error
+	IsPrivate(hash common.Hash) bool
+	IteratePublicPending(f func(tx *types.Transaction) bool) bool
+}
+
+// AddPrivate adds [tx] as a local transaction which must not be gossiped to
+// peers, but may be included in locally built blocks. The transaction is
+// dropped if it is not included within [blocks] blocks.
+func (p *TxPool) AddPrivate(tx *types.Transaction, blocks uint64) error {
+	for _, subpool := range p.subpools {
+		if subpool.Filter(tx) {
+			if private, ok := subpool.(privatePool); ok {
+				return private.AddPrivate(tx, blocks)
+			}
+			break
+		}
+	}
+	return core.ErrTxTypeNotSupported
+}
+
+// IsPrivate returns whether the pooled transaction with [hash] was added with
+// AddPrivate, in which case it must not be gossiped.
+func (p *TxPool) IsPrivate(hash common.Hash) bool {
+	for _, subpool := range p.subpools {
+		if private, ok := subpool.(privatePool); ok && private.IsPrivate(hash) {
+			return true
+		}
+	}
+	return false
+}
+
+// IteratePublicPending iterates over the pending transactions which are not
+// private until [f] returns false. The caller must not modify [tx].
+func (p *TxPool) IteratePublicPending(f func(tx *types.Transaction) bool) {
+	for _, subpool := range p.subpools {
+		iterate := subpool.IteratePending
+		if private, ok := subpool.(privatePool); ok {
+			iterate = private.IteratePublicPending
+		}
+		if !iterate(f) {
+			return
+		}
+	}
+}
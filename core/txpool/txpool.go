@@ -503,6 +503,19 @@ func (p *TxPool) Stats() (int, int) {
 	return runnable, blocked
 }
 
+// Restored returns the transactions restored from disk by the subpools on
+// startup which are still pooled, so they can be gossiped to peers. Subsequent
+// calls return nil.
+func (p *TxPool) Restored() []*types.Transaction {
+	var txs []*types.Transaction
+	for _, subpool := range p.subpools {
+		if restorer, ok := subpool.(interface{ Restored() []*types.Transaction }); ok {
+			txs = append(txs, restorer.Restored()...)
+		}
+	}
+	return txs
+}
+
 // Content retrieves the data content of the transaction pool, returning all the
 // pending as well as queued transactions, grouped by account and sorted by nonce.
 func (p *TxPool) Content() (map[common.Address][]*types.Transaction, map[common.Address][]*types.Transaction) {
@@ -0,0 +1,46 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package types
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+//go:generate go run github.com/fjl/gencodec -type AssetTransfer -field-override assetTransferMarshaling -out gen_asset_transfer_json.go
+
+// AssetTransfer represents a change to the multi-coin (non-AVAX) balance of an
+// account. Transfers are recorded both for EVM transactions and for atomic
+// transactions that import or export multi-coin assets.
+type AssetTransfer struct {
+	// account whose balance changed
+	Address common.Address `json:"address" gencodec:"required"`
+	// asset whose balance changed
+	AssetID common.Hash `json:"assetID" gencodec:"required"`
+	// absolute value of the balance change
+	Amount *big.Int `json:"amount" gencodec:"required"`
+	// true if the balance increased, false if it decreased
+	Incoming bool `json:"incoming"`
+	// hash of the transaction, or the ID of the atomic transaction
+	TxHash common.Hash `json:"transactionHash" gencodec:"required"`
+	// index of the transaction in the block, unset for atomic transactions
+	TxIndex uint `json:"transactionIndex"`
+	// true if the transfer was made by an atomic transaction
+	Atomic bool `json:"atomic"`
+
+	// Derived fields. These fields are filled in when the transfers of a
+	// block are read back from the database.
+	// block in which the transaction was included
+	BlockNumber uint64 `json:"blockNumber" rlp:"-"`
+	// hash of the block in which the transaction was included
+	BlockHash common.Hash `json:"blockHash" rlp:"-"`
+}
+
+type assetTransferMarshaling struct {
+	Amount      *hexutil.Big
+	TxIndex     hexutil.Uint
+	BlockNumber hexutil.Uint64
+}
@@ -40,6 +40,8 @@ type Config struct {
 	NoBaseFee               bool      // Forces the EIP-1559 baseFee to 0 (needed for 0 price calls)
 	EnablePreimageRecording bool      // Enables recording of SHA3/keccak preimages
 	ExtraEips               []int     // Additional EIPS that are to be enabled
+
+	OpcodeStats *OpcodeStats // Collects opcode execution statistics if non-nil
 }
 
 // ScopeContext contains the things that are per-call, such as stack and memory,
@@ -242,6 +244,16 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 			logged = true
 		}
 
+		if stats := in.evm.Config.OpcodeStats; stats != nil {
+			// Gas forwarded by the CALL family is accounted to the callee.
+			opCost := cost
+			switch op {
+			case CALL, CALLCODE, DELEGATECALL, STATICCALL:
+				opCost -= in.evm.callGasTemp
+			}
+			stats.record(op, opCost)
+		}
+
 		// execute the operation
 		res, err = operation.execute(&pc, in, callContext)
 		if err != nil {
@@ -27,6 +27,7 @@
 package vm
 
 import (
+	"math/big"
 	"testing"
 	"time"
 
@@ -85,3 +86,41 @@ func TestLoopInterrupt(t *testing.T) {
 		}
 	}
 }
+
+func TestOpcodeStats(t *testing.T) {
+	var (
+		caller = common.BytesToAddress([]byte("caller"))
+		callee = common.BytesToAddress([]byte("callee"))
+		stats  = new(OpcodeStats)
+	)
+	vmctx := BlockContext{
+		CanTransfer: func(StateDB, common.Address, *uint256.Int) bool { return true },
+		Transfer:    func(StateDB, common.Address, common.Address, *uint256.Int) {},
+		BlockNumber: new(big.Int),
+	}
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	// push(0) x5, push20(callee), gas, call, stop
+	statedb.SetCode(caller, common.Hex2Bytes("60006000600060006000"+"73"+common.Bytes2Hex(callee.Bytes())+"5af100"))
+	// push(1) push(2) add pop stop
+	statedb.SetCode(callee, common.Hex2Bytes("600160020150"+"00"))
+	statedb.Finalise(true)
+
+	evm := NewEVM(vmctx, TxContext{}, statedb, params.TestChainConfig, Config{OpcodeStats: stats})
+	if _, _, err := evm.Call(AccountRef(common.Address{}), caller, nil, 1_000_000, new(uint256.Int)); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedCounts := map[OpCode]uint64{PUSH1: 7, PUSH20: 1, GAS: 1, CALL: 1, ADD: 1, POP: 1, STOP: 2}
+	for op, count := range stats.Counts {
+		if count != expectedCounts[OpCode(op)] {
+			t.Errorf("opcode %s: expected %d executions, got %d", OpCode(op), expectedCounts[OpCode(op)], count)
+		}
+	}
+	if gas := stats.Gas[PUSH1]; gas != 7*GasFastestStep {
+		t.Errorf("expected PUSH1 gas %d, got %d", 7*GasFastestStep, gas)
+	}
+	// The gas forwarded to the callee must not be accounted to CALL.
+	if gas := stats.Gas[CALL]; gas != params.ColdAccountAccessCostEIP2929 {
+		t.Errorf("expected CALL gas %d, got %d", params.ColdAccountAccessCostEIP2929, gas)
+	}
+}
@@ -0,0 +1,26 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+// OpcodeStats counts the executions of and the gas used by each opcode.
+// When set in the interpreter Config, every executed opcode is recorded.
+// OpcodeStats is not safe for concurrent use.
+type OpcodeStats struct {
+	Counts [256]uint64 // Number of executions per opcode
+	Gas    [256]uint64 // Gas used per opcode, excluding gas forwarded to callees
+}
+
+// record adds a single execution of [op] that used [gas].
+func (s *OpcodeStats) record(op OpCode, gas uint64) {
+	s.Counts[op]++
+	s.Gas[op] += gas
+}
+
+// Merge adds the statistics of [other] to [s].
+func (s *OpcodeStats) Merge(other *OpcodeStats) {
+	for i := range s.Counts {
+		s.Counts[i] += other.Counts[i]
+		s.Gas[i] += other.Gas[i]
+	}
+}
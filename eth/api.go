@@ -27,7 +27,17 @@
 package eth
 
 import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/coreth/core/types"
+	"github.com/ava-labs/coreth/eth/gasprice"
+	"github.com/ava-labs/coreth/rpc"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
 )
 
 // EthereumAPI provides an API to access Ethereum full node-related information.
@@ -49,3 +59,123 @@ func (api *EthereumAPI) Etherbase() (common.Address, error) {
 func (api *EthereumAPI) Coinbase() (common.Address, error) {
 	return api.Etherbase()
 }
+
+// GetAssetTransfers returns the transfers of the multi-coin asset [assetID] to
+// or from [address] made in accepted blocks in the inclusive range
+// [fromBlock, toBlock]. Block tags resolve to the last accepted block.
+// Requires the node to be running with asset transfer indexing enabled.
+func (api *EthereumAPI) GetAssetTransfers(address common.Address, assetID ids.ID, fromBlock rpc.BlockNumber, toBlock rpc.BlockNumber) ([]*types.AssetTransfer, error) {
+	lastAccepted := api.e.LastAcceptedBlock().NumberU64()
+	resolve := func(number rpc.BlockNumber) uint64 {
+		if number < 0 || uint64(number) > lastAccepted {
+			return lastAccepted
+		}
+		return uint64(number)
+	}
+	from, to := resolve(fromBlock), resolve(toBlock)
+	if from > to {
+		return nil, fmt.Errorf("begin block %d is greater than end block %d", from, to)
+	}
+	if maxBlocks := api.e.settings.MaxBlocksPerRequest; maxBlocks > 0 && to-from >= uint64(maxBlocks) {
+		return nil, fmt.Errorf("requested too many blocks from %d to %d, maximum is set to %d", from, to, maxBlocks)
+	}
+	return api.e.blockchain.GetAssetTransfers(address, common.Hash(assetID), from, to)
+}
+
+type avaxFeeHistoryResult struct {
+	OldestBlock    *hexutil.Big       `json:"oldestBlock"`
+	BaseFee        []*hexutil.Big     `json:"baseFeePerGas,omitempty"`
+	GasUsedRatio   []float64          `json:"gasUsedRatio"`
+	BlockGasCost   []*hexutil.Big     `json:"blockGasCost,omitempty"`
+	ExtDataGasUsed []*hexutil.Big     `json:"extDataGasUsed,omitempty"`
+	MinRequiredTip []*hexutil.Big     `json:"minRequiredTip,omitempty"`
+	FeeWindow      [][]hexutil.Uint64 `json:"feeWindow,omitempty"`
+}
+
+// AvaxFeeHistory returns the fee market history like eth_feeHistory, along
+// with the block gas cost, atomic gas used, minimum required tip and dynamic
+// fee window of each block. Values not defined at a block are null.
+func (api *EthereumAPI) AvaxFeeHistory(ctx context.Context, blockCount math.HexOrDecimal64, lastBlock rpc.BlockNumber) (*avaxFeeHistoryResult, error) {
+	history, err := api.e.APIBackend.gpo.AvaxFeeHistory(ctx, uint64(blockCount), lastBlock)
+	if err != nil {
+		return nil, err
+	}
+	results := &avaxFeeHistoryResult{
+		OldestBlock:    (*hexutil.Big)(history.OldestBlock),
+		BaseFee:        toHexBigs(history.BaseFee),
+		GasUsedRatio:   history.GasUsedRatio,
+		BlockGasCost:   toHexBigs(history.BlockGasCost),
+		ExtDataGasUsed: toHexBigs(history.ExtDataGasUsed),
+		MinRequiredTip: toHexBigs(history.MinRequiredTip),
+	}
+	if history.FeeWindow != nil {
+		results.FeeWindow = make([][]hexutil.Uint64, len(history.FeeWindow))
+		for i, window := range history.FeeWindow {
+			if window == nil {
+				continue
+			}
+			results.FeeWindow[i] = make([]hexutil.Uint64, len(window))
+			for j, gas := range window {
+				results.FeeWindow[i][j] = hexutil.Uint64(gas)
+			}
+		}
+	}
+	return results, nil
+}
+
+func toHexBigs(values []*big.Int) []*hexutil.Big {
+	if values == nil {
+		return nil
+	}
+	results := make([]*hexutil.Big, len(values))
+	for i, v := range values {
+		results[i] = (*hexutil.Big)(v)
+	}
+	return results
+}
+
+type feeTierResult struct {
+	MaxPriorityFeePerGas *hexutil.Big `json:"maxPriorityFeePerGas"`
+	MaxFeePerGas         *hexutil.Big `json:"maxFeePerGas,omitempty"`
+	InclusionProbability float64      `json:"inclusionProbability"`
+}
+
+type suggestFeesResult struct {
+	BaseFee  *hexutil.Big   `json:"baseFeePerGas,omitempty"`
+	Blocks   hexutil.Uint64 `json:"blocks"`
+	Pending  hexutil.Uint64 `json:"pending"`
+	Slow     feeTierResult  `json:"slow"`
+	Standard feeTierResult  `json:"standard"`
+	Fast     feeTierResult  `json:"fast"`
+}
+
+// SuggestFees returns slow, standard and fast fee suggestions, each with the
+// estimated probability of being included within [blocks] blocks (default
+// gasprice.DefaultSuggestFeesBlocks), based on the minimum tips required by
+// recently accepted blocks and the number of pending transactions.
+func (api *EthereumAPI) SuggestFees(ctx context.Context, blocks *hexutil.Uint64) (*suggestFeesResult, error) {
+	var n uint64
+	if blocks != nil {
+		n = uint64(*blocks)
+	}
+	pending, _ := api.e.txPool.Stats()
+	fees, err := api.e.APIBackend.gpo.SuggestFees(ctx, pending, n)
+	if err != nil {
+		return nil, err
+	}
+	tier := func(tier gasprice.FeeTier) feeTierResult {
+		return feeTierResult{
+			MaxPriorityFeePerGas: (*hexutil.Big)(tier.TipCap),
+			MaxFeePerGas:         (*hexutil.Big)(tier.FeeCap),
+			InclusionProbability: tier.InclusionProbability,
+		}
+	}
+	return &suggestFeesResult{
+		BaseFee:  (*hexutil.Big)(fees.BaseFee),
+		Blocks:   hexutil.Uint64(fees.Blocks),
+		Pending:  hexutil.Uint64(fees.Pending),
+		Slow:     tier(fees.Slow),
+		Standard: tier(fees.Standard),
+		Fast:     tier(fees.Fast),
+	}, nil
+}
@@ -347,6 +347,15 @@ func (b *EthAPIBackend) SendTx(ctx context.Context, signedTx *types.Transaction)
 	return nil
 }
 
+// SendPrivateTx adds [signedTx] to the mempool without gossiping it, see
+// txpool.TxPool.AddPrivate.
+func (b *EthAPIBackend) SendPrivateTx(ctx context.Context, signedTx *types.Transaction, blocks uint64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return b.eth.txPool.AddPrivate(signedTx, blocks)
+}
+
 func (b *EthAPIBackend) GetPoolTransactions() (types.Transactions, error) {
 	pending := b.eth.txPool.Pending(txpool.PendingFilter{})
 	var txs types.Transactions
@@ -0,0 +1,61 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package eth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ava-labs/coreth/core/types"
+	"github.com/ava-labs/coreth/miner"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// BundleAPI provides an API to submit bundles of transactions to the block
+// builder. Bundles are executed on every block build, and reverted bundles pay
+// no fees, so the API is only served if enabled.
+type BundleAPI struct {
+	e *Ethereum
+}
+
+// NewBundleAPI creates a new instance of BundleAPI.
+func NewBundleAPI(e *Ethereum) *BundleAPI {
+	return &BundleAPI{e}
+}
+
+// SendBundleArgs are the arguments of eth_sendBundle.
+type SendBundleArgs struct {
+	Txs            []hexutil.Bytes `json:"txs"`
+	MinTip         *hexutil.Big    `json:"minTip"`
+	MaxBlockNumber *hexutil.Uint64 `json:"maxBlockNumber"`
+}
+
+// SendBundle submits an ordered bundle of signed transactions, which are
+// included contiguously and all-or-nothing in a block as long as they pay at
+// least [MinTip] in total, up to block [MaxBlockNumber] (by default
+// miner.DefaultBundleExpiry blocks after the current head). It returns the
+// bundle hash.
+func (api *BundleAPI) SendBundle(ctx context.Context, args SendBundleArgs) (common.Hash, error) {
+	signer := types.LatestSigner(api.e.blockchain.Config())
+	bundle := &miner.Bundle{
+		Txs:    make([]*types.Transaction, len(args.Txs)),
+		MinTip: (*big.Int)(args.MinTip),
+	}
+	for i, encoded := range args.Txs {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(encoded); err != nil {
+			return common.Hash{}, fmt.Errorf("invalid transaction %d: %w", i, err)
+		}
+		if _, err := types.Sender(signer, tx); err != nil {
+			return common.Hash{}, fmt.Errorf("invalid transaction %d: %w", i, err)
+		}
+		bundle.Txs[i] = tx
+	}
+	if args.MaxBlockNumber != nil {
+		bundle.MaxBlockNumber = uint64(*args.MaxBlockNumber)
+	}
+	return api.e.miner.SendBundle(bundle)
+}
@@ -30,11 +30,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/ava-labs/coreth/core/rawdb"
 	"github.com/ava-labs/coreth/core/state"
 	"github.com/ava-labs/coreth/core/types"
+	"github.com/ava-labs/coreth/core/vm"
 	"github.com/ava-labs/coreth/internal/ethapi"
 	"github.com/ava-labs/coreth/rpc"
 	"github.com/ava-labs/coreth/trie"
@@ -371,3 +373,49 @@ func (api *DebugAPI) GetAccessibleState(from, to rpc.BlockNumber) (uint64, error
 	}
 	return 0, errors.New("no state found")
 }
+
+// OpcodeStat is the execution statistics of a single opcode.
+type OpcodeStat struct {
+	Opcode string `json:"opcode"`
+	Count  uint64 `json:"count"`
+	Gas    uint64 `json:"gas"`
+}
+
+// OpcodeStatsResult is the result of a debug_opcodeStats call.
+type OpcodeStatsResult struct {
+	Blocks  uint64       `json:"blocks"`
+	Opcodes []OpcodeStat `json:"opcodes"`
+}
+
+// OpcodeStats returns the number of executions and the gas used per opcode,
+// aggregated over the blocks accepted since the node started or the statistics
+// were last reset. Opcodes are sorted by gas used in descending order.
+func (api *DebugAPI) OpcodeStats() (*OpcodeStatsResult, error) {
+	stats, blocks, err := api.eth.blockchain.OpcodeStats()
+	if err != nil {
+		return nil, err
+	}
+	result := &OpcodeStatsResult{
+		Blocks:  blocks,
+		Opcodes: []OpcodeStat{},
+	}
+	for op, count := range stats.Counts {
+		if count == 0 {
+			continue
+		}
+		result.Opcodes = append(result.Opcodes, OpcodeStat{
+			Opcode: vm.OpCode(op).String(),
+			Count:  count,
+			Gas:    stats.Gas[op],
+		})
+	}
+	sort.SliceStable(result.Opcodes, func(i, j int) bool {
+		return result.Opcodes[i].Gas > result.Opcodes[j].Gas
+	})
+	return result, nil
+}
+
+// ResetOpcodeStats clears the opcode statistics returned by debug_opcodeStats.
+func (api *DebugAPI) ResetOpcodeStats() error {
+	return api.eth.blockchain.ResetOpcodeStats()
+}
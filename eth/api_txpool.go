@@ -0,0 +1,72 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package eth
+
+import (
+	"time"
+
+	"github.com/ava-labs/coreth/core/txpool"
+	"github.com/ava-labs/coreth/core/txpool/legacypool"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// defaultRecentDrops is the number of drops returned by txpool_recentDrops if
+// no limit is requested.
+const defaultRecentDrops = 100
+
+type dropReasonResult struct {
+	Hash   common.Hash    `json:"hash"`
+	From   common.Address `json:"from"`
+	Nonce  hexutil.Uint64 `json:"nonce"`
+	Reason string         `json:"reason"`
+	Time   time.Time      `json:"time"`
+}
+
+func newDropReasonResult(drop *txpool.DropReason) *dropReasonResult {
+	return &dropReasonResult{
+		Hash:   drop.Hash,
+		From:   drop.From,
+		Nonce:  hexutil.Uint64(drop.Nonce),
+		Reason: drop.Reason,
+		Time:   drop.Time,
+	}
+}
+
+// TxPoolDropsAPI provides an API to find out why transactions were rejected by
+// or disappeared from the transaction pool.
+type TxPoolDropsAPI struct {
+	e *Ethereum
+}
+
+// NewTxPoolDropsAPI creates a new instance of TxPoolDropsAPI.
+func NewTxPoolDropsAPI(e *Ethereum) *TxPoolDropsAPI {
+	return &TxPoolDropsAPI{e: e}
+}
+
+// GetDropReason returns why the transaction with [hash] was recently rejected
+// or dropped by the transaction pool, or null if it is not known to have been.
+func (api *TxPoolDropsAPI) GetDropReason(hash common.Hash) *dropReasonResult {
+	drop := api.e.txPool.DropReason(hash)
+	if drop == nil {
+		return nil
+	}
+	return newDropReasonResult(drop)
+}
+
+// RecentDrops returns up to [limit] (default 100) of the most recently
+// rejected or dropped transactions, most recent first. [limit] is capped to
+// the number of drops retained by the pool.
+func (api *TxPoolDropsAPI) RecentDrops(limit *hexutil.Uint64) []*dropReasonResult {
+	n := defaultRecentDrops
+	if limit != nil {
+		n = int(min(uint64(*limit), legacypool.DropLogSize))
+	}
+	drops := api.e.txPool.RecentDrops(n)
+	results := make([]*dropReasonResult, len(drops))
+	for i, drop := range drops {
+		results[i] = newDropReasonResult(drop)
+	}
+	return results
+}
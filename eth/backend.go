@@ -42,6 +42,7 @@ import (
 	"github.com/ava-labs/coreth/core/rawdb"
 	"github.com/ava-labs/coreth/core/state/pruner"
 	"github.com/ava-labs/coreth/core/txpool"
+	"github.com/ava-labs/coreth/core/txpool/blobpool"
 	"github.com/ava-labs/coreth/core/txpool/legacypool"
 	"github.com/ava-labs/coreth/core/types"
 	"github.com/ava-labs/coreth/core/vm"
@@ -209,6 +210,7 @@ func New(
 			TrieDirtyLimit:                  config.TrieDirtyCache,
 			TrieDirtyCommitTarget:           config.TrieDirtyCommitTarget,
 			TriePrefetcherParallelism:       config.TriePrefetcherParallelism,
+			TriePrefetcherAccessLists:       config.TriePrefetcherAccessLists,
 			Pruning:                         config.Pruning,
 			AcceptorQueueLimit:              config.AcceptorQueueLimit,
 			CommitInterval:                  config.CommitInterval,
@@ -224,6 +226,9 @@ func New(
 			AcceptedCacheSize:               config.AcceptedCacheSize,
 			TransactionHistory:              config.TransactionHistory,
 			SkipTxIndexing:                  config.SkipTxIndexing,
+			AssetTransferIndexing:           config.AssetTransferIndexing,
+			OpcodeStats:                     config.OpcodeStats,
+			ParallelTxExecutionWorkers:      config.ParallelTxExecutionWorkers,
 			StateHistory:                    config.StateHistory,
 			StateScheme:                     scheme,
 		}
@@ -243,14 +248,12 @@ func New(
 
 	eth.bloomIndexer.Start(eth.blockchain)
 
-	// Uncomment the following to enable the new blobpool
-
-	// config.BlobPool.Datadir = ""
-	// blobPool := blobpool.New(config.BlobPool, &chainWithFinalBlock{eth.blockchain})
-
-	legacyPool := legacypool.New(config.TxPool, eth.blockchain)
+	subpools := []txpool.SubPool{legacypool.New(config.TxPool, eth.blockchain)}
+	if config.BlobPoolEnabled {
+		subpools = append(subpools, blobpool.New(config.BlobPool, &chainWithFinalBlock{eth.blockchain}))
+	}
 
-	eth.txPool, err = txpool.New(config.TxPool.PriceLimit, eth.blockchain, []txpool.SubPool{legacyPool}) //, blobPool})
+	eth.txPool, err = txpool.New(config.TxPool.PriceLimit, eth.blockchain, subpools)
 	if err != nil {
 		return nil, err
 	}
@@ -324,6 +327,14 @@ func (s *Ethereum) APIs() []rpc.API {
 			Namespace: "debug",
 			Service:   NewDebugAPI(s),
 			Name:      "debug",
+		}, {
+			Namespace: "eth",
+			Service:   NewBundleAPI(s),
+			Name:      "eth-bundle",
+		}, {
+			Namespace: "txpool",
+			Service:   NewTxPoolDropsAPI(s),
+			Name:      "tx-pool-drops",
 		}, {
 			Namespace: "net",
 			Service:   s.netRPCService,
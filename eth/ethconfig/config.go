@@ -104,6 +104,7 @@ type Config struct {
 	TrieDirtyCache            int
 	TrieDirtyCommitTarget     int
 	TriePrefetcherParallelism int
+	TriePrefetcherAccessLists bool
 	SnapshotCache             int
 	Preimages                 bool
 
@@ -118,6 +119,10 @@ type Config struct {
 	TxPool   legacypool.Config
 	BlobPool blobpool.Config
 
+	// BlobPoolEnabled enables the blob transaction pool, storing blob
+	// transactions in BlobPool.Datadir (in memory if empty).
+	BlobPoolEnabled bool
+
 	// Gas Price Oracle options
 	GPO gasprice.Config
 
@@ -173,4 +178,17 @@ type Config struct {
 	// This is useful for validators that don't need to index transactions.
 	// TransactionHistory can be still used to control unindexing old transactions.
 	SkipTxIndexing bool
+
+	// AssetTransferIndexing indexes multi-coin transfers by address and asset ID
+	// so they can be served by eth_getAssetTransfers.
+	AssetTransferIndexing bool
+
+	// OpcodeStats collects opcode execution statistics of accepted blocks,
+	// served by debug_opcodeStats.
+	OpcodeStats bool
+
+	// ParallelTxExecutionWorkers is the number of workers used to optimistically
+	// execute the transactions of a block in parallel. Transactions are executed
+	// sequentially if it is less than 2.
+	ParallelTxExecutionWorkers int
 }
@@ -0,0 +1,130 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gasprice
+
+import (
+	"context"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/ava-labs/coreth/core/types"
+	"github.com/ava-labs/coreth/params"
+	"github.com/ava-labs/coreth/rpc"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// feeWindowSize is the number of gas consumption entries in the dynamic fee
+// window stored in the header extra data.
+const feeWindowSize = params.DynamicFeeExtraDataSize / 8
+
+// AvaxFeeHistory is the fee market history of a range of blocks, including
+// the Avalanche specific header fields. Each slice holds one entry per block,
+// entries which are not defined by the rules active at a block are nil.
+type AvaxFeeHistory struct {
+	OldestBlock    *big.Int
+	BaseFee        []*big.Int
+	GasUsedRatio   []float64
+	BlockGasCost   []*big.Int
+	ExtDataGasUsed []*big.Int
+	MinRequiredTip []*big.Int
+	FeeWindow      [][]uint64
+}
+
+// AvaxFeeHistory returns the base fee, gas used ratio, block gas cost, atomic
+// gas used, minimum required tip and dynamic fee window of the specified range
+// of blocks. The range is resolved and limited as for FeeHistory. Unlike
+// FeeHistory, only headers are needed, which are served from the fee info
+// cache for the most recently accepted blocks.
+func (oracle *Oracle) AvaxFeeHistory(ctx context.Context, blocks uint64, unresolvedLastBlock rpc.BlockNumber) (*AvaxFeeHistory, error) {
+	history := &AvaxFeeHistory{OldestBlock: new(big.Int)}
+	if blocks < 1 {
+		return history, nil // returning with no data and no error means there are no retrievable blocks
+	}
+	if blocks > oracle.maxCallBlockHistory {
+		log.Warn("Sanitizing avax fee history length", "requested", blocks, "truncated", oracle.maxCallBlockHistory)
+		blocks = oracle.maxCallBlockHistory
+	}
+	lastBlock, blocks, err := oracle.resolveBlockRange(ctx, unresolvedLastBlock, blocks)
+	if err != nil || blocks == 0 {
+		return history, err
+	}
+	oldestBlock := lastBlock + 1 - blocks
+	history.OldestBlock.SetUint64(oldestBlock)
+
+	for blockNumber := oldestBlock; blockNumber < oldestBlock+blocks; blockNumber++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var header *types.Header
+		feeInfo, ok := oracle.feeInfoProvider.get(blockNumber)
+		if !ok {
+			// Don't cache historical blocks, as they would evict the recent
+			// blocks used for gas price estimation.
+			header, err = oracle.backend.HeaderByNumber(ctx, rpc.BlockNumber(blockNumber))
+			if err != nil {
+				return nil, err
+			}
+			// getting no header and no error means we are requesting into the future (might happen because of a reorg)
+			if header == nil {
+				break
+			}
+			if feeInfo, err = oracle.feeInfoProvider.newFeeInfo(ctx, header); err != nil {
+				return nil, err
+			}
+		}
+		minRequiredTip, err := oracle.avaxMinRequiredTip(ctx, blockNumber, header, feeInfo)
+		if err != nil {
+			return nil, err
+		}
+		var gasUsedRatio float64
+		if feeInfo.gasLimit != 0 {
+			gasUsedRatio = float64(feeInfo.gasUsed) / float64(feeInfo.gasLimit)
+		}
+		history.BaseFee = append(history.BaseFee, feeInfo.baseFee)
+		history.GasUsedRatio = append(history.GasUsedRatio, gasUsedRatio)
+		history.BlockGasCost = append(history.BlockGasCost, feeInfo.blockGasCost)
+		history.ExtDataGasUsed = append(history.ExtDataGasUsed, feeInfo.extDataGasUsed)
+		history.MinRequiredTip = append(history.MinRequiredTip, minRequiredTip)
+		history.FeeWindow = append(history.FeeWindow, decodeFeeWindow(feeInfo.feeWindow))
+	}
+	if len(history.BaseFee) == 0 {
+		history.OldestBlock.SetUint64(0)
+	}
+	return history, nil
+}
+
+// avaxMinRequiredTip returns the minimum tip required to be included in block
+// [blockNumber], or nil if the block consumed no gas. Unlike [feeInfo.tip],
+// the tip is returned for blocks that consumed less than the minimum gas used
+// for gas price estimation, in which case it is computed from [header], which
+// is fetched if nil.
+func (oracle *Oracle) avaxMinRequiredTip(ctx context.Context, blockNumber uint64, header *types.Header, feeInfo *feeInfo) (*big.Int, error) {
+	if feeInfo.gasUsed == 0 && (feeInfo.extDataGasUsed == nil || feeInfo.extDataGasUsed.Sign() == 0) {
+		return nil, nil
+	}
+	if feeInfo.tip != nil {
+		return feeInfo.tip, nil
+	}
+	if header == nil {
+		var err error
+		header, err = oracle.backend.HeaderByNumber(ctx, rpc.BlockNumber(blockNumber))
+		if err != nil || header == nil {
+			return nil, err
+		}
+	}
+	return oracle.backend.MinRequiredTip(ctx, header)
+}
+
+// decodeFeeWindow returns the gas consumed in each second of the dynamic fee
+// window, or nil if [window] is empty.
+func decodeFeeWindow(window []byte) []uint64 {
+	if len(window) < params.DynamicFeeExtraDataSize {
+		return nil
+	}
+	gas := make([]uint64, feeWindowSize)
+	for i := range gas {
+		gas[i] = binary.BigEndian.Uint64(window[i*8:])
+	}
+	return gas
+}
@@ -0,0 +1,96 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gasprice
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/ava-labs/coreth/consensus/dummy"
+	"github.com/ava-labs/coreth/params"
+	"github.com/ava-labs/coreth/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAvaxFeeHistory(t *testing.T) {
+	require := require.New(t)
+
+	extDataGasUsage := big.NewInt(10_000)
+	backend := newTestBackend(t, params.TestChainConfig, 5, extDataGasUsage, testGenBlock(t, 55, 370))
+	defer backend.teardown()
+	minGasUsed := big.NewInt(math.MaxInt64)
+	oracle, err := NewOracle(backend, Config{Blocks: 2, MinGasUsed: minGasUsed})
+	require.NoError(err)
+	cached := oracle.feeInfoProvider.cache.Len()
+
+	history, err := oracle.AvaxFeeHistory(context.Background(), 3, rpc.LatestBlockNumber)
+	require.NoError(err)
+	require.Equal(uint64(3), history.OldestBlock.Uint64())
+	require.Len(history.BaseFee, 3)
+	require.Len(history.GasUsedRatio, 3)
+	require.Len(history.BlockGasCost, 3)
+	require.Len(history.ExtDataGasUsed, 3)
+	require.Len(history.MinRequiredTip, 3)
+	require.Len(history.FeeWindow, 3)
+	for i := range history.BaseFee {
+		header, err := backend.HeaderByNumber(context.Background(), rpc.BlockNumber(3+i))
+		require.NoError(err)
+		require.Equal(header.BaseFee, history.BaseFee[i])
+		require.Equal(float64(header.GasUsed)/float64(header.GasLimit), history.GasUsedRatio[i])
+		require.Equal(header.BlockGasCost, history.BlockGasCost[i])
+		require.Equal(extDataGasUsage, history.ExtDataGasUsed[i])
+		tip, err := dummy.MinRequiredTip(params.TestChainConfig, header)
+		require.NoError(err)
+		require.Equal(tip, history.MinRequiredTip[i])
+		require.Len(history.FeeWindow[i], feeWindowSize)
+		require.Equal(header.Extra[:params.DynamicFeeExtraDataSize], encodeFeeWindow(history.FeeWindow[i]))
+	}
+	// Blocks outside of the fee info cache are not added to it.
+	require.Equal(cached, oracle.feeInfoProvider.cache.Len())
+	// The cache only holds the tip of blocks using at least the minimum gas
+	// used, the tip of the other blocks is computed when requested.
+	feeInfo, ok := oracle.feeInfoProvider.get(5)
+	require.True(ok)
+	require.Less(feeInfo.gasUsed, minGasUsed.Uint64())
+	require.Nil(feeInfo.tip)
+	require.NotNil(history.MinRequiredTip[2])
+
+	history, err = oracle.AvaxFeeHistory(context.Background(), 0, rpc.LatestBlockNumber)
+	require.NoError(err)
+	require.Zero(history.OldestBlock.Sign())
+	require.Empty(history.BaseFee)
+
+	_, err = oracle.AvaxFeeHistory(context.Background(), 3, 10)
+	require.ErrorIs(err, errRequestBeyondHead)
+}
+
+func TestAvaxFeeHistoryPreApricotPhase4(t *testing.T) {
+	require := require.New(t)
+
+	backend := newTestBackend(t, params.TestApricotPhase3Config, 5, nil, testGenBlock(t, 55, 370))
+	defer backend.teardown()
+	oracle, err := NewOracle(backend, Config{Blocks: 2})
+	require.NoError(err)
+
+	history, err := oracle.AvaxFeeHistory(context.Background(), 3, rpc.LatestBlockNumber)
+	require.NoError(err)
+	require.Len(history.BaseFee, 3)
+	for i := range history.BaseFee {
+		require.NotNil(history.BaseFee[i])
+		require.NotNil(history.FeeWindow[i])
+		require.Nil(history.BlockGasCost[i])
+		require.Nil(history.ExtDataGasUsed[i])
+		require.Nil(history.MinRequiredTip[i])
+	}
+}
+
+func encodeFeeWindow(gas []uint64) []byte {
+	window := make([]byte, params.DynamicFeeExtraDataSize)
+	for i, g := range gas {
+		big.NewInt(0).SetUint64(g).FillBytes(window[i*8 : (i+1)*8])
+	}
+	return window
+}
@@ -32,6 +32,7 @@ import (
 
 	"github.com/ava-labs/coreth/core"
 	"github.com/ava-labs/coreth/core/types"
+	"github.com/ava-labs/coreth/params"
 	"github.com/ava-labs/coreth/rpc"
 	lru "github.com/hashicorp/golang-lru"
 )
@@ -54,6 +55,12 @@ type feeInfoProvider struct {
 type feeInfo struct {
 	baseFee, tip *big.Int // baseFee and min. suggested tip for tx to be included in the block
 	timestamp    uint64   // timestamp of the block header
+
+	// Fields reported by AvaxFeeHistory.
+	gasUsed, gasLimit uint64
+	blockGasCost      *big.Int // nil prior to ApricotPhase4
+	extDataGasUsed    *big.Int // nil prior to ApricotPhase4
+	feeWindow         []byte   // dynamic fee window, nil prior to ApricotPhase3
 }
 
 // newFeeInfoProvider returns a bounded buffer with [size] slots to
@@ -88,9 +95,23 @@ func newFeeInfoProvider(backend OracleBackend, minGasUsed uint64, size int) (*fe
 
 // addHeader processes header into a feeInfo struct and caches the result.
 func (f *feeInfoProvider) addHeader(ctx context.Context, header *types.Header) (*feeInfo, error) {
+	feeInfo, err := f.newFeeInfo(ctx, header)
+	f.cache.Add(header.Number.Uint64(), feeInfo)
+	return feeInfo, err
+}
+
+// newFeeInfo processes header into a feeInfo struct without caching it.
+func (f *feeInfoProvider) newFeeInfo(ctx context.Context, header *types.Header) (*feeInfo, error) {
 	feeInfo := &feeInfo{
-		timestamp: header.Time,
-		baseFee:   header.BaseFee,
+		timestamp:      header.Time,
+		baseFee:        header.BaseFee,
+		gasUsed:        header.GasUsed,
+		gasLimit:       header.GasLimit,
+		blockGasCost:   header.BlockGasCost,
+		extDataGasUsed: header.ExtDataGasUsed,
+	}
+	if header.BaseFee != nil && len(header.Extra) >= params.DynamicFeeExtraDataSize {
+		feeInfo.feeWindow = header.Extra[:params.DynamicFeeExtraDataSize]
 	}
 	// Don't bias the estimate with blocks containing a limited number of transactions paying to
 	// expedite block production.
@@ -106,8 +127,6 @@ func (f *feeInfoProvider) addHeader(ctx context.Context, header *types.Header) (
 		// delay in transaction inclusion.
 		feeInfo.tip, err = f.backend.MinRequiredTip(ctx, header)
 	}
-
-	f.cache.Add(header.Number.Uint64(), feeInfo)
 	return feeInfo, err
 }
 
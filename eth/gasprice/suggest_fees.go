@@ -0,0 +1,142 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gasprice
+
+import (
+	"context"
+	"math"
+	"math/big"
+
+	"github.com/ava-labs/coreth/params"
+	"github.com/ava-labs/coreth/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/exp/slices"
+)
+
+// DefaultSuggestFeesBlocks is the number of blocks within which the inclusion
+// probability of the suggested fees is estimated if none is requested.
+const DefaultSuggestFeesBlocks = 3
+
+// Percentiles of the recent minimum required tips the fee tiers are based on.
+const (
+	slowPercentile     = 30
+	standardPercentile = 60
+	fastPercentile     = 90
+)
+
+// FeeTier is a suggested tip and fee cap along with the estimated probability
+// that a transaction paying them is included within the requested blocks.
+type FeeTier struct {
+	TipCap               *big.Int
+	FeeCap               *big.Int // nil if the base fee is not yet enabled
+	InclusionProbability float64
+}
+
+// SuggestedFees are the fee tiers suggested for inclusion within [Blocks]
+// blocks, given the [Pending] transactions in the transaction pool.
+type SuggestedFees struct {
+	BaseFee  *big.Int // nil if the base fee is not yet enabled
+	Blocks   uint64
+	Pending  int
+	Slow     FeeTier
+	Standard FeeTier
+	Fast     FeeTier
+}
+
+// SuggestFees returns slow, standard and fast fee tiers along with the
+// estimated probability of a transaction paying them being included within
+// [blocks] blocks.
+//
+// The probability of a tip being sufficient for a single block is the share
+// of recently sampled blocks whose minimum required tip it covers. As blocks
+// are built by descending tip, the [pending] transactions expected to outbid
+// the tip (assuming they follow the same distribution) delay its inclusion by
+// the number of blocks needed to include them.
+func (oracle *Oracle) SuggestFees(ctx context.Context, pending int, blocks uint64) (*SuggestedFees, error) {
+	if blocks == 0 {
+		blocks = DefaultSuggestFeesBlocks
+	}
+	head, err := oracle.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	feeInfos, err := oracle.recentFeeInfos(ctx, head.Number.Uint64())
+	if err != nil {
+		return nil, err
+	}
+	tips := make([]*big.Int, len(feeInfos))
+	for i, feeInfo := range feeInfos {
+		if tips[i] = feeInfo.tip; tips[i] == nil {
+			tips[i] = common.Big0
+		}
+	}
+	slices.SortFunc(tips, func(a, b *big.Int) int { return a.Cmp(b) })
+
+	baseFee, err := oracle.EstimateBaseFee(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// The number of pending transactions that fit in a block, assuming they
+	// are simple transfers.
+	capacity := head.GasLimit / params.TxGas
+	if capacity == 0 {
+		capacity = 1
+	}
+	oracle.cacheLock.RLock()
+	lastPrice := oracle.lastPrice
+	oracle.cacheLock.RUnlock()
+	tier := func(percentile int) FeeTier {
+		tip := lastPrice
+		if len(tips) > 0 {
+			tip = tips[(len(tips)-1)*percentile/100]
+		}
+		tip = new(big.Int).Set(tip)
+		if tip.Cmp(oracle.maxPrice) > 0 {
+			tip.Set(oracle.maxPrice)
+		}
+		if tip.Cmp(oracle.minPrice) < 0 {
+			tip.Set(oracle.minPrice)
+		}
+		result := FeeTier{
+			TipCap:               tip,
+			InclusionProbability: inclusionProbability(tips, tip, pending, capacity, blocks),
+		}
+		if baseFee != nil {
+			result.FeeCap = new(big.Int).Add(baseFee, tip)
+		}
+		return result
+	}
+	return &SuggestedFees{
+		BaseFee:  baseFee,
+		Blocks:   blocks,
+		Pending:  pending,
+		Slow:     tier(slowPercentile),
+		Standard: tier(standardPercentile),
+		Fast:     tier(fastPercentile),
+	}, nil
+}
+
+// inclusionProbability estimates the probability that a transaction paying
+// [tip] is included within [blocks] blocks, given the sorted recent minimum
+// required [tips] and [pending] transactions competing for [capacity]
+// transactions per block.
+func inclusionProbability(tips []*big.Int, tip *big.Int, pending int, capacity uint64, blocks uint64) float64 {
+	// Without recent blocks to sample there is no competition for inclusion.
+	p := 1.0
+	if len(tips) > 0 {
+		covered, _ := slices.BinarySearchFunc(tips, tip, func(a, b *big.Int) int {
+			if a.Cmp(b) <= 0 {
+				return -1
+			}
+			return 1
+		})
+		p = float64(covered) / float64(len(tips))
+	}
+	outbidding := float64(pending) * (1 - p)
+	delay := uint64(outbidding) / capacity
+	if delay >= blocks {
+		return 0
+	}
+	return 1 - math.Pow(1-p, float64(blocks-delay))
+}
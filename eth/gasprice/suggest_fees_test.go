@@ -0,0 +1,77 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gasprice
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/coreth/params"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInclusionProbability(t *testing.T) {
+	tips := []*big.Int{big.NewInt(0), big.NewInt(0), big.NewInt(10), big.NewInt(20)}
+	tests := []struct {
+		name     string
+		tips     []*big.Int
+		tip      int64
+		pending  int
+		blocks   uint64
+		expected float64
+	}{
+		{name: "no samples", tip: 0, blocks: 1, expected: 1},
+		{name: "covers all blocks", tips: tips, tip: 20, pending: 1000, blocks: 1, expected: 1},
+		{name: "covers half of the blocks", tips: tips, tip: 5, blocks: 1, expected: 0.5},
+		{name: "covers half of the blocks over two blocks", tips: tips, tip: 5, blocks: 2, expected: 0.75},
+		{name: "covers three quarters of the blocks", tips: tips, tip: 10, blocks: 1, expected: 0.75},
+		{name: "delayed by pending transactions", tips: tips, tip: 5, pending: 200, blocks: 2, expected: 0.5},
+		{name: "delayed beyond the requested blocks", tips: tips, tip: 5, pending: 400, blocks: 2, expected: 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := inclusionProbability(test.tips, big.NewInt(test.tip), test.pending, 100, test.blocks)
+			require.InDelta(t, test.expected, got, 1e-9)
+		})
+	}
+}
+
+func TestSuggestFees(t *testing.T) {
+	require := require.New(t)
+
+	backend := newTestBackend(t, params.TestChainConfig, 3, big.NewInt(1), testGenBlock(t, 55, 370))
+	defer backend.teardown()
+	oracle, err := NewOracle(backend, Config{Blocks: 20, Percentile: 60})
+	require.NoError(err)
+	oracle.clock.Set(time.Unix(20, 0))
+
+	fees, err := oracle.SuggestFees(context.Background(), 0, 0)
+	require.NoError(err)
+	require.Equal(uint64(DefaultSuggestFeesBlocks), fees.Blocks)
+	require.NotNil(fees.BaseFee)
+
+	// The standard tier matches the suggested tip.
+	tip, err := oracle.SuggestTipCap(context.Background())
+	require.NoError(err)
+	require.Equal(tip, fees.Standard.TipCap)
+
+	tiers := []FeeTier{fees.Slow, fees.Standard, fees.Fast}
+	for i, tier := range tiers {
+		require.Equal(new(big.Int).Add(fees.BaseFee, tier.TipCap), tier.FeeCap)
+		require.GreaterOrEqual(tier.InclusionProbability, 0.0)
+		require.LessOrEqual(tier.InclusionProbability, 1.0)
+		if i > 0 {
+			require.GreaterOrEqual(tier.TipCap.Cmp(tiers[i-1].TipCap), 0)
+			require.GreaterOrEqual(tier.InclusionProbability, tiers[i-1].InclusionProbability)
+		}
+	}
+
+	// Pending transactions lower the inclusion probability of lower tiers.
+	congested, err := oracle.SuggestFees(context.Background(), 1_000_000, 1)
+	require.NoError(err)
+	require.Equal(fees.Slow.TipCap, congested.Slow.TipCap)
+	require.Zero(congested.Slow.InclusionProbability)
+}
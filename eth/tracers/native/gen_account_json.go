@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"math/big"
 
+	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 )
@@ -15,26 +16,34 @@ var _ = (*accountMarshaling)(nil)
 // MarshalJSON marshals as JSON.
 func (a account) MarshalJSON() ([]byte, error) {
 	type account struct {
-		Balance *hexutil.Big                `json:"balance,omitempty"`
-		Code    hexutil.Bytes               `json:"code,omitempty"`
-		Nonce   uint64                      `json:"nonce,omitempty"`
-		Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+		Balance           *hexutil.Big                `json:"balance,omitempty"`
+		Code              hexutil.Bytes               `json:"code,omitempty"`
+		Nonce             uint64                      `json:"nonce,omitempty"`
+		Storage           map[common.Hash]common.Hash `json:"storage,omitempty"`
+		MultiCoinBalances map[ids.ID]*hexutil.Big     `json:"multiCoinBalances,omitempty"`
 	}
 	var enc account
 	enc.Balance = (*hexutil.Big)(a.Balance)
 	enc.Code = a.Code
 	enc.Nonce = a.Nonce
 	enc.Storage = a.Storage
+	if a.MultiCoinBalances != nil {
+		enc.MultiCoinBalances = make(map[ids.ID]*hexutil.Big, len(a.MultiCoinBalances))
+		for k, v := range a.MultiCoinBalances {
+			enc.MultiCoinBalances[k] = (*hexutil.Big)(v)
+		}
+	}
 	return json.Marshal(&enc)
 }
 
 // UnmarshalJSON unmarshals from JSON.
 func (a *account) UnmarshalJSON(input []byte) error {
 	type account struct {
-		Balance *hexutil.Big                `json:"balance,omitempty"`
-		Code    *hexutil.Bytes              `json:"code,omitempty"`
-		Nonce   *uint64                     `json:"nonce,omitempty"`
-		Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+		Balance           *hexutil.Big                `json:"balance,omitempty"`
+		Code              *hexutil.Bytes              `json:"code,omitempty"`
+		Nonce             *uint64                     `json:"nonce,omitempty"`
+		Storage           map[common.Hash]common.Hash `json:"storage,omitempty"`
+		MultiCoinBalances map[ids.ID]*hexutil.Big     `json:"multiCoinBalances,omitempty"`
 	}
 	var dec account
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -52,5 +61,11 @@ func (a *account) UnmarshalJSON(input []byte) error {
 	if dec.Storage != nil {
 		a.Storage = dec.Storage
 	}
+	if dec.MultiCoinBalances != nil {
+		a.MultiCoinBalances = make(map[ids.ID]*big.Int, len(dec.MultiCoinBalances))
+		for k, v := range dec.MultiCoinBalances {
+			a.MultiCoinBalances[k] = (*big.Int)(v)
+		}
+	}
 	return nil
 }
@@ -32,6 +32,7 @@ import (
 	"math/big"
 	"sync/atomic"
 
+	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/coreth/core/vm"
 	"github.com/ava-labs/coreth/eth/tracers"
 	"github.com/ethereum/go-ethereum/common"
@@ -49,19 +50,21 @@ func init() {
 type state = map[common.Address]*account
 
 type account struct {
-	Balance *big.Int                    `json:"balance,omitempty"`
-	Code    []byte                      `json:"code,omitempty"`
-	Nonce   uint64                      `json:"nonce,omitempty"`
-	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+	Balance           *big.Int                    `json:"balance,omitempty"`
+	Code              []byte                      `json:"code,omitempty"`
+	Nonce             uint64                      `json:"nonce,omitempty"`
+	Storage           map[common.Hash]common.Hash `json:"storage,omitempty"`
+	MultiCoinBalances map[ids.ID]*big.Int         `json:"multiCoinBalances,omitempty"`
 }
 
 func (a *account) exists() bool {
-	return a.Nonce > 0 || len(a.Code) > 0 || len(a.Storage) > 0 || (a.Balance != nil && a.Balance.Sign() != 0)
+	return a.Nonce > 0 || len(a.Code) > 0 || len(a.Storage) > 0 || len(a.MultiCoinBalances) > 0 || (a.Balance != nil && a.Balance.Sign() != 0)
 }
 
 type accountMarshaling struct {
-	Balance *hexutil.Big
-	Code    hexutil.Bytes
+	Balance           *hexutil.Big
+	Code              hexutil.Bytes
+	MultiCoinBalances map[ids.ID]*hexutil.Big
 }
 
 type prestateTracer struct {
@@ -108,6 +111,7 @@ func (t *prestateTracer) CaptureStart(env *vm.EVM, from common.Address, to commo
 	t.lookupAccount(from)
 	t.lookupAccount(to)
 	t.lookupAccount(env.Context.Coinbase)
+	t.lookupNativeAsset(from, to, input)
 
 	// The recipient balance includes the value transferred.
 	toBal := new(big.Int).Sub(t.pre[to].Balance, value)
@@ -189,6 +193,15 @@ func (t *prestateTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64,
 	}
 }
 
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *prestateTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	// Skip if tracing was interrupted
+	if t.interrupt.Load() {
+		return
+	}
+	t.lookupNativeAsset(from, to, input)
+}
+
 func (t *prestateTracer) CaptureTxStart(gasLimit uint64) {
 	t.gasLimit = gasLimit
 }
@@ -204,7 +217,10 @@ func (t *prestateTracer) CaptureTxEnd(restGas uint64) {
 			continue
 		}
 		modified := false
-		postAccount := &account{Storage: make(map[common.Hash]common.Hash)}
+		postAccount := &account{
+			Storage:           make(map[common.Hash]common.Hash),
+			MultiCoinBalances: make(map[ids.ID]*big.Int),
+		}
 		newBalance := t.env.StateDB.GetBalance(addr).ToBig()
 		newNonce := t.env.StateDB.GetNonce(addr)
 		newCode := t.env.StateDB.GetCode(addr)
@@ -240,6 +256,17 @@ func (t *prestateTracer) CaptureTxEnd(restGas uint64) {
 			}
 		}
 
+		for assetID, bal := range state.MultiCoinBalances {
+			newBal := t.env.StateDB.GetBalanceMultiCoin(addr, common.Hash(assetID))
+			if bal.Cmp(newBal) == 0 {
+				// Omit unchanged balances
+				delete(t.pre[addr].MultiCoinBalances, assetID)
+			} else {
+				modified = true
+				postAccount.MultiCoinBalances[assetID] = newBal
+			}
+		}
+
 		if modified {
 			t.post[addr] = postAccount
 		} else {
@@ -289,10 +316,11 @@ func (t *prestateTracer) lookupAccount(addr common.Address) {
 	}
 
 	t.pre[addr] = &account{
-		Balance: t.env.StateDB.GetBalance(addr).ToBig(),
-		Nonce:   t.env.StateDB.GetNonce(addr),
-		Code:    t.env.StateDB.GetCode(addr),
-		Storage: make(map[common.Hash]common.Hash),
+		Balance:           t.env.StateDB.GetBalance(addr).ToBig(),
+		Nonce:             t.env.StateDB.GetNonce(addr),
+		Code:              t.env.StateDB.GetCode(addr),
+		Storage:           make(map[common.Hash]common.Hash),
+		MultiCoinBalances: make(map[ids.ID]*big.Int),
 	}
 }
 
@@ -313,3 +341,34 @@ func (t *prestateTracer) lookupStorage(addr common.Address, key common.Hash) {
 	}
 	t.pre[addr].Storage[key] = t.env.StateDB.GetState(addr, key)
 }
+
+// lookupMultiCoinBalance fetches the balance of [assetID] held by [addr] and adds
+// it to the prestate of the given account.
+func (t *prestateTracer) lookupMultiCoinBalance(addr common.Address, assetID common.Hash) {
+	t.lookupAccount(addr)
+	if _, ok := t.pre[addr].MultiCoinBalances[ids.ID(assetID)]; ok {
+		return
+	}
+	t.pre[addr].MultiCoinBalances[ids.ID(assetID)] = t.env.StateDB.GetBalanceMultiCoin(addr, assetID)
+}
+
+// lookupNativeAsset adds the multi-coin balances read or modified by a call from
+// [caller] to the nativeAssetBalance or nativeAssetCall precompiles to the prestate.
+// Calls to any other address are ignored.
+func (t *prestateTracer) lookupNativeAsset(caller common.Address, addr common.Address, input []byte) {
+	switch addr {
+	case vm.NativeAssetBalanceAddr:
+		owner, assetID, err := vm.UnpackNativeAssetBalanceInput(input)
+		if err != nil {
+			return
+		}
+		t.lookupMultiCoinBalance(owner, assetID)
+	case vm.NativeAssetCallAddr:
+		to, assetID, _, _, err := vm.UnpackNativeAssetCallInput(input)
+		if err != nil {
+			return
+		}
+		t.lookupMultiCoinBalance(caller, assetID)
+		t.lookupMultiCoinBalance(to, assetID)
+	}
+}
@@ -33,6 +33,7 @@ import (
 	"runtime"
 	"runtime/debug"
 
+	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/coreth/core/types"
 	"github.com/ava-labs/coreth/ethclient"
 	"github.com/ava-labs/coreth/interfaces"
@@ -135,11 +136,12 @@ func (ec *Client) GetProof(ctx context.Context, account common.Address, keys []s
 
 // OverrideAccount specifies the state of an account to be overridden.
 type OverrideAccount struct {
-	Nonce     uint64                      `json:"nonce"`
-	Code      []byte                      `json:"code"`
-	Balance   *big.Int                    `json:"balance"`
-	State     map[common.Hash]common.Hash `json:"state"`
-	StateDiff map[common.Hash]common.Hash `json:"stateDiff"`
+	Nonce             uint64                      `json:"nonce"`
+	Code              []byte                      `json:"code"`
+	Balance           *big.Int                    `json:"balance"`
+	State             map[common.Hash]common.Hash `json:"state"`
+	StateDiff         map[common.Hash]common.Hash `json:"stateDiff"`
+	MultiCoinBalances map[ids.ID]*big.Int         `json:"multiCoinBalances"`
 }
 
 // CallContract executes a message call transaction, which is directly executed in the VM
@@ -220,20 +222,29 @@ func toOverrideMap(overrides *map[common.Address]OverrideAccount) interface{} {
 		return nil
 	}
 	type overrideAccount struct {
-		Nonce     hexutil.Uint64              `json:"nonce"`
-		Code      hexutil.Bytes               `json:"code"`
-		Balance   *hexutil.Big                `json:"balance"`
-		State     map[common.Hash]common.Hash `json:"state"`
-		StateDiff map[common.Hash]common.Hash `json:"stateDiff"`
+		Nonce             hexutil.Uint64              `json:"nonce"`
+		Code              hexutil.Bytes               `json:"code"`
+		Balance           *hexutil.Big                `json:"balance"`
+		State             map[common.Hash]common.Hash `json:"state"`
+		StateDiff         map[common.Hash]common.Hash `json:"stateDiff"`
+		MultiCoinBalances map[ids.ID]*hexutil.Big     `json:"multiCoinBalances"`
 	}
 	result := make(map[common.Address]overrideAccount)
 	for addr, override := range *overrides {
+		var multiCoinBalances map[ids.ID]*hexutil.Big
+		if override.MultiCoinBalances != nil {
+			multiCoinBalances = make(map[ids.ID]*hexutil.Big, len(override.MultiCoinBalances))
+			for assetID, balance := range override.MultiCoinBalances {
+				multiCoinBalances[assetID] = (*hexutil.Big)(balance)
+			}
+		}
 		result[addr] = overrideAccount{
-			Nonce:     hexutil.Uint64(override.Nonce),
-			Code:      override.Code,
-			Balance:   (*hexutil.Big)(override.Balance),
-			State:     override.State,
-			StateDiff: override.StateDiff,
+			Nonce:             hexutil.Uint64(override.Nonce),
+			Code:              override.Code,
+			Balance:           (*hexutil.Big)(override.Balance),
+			State:             override.State,
+			StateDiff:         override.StateDiff,
+			MultiCoinBalances: multiCoinBalances,
 		}
 	}
 	return &result
@@ -91,6 +91,7 @@ type Client interface {
 	NetworkID(context.Context) (*big.Int, error)
 	BalanceAt(context.Context, common.Address, *big.Int) (*big.Int, error)
 	AssetBalanceAt(context.Context, common.Address, ids.ID, *big.Int) (*big.Int, error)
+	AssetTransfers(context.Context, common.Address, ids.ID, *big.Int, *big.Int) ([]*types.AssetTransfer, error)
 	BalanceAtHash(ctx context.Context, account common.Address, blockHash common.Hash) (*big.Int, error)
 	StorageAt(context.Context, common.Address, common.Hash, *big.Int) ([]byte, error)
 	StorageAtHash(ctx context.Context, account common.Address, key common.Hash, blockHash common.Hash) ([]byte, error)
@@ -475,6 +476,15 @@ func (ec *client) AssetBalanceAt(ctx context.Context, account common.Address, as
 	return (*big.Int)(&result), err
 }
 
+// AssetTransfers returns the transfers of [assetID] to or from the given account
+// made in accepted blocks in the inclusive range [fromBlock, toBlock].
+// The block numbers can be nil, in which case the latest accepted block is used.
+func (ec *client) AssetTransfers(ctx context.Context, account common.Address, assetID ids.ID, fromBlock *big.Int, toBlock *big.Int) ([]*types.AssetTransfer, error) {
+	var result []*types.AssetTransfer
+	err := ec.c.CallContext(ctx, &result, "eth_getAssetTransfers", account, assetID, ToBlockNumArg(fromBlock), ToBlockNumArg(toBlock))
+	return result, err
+}
+
 // BalanceAtHash returns the wei balance of the given account.
 func (ec *client) BalanceAtHash(ctx context.Context, account common.Address, blockHash common.Hash) (*big.Int, error) {
 	var result hexutil.Big
@@ -121,7 +121,7 @@ func newWithNode(stack *node.Node, conf *eth.Config, blockPeriod uint64) (*Backe
 	clock.Set(time.Unix(0, 0))
 
 	engine := dummy.NewFakerWithModeAndClock(
-		dummy.Mode{ModeSkipCoinbase: true}, clock,
+		dummy.ConsensusCallbacks{}, dummy.Mode{ModeSkipCoinbase: true}, clock,
 	)
 
 	backend, err := eth.New(
@@ -64,6 +64,10 @@ import (
 // allowed to produce in order to speed up calculations.
 const estimateGasErrorRatio = 0.015
 
+// defaultPrivateTxExpiry is the number of blocks within which a transaction
+// sent with eth_sendPrivateTransaction must be included if none is requested.
+const defaultPrivateTxExpiry = 25
+
 var errBlobTxNotSupported = errors.New("signing blob transactions not supported")
 
 // EthereumAPI provides an API to access Ethereum related information.
@@ -960,6 +964,11 @@ type OverrideAccount struct {
 	Balance   **hexutil.Big                `json:"balance"`
 	State     *map[common.Hash]common.Hash `json:"state"`
 	StateDiff *map[common.Hash]common.Hash `json:"stateDiff"`
+
+	// MultiCoinBalances overrides the balances of the given assets. Since
+	// multi-coin balances are kept in the account storage, they are applied
+	// after [State] and [StateDiff].
+	MultiCoinBalances *map[ids.ID]*hexutil.Big `json:"multiCoinBalances"`
 }
 
 // StateOverride is the collection of overridden accounts.
@@ -997,6 +1006,15 @@ func (diff *StateOverride) Apply(state *state.StateDB) error {
 				state.SetState(addr, key, value)
 			}
 		}
+		// Override multi-coin balances.
+		if account.MultiCoinBalances != nil {
+			for assetID, balance := range *account.MultiCoinBalances {
+				if balance == nil {
+					return fmt.Errorf("account %s has nil balance for asset %s", addr.Hex(), assetID)
+				}
+				state.SetBalanceMultiCoin(addr, common.Hash(assetID), balance.ToInt())
+			}
+		}
 	}
 	// Now finalize the changes. Finalize is normally performed between transactions.
 	// By using finalize, the overrides are semantically behaving as
@@ -1770,6 +1788,12 @@ func (s *TransactionAPI) sign(addr common.Address, tx *types.Transaction) (*type
 
 // SubmitTransaction is a helper function that submits tx to txPool and logs a message.
 func SubmitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (common.Hash, error) {
+	return submitTransaction(ctx, b, tx, b.SendTx)
+}
+
+// submitTransaction checks [tx] like SubmitTransaction and adds it to the
+// transaction pool using [send].
+func submitTransaction(ctx context.Context, b Backend, tx *types.Transaction, send func(context.Context, *types.Transaction) error) (common.Hash, error) {
 	// If the transaction fee cap is already specified, ensure the
 	// fee of the given transaction is _reasonable_.
 	if err := checkTxFee(tx.GasPrice(), tx.Gas(), b.RPCTxFeeCap()); err != nil {
@@ -1779,7 +1803,7 @@ func SubmitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (c
 		// Ensure only eip155 signed transactions are submitted if EIP155Required is set.
 		return common.Hash{}, errors.New("only replay-protected (EIP-155) transactions allowed over RPC")
 	}
-	if err := b.SendTx(ctx, tx); err != nil {
+	if err := send(ctx, tx); err != nil {
 		return common.Hash{}, err
 	}
 	// Print a log with full tx details for manual investigations and interventions
@@ -1863,6 +1887,27 @@ func (s *TransactionAPI) SendRawTransaction(ctx context.Context, input hexutil.B
 	return SubmitTransaction(ctx, s.b, tx)
 }
 
+// SendPrivateTransaction will add the signed transaction to the transaction
+// pool without gossiping it to peers, so it is only included in blocks built
+// by this node. The transaction is dropped if it is not included within
+// [blocks] blocks (default 25).
+func (s *TransactionAPI) SendPrivateTransaction(ctx context.Context, input hexutil.Bytes, blocks *hexutil.Uint64) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(input); err != nil {
+		return common.Hash{}, err
+	}
+	expiry := uint64(defaultPrivateTxExpiry)
+	if blocks != nil {
+		expiry = uint64(*blocks)
+	}
+	if expiry == 0 {
+		return common.Hash{}, errors.New("private transaction expiry must be at least one block")
+	}
+	return submitTransaction(ctx, s.b, tx, func(ctx context.Context, tx *types.Transaction) error {
+		return s.b.SendPrivateTx(ctx, tx, expiry)
+	})
+}
+
 // Sign calculates an ECDSA signature for:
 // keccak256("\x19Ethereum Signed Message:\n" + len(message) + message).
 //
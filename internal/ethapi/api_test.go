@@ -41,6 +41,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/coreth/accounts"
 	"github.com/ava-labs/coreth/accounts/keystore"
 	"github.com/ava-labs/coreth/consensus"
@@ -580,6 +581,9 @@ func (b testBackend) SubscribeChainSideEvent(ch chan<- core.ChainSideEvent) even
 func (b testBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
 	panic("implement me")
 }
+func (b testBackend) SendPrivateTx(ctx context.Context, signedTx *types.Transaction, blocks uint64) error {
+	panic("implement me")
+}
 func (b testBackend) GetTransaction(ctx context.Context, txHash common.Hash) (bool, *types.Transaction, common.Hash, uint64, uint64, error) {
 	tx, blockHash, blockNumber, index := rawdb.ReadTransaction(b.db, txHash)
 	return true, tx, blockHash, blockNumber, index, nil
@@ -983,6 +987,31 @@ func TestCall(t *testing.T) {
 	}
 }
 
+func TestStateOverrideMultiCoinBalances(t *testing.T) {
+	var (
+		addr    = common.HexToAddress("0x1234")
+		assetID = ids.ID{0xaa}
+		db      = state.NewDatabase(rawdb.NewMemoryDatabase())
+	)
+	statedb, err := state.New(types.EmptyRootHash, db, nil)
+	require.NoError(t, err)
+
+	overrides := StateOverride{
+		addr: OverrideAccount{
+			State:             &map[common.Hash]common.Hash{{0x02}: {0x01}},
+			MultiCoinBalances: &map[ids.ID]*hexutil.Big{assetID: (*hexutil.Big)(big.NewInt(100))},
+		},
+	}
+	require.NoError(t, overrides.Apply(statedb))
+	require.Equal(t, big.NewInt(100), statedb.GetBalanceMultiCoin(addr, common.Hash(assetID)))
+	require.Equal(t, common.Hash{0x01}, statedb.GetState(addr, common.Hash{0x02}))
+
+	overrides = StateOverride{
+		addr: OverrideAccount{MultiCoinBalances: &map[ids.ID]*hexutil.Big{assetID: nil}},
+	}
+	require.Error(t, overrides.Apply(statedb))
+}
+
 func TestSignTransaction(t *testing.T) {
 	t.Parallel()
 	// Initialize test accounts
@@ -0,0 +1,325 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/coreth/core/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// MaxBundleTxs is the maximum number of transactions in a bundle.
+	MaxBundleTxs = 16
+	// DefaultBundleExpiry is the number of blocks after the current head a
+	// bundle is considered for inclusion in if no maximum block is specified.
+	DefaultBundleExpiry = 25
+	// maxBundles is the maximum number of bundles awaiting inclusion.
+	maxBundles = 256
+	// maxBundleAge is the longest a bundle awaits inclusion, regardless of its
+	// maximum block number.
+	maxBundleAge = time.Minute
+)
+
+var (
+	errEmptyBundle     = errors.New("bundle has no transactions")
+	errBundleTooLarge  = fmt.Errorf("bundle exceeds %d transactions", MaxBundleTxs)
+	errBundleBlobTx    = errors.New("bundle contains a blob transaction")
+	errBundleExpired   = errors.New("bundle maximum block number is not after the current head")
+	errBundleKnown     = errors.New("bundle already known")
+	errTooManyBundles  = errors.New("too many pending bundles")
+	errBundleTxFailed  = errors.New("bundle transaction failed")
+	errBundleTipTooLow = errors.New("bundle tip below minimum")
+	errBundleGasLimit  = errors.New("not enough gas left in block for bundle")
+)
+
+// Bundle is an ordered group of transactions included contiguously and
+// all-or-nothing in a block.
+type Bundle struct {
+	Txs []*types.Transaction
+	// MinTip is the minimum total tip the transactions of the bundle must pay
+	// for it to be included. A nil MinTip imposes no minimum.
+	MinTip *big.Int
+	// MaxBlockNumber is the last block the bundle may be included in.
+	MaxBlockNumber uint64
+}
+
+// Hash returns the hash identifying the bundle, which is the hash of the
+// concatenated hashes of its transactions.
+func (b *Bundle) Hash() common.Hash {
+	hashes := make([]byte, 0, len(b.Txs)*common.HashLength)
+	for _, tx := range b.Txs {
+		hashes = append(hashes, tx.Hash().Bytes()...)
+	}
+	return crypto.Keccak256Hash(hashes)
+}
+
+// NewBundleEvent is posted when a bundle is submitted for inclusion.
+type NewBundleEvent struct{ Bundle *Bundle }
+
+// pooledBundle is a bundle awaiting inclusion.
+type pooledBundle struct {
+	bundle *Bundle
+	added  time.Time // Time the bundle was submitted
+	tried  bool      // Whether the bundle was included in a built block
+}
+
+// bundlePool holds the bundles awaiting inclusion in submission order.
+type bundlePool struct {
+	lock    sync.Mutex
+	bundles []*pooledBundle
+	feed    event.Feed
+}
+
+// add validates and adds [bundle], given the current [head] block number and
+// time [now].
+func (p *bundlePool) add(bundle *Bundle, head uint64, now time.Time) (common.Hash, error) {
+	switch {
+	case len(bundle.Txs) == 0:
+		return common.Hash{}, errEmptyBundle
+	case len(bundle.Txs) > MaxBundleTxs:
+		return common.Hash{}, errBundleTooLarge
+	}
+	for _, tx := range bundle.Txs {
+		if tx.Type() == types.BlobTxType {
+			return common.Hash{}, errBundleBlobTx
+		}
+	}
+	if bundle.MaxBlockNumber == 0 {
+		bundle.MaxBlockNumber = head + DefaultBundleExpiry
+	}
+	if bundle.MaxBlockNumber <= head {
+		return common.Hash{}, errBundleExpired
+	}
+	hash := bundle.Hash()
+	if err := p.insert(bundle, hash, head, now); err != nil {
+		return common.Hash{}, err
+	}
+	// The event is sent without holding the lock, as sending blocks until
+	// every subscriber received it.
+	p.feed.Send(NewBundleEvent{Bundle: bundle})
+	return hash, nil
+}
+
+func (p *bundlePool) insert(bundle *Bundle, hash common.Hash, head uint64, now time.Time) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.expire(head+1, now)
+	if len(p.bundles) >= maxBundles {
+		return errTooManyBundles
+	}
+	for _, pending := range p.bundles {
+		if pending.bundle.Hash() == hash {
+			return errBundleKnown
+		}
+	}
+	p.bundles = append(p.bundles, &pooledBundle{bundle: bundle, added: now})
+	return nil
+}
+
+// pending returns the bundles which may be included in block [number] at
+// time [now], after removing the expired ones.
+func (p *bundlePool) pending(number uint64, now time.Time) []*Bundle {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.expire(number, now)
+	bundles := make([]*Bundle, len(p.bundles))
+	for i, pending := range p.bundles {
+		bundles[i] = pending.bundle
+	}
+	return bundles
+}
+
+// expire removes the bundles which may not be included in block [number], or
+// which were submitted more than [maxBundleAge] before [now].
+// Assumes the lock is held.
+func (p *bundlePool) expire(number uint64, now time.Time) {
+	p.filter(func(pending *pooledBundle) bool {
+		return pending.bundle.MaxBlockNumber >= number && now.Sub(pending.added) <= maxBundleAge
+	})
+}
+
+// filter keeps the bundles for which [keep] returns true.
+// Assumes the lock is held.
+func (p *bundlePool) filter(keep func(pending *pooledBundle) bool) {
+	bundles := p.bundles[:0]
+	for _, pending := range p.bundles {
+		if keep(pending) {
+			bundles = append(bundles, pending)
+		}
+	}
+	for i := len(bundles); i < len(p.bundles); i++ {
+		p.bundles[i] = nil
+	}
+	p.bundles = bundles
+}
+
+// remove removes [bundle] from the pool.
+func (p *bundlePool) remove(bundle *Bundle) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.filter(func(pending *pooledBundle) bool { return pending.bundle != bundle })
+}
+
+// markTried records that [bundle] was included in a built block, so that it
+// no longer requires a block to be built.
+func (p *bundlePool) markTried(bundle *Bundle) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, pending := range p.bundles {
+		if pending.bundle == bundle {
+			pending.tried = true
+			return
+		}
+	}
+}
+
+// removeIncluded removes the bundles with transactions included in [block],
+// which may no longer be included.
+func (p *bundlePool) removeIncluded(block *types.Block) {
+	included := make(map[common.Hash]struct{}, len(block.Transactions()))
+	for _, tx := range block.Transactions() {
+		included[tx.Hash()] = struct{}{}
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.filter(func(pending *pooledBundle) bool {
+		for _, tx := range pending.bundle.Txs {
+			if _, ok := included[tx.Hash()]; ok {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// len returns the number of bundles in the pool.
+func (p *bundlePool) len() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return len(p.bundles)
+}
+
+// untried returns the number of bundles not yet included in a built block
+// which may be included at time [now].
+func (p *bundlePool) untried(now time.Time) int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var count int
+	for _, pending := range p.bundles {
+		if !pending.tried && now.Sub(pending.added) <= maxBundleAge {
+			count++
+		}
+	}
+	return count
+}
+
+// commitBundles applies the pending bundles in submission order. Each bundle
+// is either included in its entirety, or reverted if any of its transactions
+// fails or reverts, or if they pay less than its minimum tip in total.
+// Bundles which fail for reasons other than the block running out of gas are
+// removed from the pool, and bundles included in the block are marked as
+// tried, unless the block is built as a dry run.
+func (w *worker) commitBundles(env *environment) {
+	for _, bundle := range w.bundles.pending(env.header.Number.Uint64(), w.clock.Time()) {
+		err := w.commitBundle(env, bundle)
+		if err != nil {
+			log.Debug("Skipping bundle", "hash", bundle.Hash(), "err", err)
+		} else {
+			log.Debug("Included bundle", "hash", bundle.Hash(), "txs", len(bundle.Txs))
+		}
+		if env.dryRun {
+			continue
+		}
+		switch {
+		case err == nil, errors.Is(err, errBundleGasLimit):
+			w.bundles.markTried(bundle)
+		default:
+			w.bundles.remove(bundle)
+		}
+	}
+}
+
+// commitBundle applies the transactions of [bundle], reverting all of them
+// if any of them fails.
+func (w *worker) commitBundle(env *environment, bundle *Bundle) error {
+	var gas uint64
+	for _, tx := range bundle.Txs {
+		gas += tx.Gas()
+	}
+	if env.gasPool.Gas() < gas {
+		return errBundleGasLimit
+	}
+	// State snapshots do not span transactions, so the bundle is reverted to a
+	// copy of the state taken before applying it.
+	var (
+		state    = env.state.Copy()
+		gp       = env.gasPool.Gas()
+		gasUsed  = env.header.GasUsed
+		txs      = len(env.txs)
+		size     = env.size
+		tcount   = env.tcount
+		totalTip = new(big.Int)
+	)
+	revert := func() {
+		for _, tx := range env.txs[txs:] {
+			env.predicateResults.DeleteTxResults(tx.Hash())
+		}
+		env.state.StopPrefetcher()
+		env.state = state
+		env.state.StartPrefetcher("miner", w.chain.CacheConfig().TriePrefetcherParallelism)
+		env.gasPool.SetGas(gp)
+		env.header.GasUsed = gasUsed
+		env.txs, env.receipts = env.txs[:txs], env.receipts[:txs]
+		env.size, env.tcount = size, tcount
+	}
+	for _, tx := range bundle.Txs {
+		if tx.Protected() && !w.chainConfig.IsEIP155(env.header.Number) {
+			revert()
+			return fmt.Errorf("%w: %s: replay protected before EIP-155", errBundleTxFailed, tx.Hash())
+		}
+		if totalTxsSize := env.size + tx.Size(); totalTxsSize > targetTxsSize {
+			revert()
+			return fmt.Errorf("%w: %s: exceeds target block size", errBundleTxFailed, tx.Hash())
+		}
+		env.state.SetTxContext(tx.Hash(), env.tcount)
+		if _, err := w.commitTransaction(env, tx, env.header.Coinbase); err != nil {
+			revert()
+			return fmt.Errorf("%w: %s: %w", errBundleTxFailed, tx.Hash(), err)
+		}
+		receipt := env.receipts[len(env.receipts)-1]
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			revert()
+			return fmt.Errorf("%w: %s: execution reverted", errBundleTxFailed, tx.Hash())
+		}
+		env.tcount++
+		tip, err := tx.EffectiveGasTip(env.header.BaseFee)
+		if err != nil {
+			revert()
+			return fmt.Errorf("%w: %s: %w", errBundleTxFailed, tx.Hash(), err)
+		}
+		totalTip.Add(totalTip, tip.Mul(tip, new(big.Int).SetUint64(receipt.GasUsed)))
+	}
+	if bundle.MinTip != nil && totalTip.Cmp(bundle.MinTip) < 0 {
+		revert()
+		return fmt.Errorf("%w: paid %d, want %d", errBundleTipTooLow, totalTip, bundle.MinTip)
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package miner
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/utils/timer/mockable"
+	"github.com/ava-labs/coreth/consensus/dummy"
+	"github.com/ava-labs/coreth/core"
+	"github.com/ava-labs/coreth/core/rawdb"
+	"github.com/ava-labs/coreth/core/txpool"
+	"github.com/ava-labs/coreth/core/txpool/legacypool"
+	"github.com/ava-labs/coreth/core/types"
+	"github.com/ava-labs/coreth/core/vm"
+	"github.com/ava-labs/coreth/params"
+	"github.com/ava-labs/coreth/precompile/precompileconfig"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/stretchr/testify/require"
+)
+
+type testBackend struct {
+	chain  *core.BlockChain
+	txPool *txpool.TxPool
+}
+
+func (b *testBackend) BlockChain() *core.BlockChain { return b.chain }
+func (b *testBackend) TxPool() *txpool.TxPool       { return b.txPool }
+
+func newTestWorker(t *testing.T, funded ...common.Address) *worker {
+	alloc := make(types.GenesisAlloc)
+	for _, addr := range funded {
+		alloc[addr] = types.GenesisAccount{Balance: big.NewInt(params.Ether)}
+	}
+	gspec := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc:  alloc,
+	}
+	engine := dummy.NewETHFaker()
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), core.DefaultCacheConfig, gspec, engine, vm.Config{}, common.Hash{}, false)
+	require.NoError(t, err)
+	t.Cleanup(chain.Stop)
+
+	pool := legacypool.New(legacypool.DefaultConfig, chain)
+	txPool, err := txpool.New(legacypool.DefaultConfig.PriceLimit, chain, []txpool.SubPool{pool})
+	require.NoError(t, err)
+	t.Cleanup(func() { txPool.Close() })
+
+	config := &Config{Etherbase: common.Address{1}}
+	return newWorker(config, params.TestChainConfig, engine, &testBackend{chain: chain, txPool: txPool}, new(event.TypeMux), &mockable.Clock{})
+}
+
+func newBundleTx(t *testing.T, key *ecdsa.PrivateKey, nonce uint64, tip int64) *types.Transaction {
+	tx, err := types.SignNewTx(key, types.LatestSigner(params.TestChainConfig), &types.DynamicFeeTx{
+		ChainID:   params.TestChainConfig.ChainID,
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(tip),
+		GasFeeCap: big.NewInt(1000 * params.GWei),
+		Gas:       params.TxGas,
+		To:        &common.Address{2},
+	})
+	require.NoError(t, err)
+	return tx
+}
+
+func TestBundleInclusion(t *testing.T) {
+	require := require.New(t)
+
+	key1, _ := crypto.GenerateKey()
+	key2, _ := crypto.GenerateKey()
+	w := newTestWorker(t, crypto.PubkeyToAddress(key1.PublicKey), crypto.PubkeyToAddress(key2.PublicKey))
+	w.eth.TxPool().SetGasTip(common.Big1)
+
+	// A pooled transaction paying a higher tip is included after the bundle.
+	pooled := newBundleTx(t, key2, 0, 100*params.GWei)
+	require.NoError(w.eth.TxPool().Add([]*types.Transaction{pooled}, false, true)[0])
+
+	bundle := &Bundle{
+		Txs:    []*types.Transaction{newBundleTx(t, key1, 0, params.GWei), newBundleTx(t, key1, 1, params.GWei)},
+		MinTip: new(big.Int).SetUint64(2 * params.TxGas * params.GWei),
+	}
+	hash, err := w.bundles.add(bundle, 0, w.clock.Time())
+	require.NoError(err)
+	require.Equal(bundle.Hash(), hash)
+	require.Equal(uint64(DefaultBundleExpiry), bundle.MaxBlockNumber)
+	require.Equal(1, w.bundles.untried(w.clock.Time()))
+
+	block, _, err := w.generateWork(&precompileconfig.PredicateContext{}, false)
+	require.NoError(err)
+	var included []common.Hash
+	for _, tx := range block.Transactions() {
+		included = append(included, tx.Hash())
+	}
+	require.Equal([]common.Hash{bundle.Txs[0].Hash(), bundle.Txs[1].Hash(), pooled.Hash()}, included)
+
+	// The included bundle no longer requires a block to be built, and is
+	// removed once the block is accepted.
+	require.Zero(w.bundles.untried(w.clock.Time()))
+	require.Equal(1, w.bundles.len())
+	w.bundles.removeIncluded(block)
+	require.Zero(w.bundles.len())
+}
+
+func TestBundleReverted(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	tests := []struct {
+		name   string
+		bundle *Bundle
+		err    error
+	}{
+		{
+			name:   "failing transaction",
+			bundle: &Bundle{Txs: []*types.Transaction{newBundleTx(t, key, 0, params.GWei), newBundleTx(t, key, 2, params.GWei)}},
+			err:    errBundleTxFailed,
+		},
+		{
+			name: "tip below minimum",
+			bundle: &Bundle{
+				Txs:    []*types.Transaction{newBundleTx(t, key, 0, params.GWei), newBundleTx(t, key, 1, params.GWei)},
+				MinTip: new(big.Int).SetUint64(2*params.TxGas*params.GWei + 1),
+			},
+			err: errBundleTipTooLow,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+
+			w := newTestWorker(t, crypto.PubkeyToAddress(key.PublicKey))
+			_, err := w.bundles.add(test.bundle, 0, w.clock.Time())
+			require.NoError(err)
+
+			// Dry runs leave the pool unchanged.
+			block, env, err := w.generateWork(&precompileconfig.PredicateContext{}, true)
+			require.NoError(err)
+			require.Empty(block.Transactions())
+			require.Zero(env.header.GasUsed)
+			require.Empty(env.skipped)
+			require.Equal(1, w.bundles.untried(w.clock.Time()))
+
+			// The failed bundle is removed from the pool.
+			block, _, err = w.generateWork(&precompileconfig.PredicateContext{}, false)
+			require.NoError(err)
+			require.Empty(block.Transactions())
+			require.Zero(w.bundles.len())
+		})
+	}
+}
+
+func TestAddBundle(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	tx := newBundleTx(t, key, 0, params.GWei)
+	tooLarge := make([]*types.Transaction, MaxBundleTxs+1)
+	for i := range tooLarge {
+		tooLarge[i] = tx
+	}
+	tests := []struct {
+		name   string
+		bundle *Bundle
+		err    error
+	}{
+		{name: "empty", bundle: &Bundle{}, err: errEmptyBundle},
+		{name: "too large", bundle: &Bundle{Txs: tooLarge}, err: errBundleTooLarge},
+		{name: "expired", bundle: &Bundle{Txs: []*types.Transaction{tx}, MaxBlockNumber: 10}, err: errBundleExpired},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var pool bundlePool
+			_, err := pool.add(test.bundle, 10, time.Now())
+			require.ErrorIs(t, err, test.err)
+		})
+	}
+
+	var pool bundlePool
+	_, err := pool.add(&Bundle{Txs: []*types.Transaction{tx}}, 10, time.Now())
+	require.NoError(t, err)
+	_, err = pool.add(&Bundle{Txs: []*types.Transaction{tx}}, 10, time.Now())
+	require.ErrorIs(t, err, errBundleKnown)
+}
+
+func TestBundleExpiry(t *testing.T) {
+	require := require.New(t)
+	key, _ := crypto.GenerateKey()
+	now := time.Now()
+
+	var pool bundlePool
+	_, err := pool.add(&Bundle{Txs: []*types.Transaction{newBundleTx(t, key, 0, params.GWei)}}, 10, now)
+	require.NoError(err)
+	_, err = pool.add(&Bundle{Txs: []*types.Transaction{newBundleTx(t, key, 1, params.GWei)}, MaxBlockNumber: 12}, 10, now.Add(time.Second))
+	require.NoError(err)
+
+	// Bundles expire after their maximum block number.
+	require.Len(pool.pending(12, now), 2)
+	require.Len(pool.pending(13, now), 1)
+
+	// Bundles expire after [maxBundleAge] regardless of their maximum block
+	// number.
+	require.Equal(1, pool.untried(now.Add(maxBundleAge)))
+	require.Zero(pool.untried(now.Add(maxBundleAge + time.Second)))
+	require.Empty(pool.pending(11, now.Add(maxBundleAge+time.Second)))
+}
+
+func TestBundleEventSentWithoutLock(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+
+	var pool bundlePool
+	events := make(chan NewBundleEvent)
+	sub := pool.feed.Subscribe(events)
+	defer sub.Unsubscribe()
+
+	added := make(chan error)
+	go func() {
+		_, err := pool.add(&Bundle{Txs: []*types.Transaction{newBundleTx(t, key, 0, params.GWei)}}, 10, time.Now())
+		added <- err
+	}()
+	// The pool is accessible while the event awaits delivery.
+	require.Eventually(t, func() bool { return pool.untried(time.Now()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	<-events
+	require.NoError(t, <-added)
+}
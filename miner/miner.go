@@ -49,6 +49,9 @@ type Backend interface {
 type Config struct {
 	Etherbase                    common.Address `toml:",omitempty"` // Public address for block mining rewards
 	TestOnlyAllowDuplicateBlocks bool           // Allow mining of duplicate blocks (used in tests only)
+
+	Ordering          string           // Name of the transaction ordering policy, see NewOrderingPolicy
+	PriorityAddresses []common.Address // Addresses whose transactions are included first by the priority ordering policy
 }
 
 type Miner struct {
@@ -69,6 +72,66 @@ func (miner *Miner) GenerateBlock(predicateContext *precompileconfig.PredicateCo
 	return miner.worker.commitNewWork(predicateContext)
 }
 
+// SkippedTx is a pending transaction left out of a block being built.
+type SkippedTx struct {
+	Hash   common.Hash
+	Reason string
+}
+
+// PendingBlock is the outcome of building a block without issuing it.
+type PendingBlock struct {
+	Header   *types.Header        // Header of the block, including fields set while assembling it if it succeeded
+	Txs      []*types.Transaction // Transactions included in the block
+	Receipts []*types.Receipt     // Receipts of the included transactions
+	Skipped  []SkippedTx          // Pending transactions left out of the block
+
+	Block       *types.Block // Assembled block, nil if assembling it failed
+	AssembleErr error        // Error assembling the block, such as it being empty
+}
+
+// BuildPendingBlock builds the block that would currently be generated on top
+// of the current head, without issuing it. Transactions left out of the block
+// are reported along with the reason they were skipped.
+func (miner *Miner) BuildPendingBlock(predicateContext *precompileconfig.PredicateContext) (*PendingBlock, error) {
+	block, env, err := miner.worker.generateWork(predicateContext, true)
+	if env == nil {
+		return nil, err
+	}
+	return &PendingBlock{
+		Header:      env.header,
+		Txs:         env.txs,
+		Receipts:    env.receipts,
+		Skipped:     env.skipped,
+		Block:       block,
+		AssembleErr: err,
+	}, nil
+}
+
+// SendBundle submits [bundle] for inclusion in a block and returns its hash.
+// If the bundle does not specify a maximum block number, it expires
+// DefaultBundleExpiry blocks after the current head.
+func (miner *Miner) SendBundle(bundle *Bundle) (common.Hash, error) {
+	return miner.worker.bundles.add(bundle, miner.worker.chain.CurrentBlock().Number.Uint64(), miner.worker.clock.Time())
+}
+
+// PendingBundles returns the number of bundles awaiting inclusion which were
+// not yet included in a built block.
+func (miner *Miner) PendingBundles() int {
+	return miner.worker.bundles.untried(miner.worker.clock.Time())
+}
+
+// RemoveIncludedBundles removes the bundles with transactions included in the
+// accepted [block].
+func (miner *Miner) RemoveIncludedBundles(block *types.Block) {
+	miner.worker.bundles.removeIncluded(block)
+}
+
+// SubscribeNewBundles registers a subscription for bundles submitted for
+// inclusion.
+func (miner *Miner) SubscribeNewBundles(ch chan<- NewBundleEvent) event.Subscription {
+	return miner.worker.bundles.feed.Subscribe(ch)
+}
+
 // SubscribePendingLogs starts delivering logs from pending transactions
 // to the given channel.
 func (miner *Miner) SubscribePendingLogs(ch chan<- []*types.Log) event.Subscription {
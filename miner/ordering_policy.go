@@ -0,0 +1,277 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package miner
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ava-labs/coreth/core/txpool"
+	"github.com/ava-labs/coreth/core/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+)
+
+// Names of the ordering policies selectable with [NewOrderingPolicy].
+const (
+	OrderingPriceAndNonce = "price"    // Highest effective tip first
+	OrderingArrival       = "fcfs"     // First come first served by the time transactions were first seen
+	OrderingPriority      = "priority" // Transactions of priority addresses first, then by price
+)
+
+var errNoPriorityAddresses = errors.New("priority ordering requires at least one priority address")
+
+// TransactionSet yields pending transactions to the block builder in the
+// order determined by an [OrderingPolicy], while honouring account nonces.
+type TransactionSet interface {
+	// Peek returns the next transaction to include along with its effective
+	// miner tip, or nil if the set is empty.
+	Peek() (*txpool.LazyTransaction, *uint256.Int)
+	// Shift replaces the next transaction with the following one from the
+	// same account.
+	Shift()
+	// Pop removes the next transaction, *not* replacing it with the following
+	// one from the same account.
+	Pop()
+	// Empty returns whether there are no transactions left.
+	Empty() bool
+	// Clear removes all transactions.
+	Clear()
+}
+
+// OrderingPolicy determines the order in which the block builder includes
+// pending transactions.
+type OrderingPolicy interface {
+	// NewTransactionSet returns a set ordering the per account nonce-sorted
+	// [txs]. The input map is reowned by the returned set.
+	NewTransactionSet(signer types.Signer, txs map[common.Address][]*txpool.LazyTransaction, baseFee *big.Int) TransactionSet
+}
+
+// OrderingPolicyFunc is an adapter to allow the use of ordinary functions as
+// ordering policies.
+type OrderingPolicyFunc func(signer types.Signer, txs map[common.Address][]*txpool.LazyTransaction, baseFee *big.Int) TransactionSet
+
+// NewTransactionSet calls f(signer, txs, baseFee).
+func (f OrderingPolicyFunc) NewTransactionSet(signer types.Signer, txs map[common.Address][]*txpool.LazyTransaction, baseFee *big.Int) TransactionSet {
+	return f(signer, txs, baseFee)
+}
+
+var (
+	// PriceAndNonceOrdering includes transactions in a profit-maximizing order.
+	// This is the default policy.
+	PriceAndNonceOrdering OrderingPolicy = OrderingPolicyFunc(func(signer types.Signer, txs map[common.Address][]*txpool.LazyTransaction, baseFee *big.Int) TransactionSet {
+		return newTransactionsByPriceAndNonce(signer, txs, baseFee)
+	})
+
+	// ArrivalOrdering includes transactions in the order they were first seen,
+	// regardless of the tip they pay.
+	ArrivalOrdering OrderingPolicy = OrderingPolicyFunc(func(signer types.Signer, txs map[common.Address][]*txpool.LazyTransaction, baseFee *big.Int) TransactionSet {
+		return newTransactionsByArrivalAndNonce(txs, baseFee)
+	})
+)
+
+// NewOrderingPolicy returns the ordering policy with the given [name]. The
+// [priorityAddresses] are only used by the priority policy, which requires
+// at least one.
+func NewOrderingPolicy(name string, priorityAddresses []common.Address) (OrderingPolicy, error) {
+	switch name {
+	case "", OrderingPriceAndNonce:
+		return PriceAndNonceOrdering, nil
+	case OrderingArrival:
+		return ArrivalOrdering, nil
+	case OrderingPriority:
+		if len(priorityAddresses) == 0 {
+			return nil, errNoPriorityAddresses
+		}
+		return NewPriorityOrdering(priorityAddresses, PriceAndNonceOrdering), nil
+	default:
+		return nil, fmt.Errorf("unknown ordering policy %q", name)
+	}
+}
+
+// priorityOrdering is an ordering policy with a priority lane for a set of
+// addresses.
+type priorityOrdering struct {
+	addresses map[common.Address]struct{}
+	lane      OrderingPolicy
+}
+
+// NewPriorityOrdering returns a policy including all transactions sent by the
+// priority [addresses] before any other transaction. Transactions within each
+// lane are ordered by [lane].
+func NewPriorityOrdering(addresses []common.Address, lane OrderingPolicy) OrderingPolicy {
+	p := &priorityOrdering{
+		addresses: make(map[common.Address]struct{}, len(addresses)),
+		lane:      lane,
+	}
+	for _, addr := range addresses {
+		p.addresses[addr] = struct{}{}
+	}
+	return p
+}
+
+func (p *priorityOrdering) NewTransactionSet(signer types.Signer, txs map[common.Address][]*txpool.LazyTransaction, baseFee *big.Int) TransactionSet {
+	priority := make(map[common.Address][]*txpool.LazyTransaction)
+	for addr := range p.addresses {
+		if accTxs, ok := txs[addr]; ok {
+			priority[addr] = accTxs
+			delete(txs, addr)
+		}
+	}
+	return &lanes{
+		lanes: []TransactionSet{
+			p.lane.NewTransactionSet(signer, priority, baseFee),
+			p.lane.NewTransactionSet(signer, txs, baseFee),
+		},
+	}
+}
+
+// lanes is a transaction set exhausting each of its lanes before moving on to
+// the next one.
+type lanes struct {
+	lanes []TransactionSet
+}
+
+// current returns the first non-empty lane, or nil if all lanes are empty.
+func (l *lanes) current() TransactionSet {
+	for _, lane := range l.lanes {
+		if !lane.Empty() {
+			return lane
+		}
+	}
+	return nil
+}
+
+func (l *lanes) Peek() (*txpool.LazyTransaction, *uint256.Int) {
+	if lane := l.current(); lane != nil {
+		return lane.Peek()
+	}
+	return nil, nil
+}
+
+func (l *lanes) Shift() {
+	if lane := l.current(); lane != nil {
+		lane.Shift()
+	}
+}
+
+func (l *lanes) Pop() {
+	if lane := l.current(); lane != nil {
+		lane.Pop()
+	}
+}
+
+func (l *lanes) Empty() bool {
+	return l.current() == nil
+}
+
+func (l *lanes) Clear() {
+	for _, lane := range l.lanes {
+		lane.Clear()
+	}
+}
+
+// txByTime implements the heap interface, ordering transactions by the time
+// they were first seen.
+type txByTime []*txWithMinerFee
+
+func (s txByTime) Len() int { return len(s) }
+func (s txByTime) Less(i, j int) bool {
+	// If the times are equal, order by hash for deterministic sorting
+	if s[i].tx.Time.Equal(s[j].tx.Time) {
+		return s[i].tx.Hash.Cmp(s[j].tx.Hash) < 0
+	}
+	return s[i].tx.Time.Before(s[j].tx.Time)
+}
+func (s txByTime) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+func (s *txByTime) Push(x interface{}) {
+	*s = append(*s, x.(*txWithMinerFee))
+}
+
+func (s *txByTime) Pop() interface{} {
+	old := *s
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	*s = old[0 : n-1]
+	return x
+}
+
+// transactionsByArrivalAndNonce represents a set of transactions that can
+// return transactions in the order they were first seen, while supporting
+// removing entire batches of transactions for non-executable accounts.
+type transactionsByArrivalAndNonce struct {
+	txs     map[common.Address][]*txpool.LazyTransaction // Per account nonce-sorted list of transactions
+	heads   txByTime                                     // Next transaction for each unique account (time heap)
+	baseFee *uint256.Int                                 // Current base fee
+}
+
+// newTransactionsByArrivalAndNonce creates a transaction set that can retrieve
+// arrival time sorted transactions in a nonce-honouring way.
+//
+// Note, the input map is reowned so the caller should not interact any more with
+// it after providing it to the constructor.
+func newTransactionsByArrivalAndNonce(txs map[common.Address][]*txpool.LazyTransaction, baseFee *big.Int) *transactionsByArrivalAndNonce {
+	var baseFeeUint *uint256.Int
+	if baseFee != nil {
+		baseFeeUint = uint256.MustFromBig(baseFee)
+	}
+	heads := make(txByTime, 0, len(txs))
+	for from, accTxs := range txs {
+		wrapped, err := newTxWithMinerFee(accTxs[0], from, baseFeeUint)
+		if err != nil {
+			delete(txs, from)
+			continue
+		}
+		heads = append(heads, wrapped)
+		txs[from] = accTxs[1:]
+	}
+	heap.Init(&heads)
+
+	return &transactionsByArrivalAndNonce{
+		txs:     txs,
+		heads:   heads,
+		baseFee: baseFeeUint,
+	}
+}
+
+// Peek returns the earliest seen transaction.
+func (t *transactionsByArrivalAndNonce) Peek() (*txpool.LazyTransaction, *uint256.Int) {
+	if len(t.heads) == 0 {
+		return nil, nil
+	}
+	return t.heads[0].tx, t.heads[0].fees
+}
+
+// Shift replaces the current head with the next one from the same account.
+func (t *transactionsByArrivalAndNonce) Shift() {
+	acc := t.heads[0].from
+	if txs, ok := t.txs[acc]; ok && len(txs) > 0 {
+		if wrapped, err := newTxWithMinerFee(txs[0], acc, t.baseFee); err == nil {
+			t.heads[0], t.txs[acc] = wrapped, txs[1:]
+			heap.Fix(&t.heads, 0)
+			return
+		}
+	}
+	heap.Pop(&t.heads)
+}
+
+// Pop removes the current head, *not* replacing it with the next one from the
+// same account.
+func (t *transactionsByArrivalAndNonce) Pop() {
+	heap.Pop(&t.heads)
+}
+
+// Empty returns if the time heap is empty.
+func (t *transactionsByArrivalAndNonce) Empty() bool {
+	return len(t.heads) == 0
+}
+
+// Clear removes the entire content of the heap.
+func (t *transactionsByArrivalAndNonce) Clear() {
+	t.heads, t.txs = nil, nil
+}
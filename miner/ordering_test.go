@@ -30,6 +30,7 @@ import (
 	"crypto/ecdsa"
 	"math/big"
 	"math/rand"
+	"slices"
 	"testing"
 	"time"
 
@@ -204,3 +205,110 @@ func TestTransactionTimeSort(t *testing.T) {
 		}
 	}
 }
+
+func newLazyTransaction(t *testing.T, key *ecdsa.PrivateKey, nonce uint64, gasPrice int64, seen int64) *txpool.LazyTransaction {
+	tx, err := types.SignTx(types.NewTransaction(nonce, common.Address{}, big.NewInt(100), 100, big.NewInt(gasPrice), nil), types.HomesteadSigner{}, key)
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	tx.SetTime(time.Unix(seen, 0))
+	return &txpool.LazyTransaction{
+		Hash:      tx.Hash(),
+		Tx:        tx,
+		Time:      tx.Time(),
+		GasFeeCap: uint256.MustFromBig(tx.GasFeeCap()),
+		GasTipCap: uint256.MustFromBig(tx.GasTipCap()),
+		Gas:       tx.Gas(),
+		BlobGas:   tx.BlobGas(),
+	}
+}
+
+func drainTransactionSet(txset TransactionSet) []common.Hash {
+	var hashes []common.Hash
+	for tx, _ := txset.Peek(); tx != nil; tx, _ = txset.Peek() {
+		hashes = append(hashes, tx.Hash)
+		txset.Shift()
+	}
+	return hashes
+}
+
+// Tests that the arrival ordering includes transactions in the order they were
+// first seen regardless of their price, while honouring nonces.
+func TestArrivalOrdering(t *testing.T) {
+	t.Parallel()
+	key1, _ := crypto.GenerateKey()
+	key2, _ := crypto.GenerateKey()
+
+	var (
+		a0 = newLazyTransaction(t, key1, 0, 1, 1)
+		a1 = newLazyTransaction(t, key1, 1, 1, 4)
+		b0 = newLazyTransaction(t, key2, 0, 100, 2)
+		b1 = newLazyTransaction(t, key2, 1, 100, 3)
+	)
+	groups := map[common.Address][]*txpool.LazyTransaction{
+		crypto.PubkeyToAddress(key1.PublicKey): {a0, a1},
+		crypto.PubkeyToAddress(key2.PublicKey): {b0, b1},
+	}
+	txset := ArrivalOrdering.NewTransactionSet(types.HomesteadSigner{}, groups, nil)
+	want := []common.Hash{a0.Hash, b0.Hash, b1.Hash, a1.Hash}
+	if got := drainTransactionSet(txset); !slices.Equal(got, want) {
+		t.Errorf("unexpected ordering: have %v, want %v", got, want)
+	}
+}
+
+// Tests that the priority ordering includes all transactions of the priority
+// addresses first, ordering each lane by price.
+func TestPriorityOrdering(t *testing.T) {
+	t.Parallel()
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	var (
+		priority0 = newLazyTransaction(t, keys[0], 0, 1, 1)
+		priority1 = newLazyTransaction(t, keys[0], 1, 1, 1)
+		cheap     = newLazyTransaction(t, keys[1], 0, 10, 1)
+		expensive = newLazyTransaction(t, keys[2], 0, 100, 1)
+	)
+	groups := map[common.Address][]*txpool.LazyTransaction{
+		crypto.PubkeyToAddress(keys[0].PublicKey): {priority0, priority1},
+		crypto.PubkeyToAddress(keys[1].PublicKey): {cheap},
+		crypto.PubkeyToAddress(keys[2].PublicKey): {expensive},
+	}
+	policy, err := NewOrderingPolicy(OrderingPriority, []common.Address{crypto.PubkeyToAddress(keys[0].PublicKey)})
+	if err != nil {
+		t.Fatalf("failed to create ordering policy: %v", err)
+	}
+	txset := policy.NewTransactionSet(types.HomesteadSigner{}, groups, nil)
+	want := []common.Hash{priority0.Hash, priority1.Hash, expensive.Hash, cheap.Hash}
+	if got := drainTransactionSet(txset); !slices.Equal(got, want) {
+		t.Errorf("unexpected ordering: have %v, want %v", got, want)
+	}
+
+	// Popping a priority transaction skips the rest of the account.
+	groups = map[common.Address][]*txpool.LazyTransaction{
+		crypto.PubkeyToAddress(keys[0].PublicKey): {priority0, priority1},
+		crypto.PubkeyToAddress(keys[1].PublicKey): {cheap},
+	}
+	txset = policy.NewTransactionSet(types.HomesteadSigner{}, groups, nil)
+	txset.Pop()
+	want = []common.Hash{cheap.Hash}
+	if got := drainTransactionSet(txset); !slices.Equal(got, want) {
+		t.Errorf("unexpected ordering after pop: have %v, want %v", got, want)
+	}
+}
+
+func TestNewOrderingPolicy(t *testing.T) {
+	t.Parallel()
+	for _, name := range []string{"", OrderingPriceAndNonce, OrderingArrival} {
+		if _, err := NewOrderingPolicy(name, nil); err != nil {
+			t.Errorf("ordering policy %q: unexpected error: %v", name, err)
+		}
+	}
+	if _, err := NewOrderingPolicy(OrderingPriority, nil); err != errNoPriorityAddresses {
+		t.Errorf("priority ordering without addresses: have %v, want %v", err, errNoPriorityAddresses)
+	}
+	if _, err := NewOrderingPolicy("unknown", nil); err == nil {
+		t.Error("expected error for unknown ordering policy")
+	}
+}
@@ -85,6 +85,17 @@ type environment struct {
 	predicateResults *predicate.Results
 
 	start time.Time // Time that block building began
+
+	dryRun  bool        // Whether the block is built without being issued
+	skipped []SkippedTx // Transactions left out of the block, only recorded on dry runs
+}
+
+// skip records that the transaction [hash] was left out of the block for
+// [reason] when dry running.
+func (env *environment) skip(hash common.Hash, reason string) {
+	if env.dryRun {
+		env.skipped = append(env.skipped, SkippedTx{Hash: hash, Reason: reason})
+	}
 }
 
 // worker is the main object which takes care of submitting new work to consensus engine
@@ -95,6 +106,8 @@ type worker struct {
 	engine      consensus.Engine
 	eth         Backend
 	chain       *core.BlockChain
+	ordering    OrderingPolicy
+	bundles     bundlePool
 
 	// Feeds
 	// TODO remove since this will never be written to
@@ -109,12 +122,18 @@ type worker struct {
 }
 
 func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus.Engine, eth Backend, mux *event.TypeMux, clock *mockable.Clock) *worker {
+	ordering, err := NewOrderingPolicy(config.Ordering, config.PriorityAddresses)
+	if err != nil {
+		log.Error("Invalid transaction ordering policy, ordering by price", "ordering", config.Ordering, "err", err)
+		ordering = PriceAndNonceOrdering
+	}
 	worker := &worker{
 		config:      config,
 		chainConfig: chainConfig,
 		engine:      engine,
 		eth:         eth,
 		chain:       eth.BlockChain(),
+		ordering:    ordering,
 		mux:         mux,
 		coinbase:    config.Etherbase,
 		clock:       clock,
@@ -133,6 +152,16 @@ func (w *worker) setEtherbase(addr common.Address) {
 
 // commitNewWork generates several new sealing tasks based on the parent block.
 func (w *worker) commitNewWork(predicateContext *precompileconfig.PredicateContext) (*types.Block, error) {
+	block, _, err := w.generateWork(predicateContext, false)
+	return block, err
+}
+
+// generateWork builds a block on top of the current head. If [dryRun] is set,
+// the transactions left out of the block are recorded in the returned
+// environment and the block is not handed off as new mining work. The
+// environment is returned as long as transactions were applied, even if the
+// block could not be assembled.
+func (w *worker) generateWork(predicateContext *precompileconfig.PredicateContext, dryRun bool) (*types.Block, *environment, error) {
 	w.mu.RLock()
 	defer w.mu.RUnlock()
 
@@ -169,7 +198,7 @@ func (w *worker) commitNewWork(predicateContext *precompileconfig.PredicateConte
 		var err error
 		header.Extra, header.BaseFee, err = dummy.CalcBaseFee(w.chainConfig, parent, timestamp)
 		if err != nil {
-			return nil, fmt.Errorf("failed to calculate new base fee: %w", err)
+			return nil, nil, fmt.Errorf("failed to calculate new base fee: %w", err)
 		}
 	}
 	// Apply EIP-4844, EIP-4788.
@@ -187,17 +216,18 @@ func (w *worker) commitNewWork(predicateContext *precompileconfig.PredicateConte
 	}
 
 	if w.coinbase == (common.Address{}) {
-		return nil, errors.New("cannot mine without etherbase")
+		return nil, nil, errors.New("cannot mine without etherbase")
 	}
 	header.Coinbase = w.coinbase
 	if err := w.engine.Prepare(w.chain, header); err != nil {
-		return nil, fmt.Errorf("failed to prepare header for mining: %w", err)
+		return nil, nil, fmt.Errorf("failed to prepare header for mining: %w", err)
 	}
 
 	env, err := w.createCurrentEnvironment(predicateContext, parent, header, tstart)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create new current environment: %w", err)
+		return nil, nil, fmt.Errorf("failed to create new current environment: %w", err)
 	}
+	env.dryRun = dryRun
 	if header.ParentBeaconRoot != nil {
 		context := core.NewEVMBlockContext(header, w.chain, nil)
 		vmenv := vm.NewEVM(context, vm.TxContext{}, env.state, w.chainConfig, vm.Config{})
@@ -214,7 +244,7 @@ func (w *worker) commitNewWork(predicateContext *precompileconfig.PredicateConte
 	err = core.ApplyUpgrades(w.chainConfig, &parent.Time, types.NewBlockWithHeader(header), env.state)
 	if err != nil {
 		log.Error("failed to configure precompiles mining new block", "parent", parent.Hash(), "number", header.Number, "timestamp", header.Time, "err", err)
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Retrieve the pending transactions pre-filtered by the 1559/4844 dynamic fees
@@ -246,21 +276,24 @@ func (w *worker) commitNewWork(predicateContext *precompileconfig.PredicateConte
 			localBlobTxs[account] = txs
 		}
 	}
-	// Fill the block with all available pending transactions.
+	// Fill the block with the pending bundles first, then with all available
+	// pending transactions.
+	w.commitBundles(env)
 	if len(localPlainTxs) > 0 || len(localBlobTxs) > 0 {
-		plainTxs := newTransactionsByPriceAndNonce(env.signer, localPlainTxs, env.header.BaseFee)
-		blobTxs := newTransactionsByPriceAndNonce(env.signer, localBlobTxs, env.header.BaseFee)
+		plainTxs := w.ordering.NewTransactionSet(env.signer, localPlainTxs, env.header.BaseFee)
+		blobTxs := w.ordering.NewTransactionSet(env.signer, localBlobTxs, env.header.BaseFee)
 
 		w.commitTransactions(env, plainTxs, blobTxs, env.header.Coinbase)
 	}
 	if len(remotePlainTxs) > 0 || len(remoteBlobTxs) > 0 {
-		plainTxs := newTransactionsByPriceAndNonce(env.signer, remotePlainTxs, env.header.BaseFee)
-		blobTxs := newTransactionsByPriceAndNonce(env.signer, remoteBlobTxs, env.header.BaseFee)
+		plainTxs := w.ordering.NewTransactionSet(env.signer, remotePlainTxs, env.header.BaseFee)
+		blobTxs := w.ordering.NewTransactionSet(env.signer, remoteBlobTxs, env.header.BaseFee)
 
 		w.commitTransactions(env, plainTxs, blobTxs, env.header.Coinbase)
 	}
 
-	return w.commit(env)
+	block, err := w.commit(env)
+	return block, env, err
 }
 
 func (w *worker) createCurrentEnvironment(predicateContext *precompileconfig.PredicateContext, parent *types.Header, header *types.Header, tstart time.Time) (*environment, error) {
@@ -351,7 +384,7 @@ func (w *worker) applyTransaction(env *environment, tx *types.Transaction, coinb
 	return receipt, err
 }
 
-func (w *worker) commitTransactions(env *environment, plainTxs, blobTxs *transactionsByPriceAndNonce, coinbase common.Address) {
+func (w *worker) commitTransactions(env *environment, plainTxs, blobTxs TransactionSet, coinbase common.Address) {
 	for {
 		// If we don't have enough gas for any further transactions then we're done.
 		if env.gasPool.Gas() < params.TxGas {
@@ -375,7 +408,7 @@ func (w *worker) commitTransactions(env *environment, plainTxs, blobTxs *transac
 		// Retrieve the next transaction and abort if all done.
 		var (
 			ltx *txpool.LazyTransaction
-			txs *transactionsByPriceAndNonce
+			txs TransactionSet
 		)
 		pltx, ptip := plainTxs.Peek()
 		bltx, btip := blobTxs.Peek()
@@ -398,11 +431,13 @@ func (w *worker) commitTransactions(env *environment, plainTxs, blobTxs *transac
 		// If we don't have enough space for the next transaction, skip the account.
 		if env.gasPool.Gas() < ltx.Gas {
 			log.Trace("Not enough gas left for transaction", "hash", ltx.Hash, "left", env.gasPool.Gas(), "needed", ltx.Gas)
+			env.skip(ltx.Hash, "not enough gas left in block")
 			txs.Pop()
 			continue
 		}
 		if left := uint64(params.MaxBlobGasPerBlock - env.blobs*params.BlobTxBlobGasPerBlob); left < ltx.BlobGas {
 			log.Trace("Not enough blob gas left for transaction", "hash", ltx.Hash, "left", left, "needed", ltx.BlobGas)
+			env.skip(ltx.Hash, "not enough blob gas left in block")
 			txs.Pop()
 			continue
 		}
@@ -410,6 +445,7 @@ func (w *worker) commitTransactions(env *environment, plainTxs, blobTxs *transac
 		tx := ltx.Resolve()
 		if tx == nil {
 			log.Trace("Ignoring evicted transaction", "hash", ltx.Hash)
+			env.skip(ltx.Hash, "evicted from transaction pool")
 			txs.Pop()
 			continue
 		}
@@ -417,6 +453,7 @@ func (w *worker) commitTransactions(env *environment, plainTxs, blobTxs *transac
 		// transction that will fit.
 		if totalTxsSize := env.size + tx.Size(); totalTxsSize > targetTxsSize {
 			log.Trace("Skipping transaction that would exceed target size", "hash", tx.Hash(), "totalTxsSize", totalTxsSize, "txSize", tx.Size())
+			env.skip(ltx.Hash, "exceeds target block size")
 			txs.Pop()
 			continue
 		}
@@ -429,6 +466,7 @@ func (w *worker) commitTransactions(env *environment, plainTxs, blobTxs *transac
 		// phase, start ignoring the sender until we do.
 		if tx.Protected() && !w.chainConfig.IsEIP155(env.header.Number) {
 			log.Trace("Ignoring replay protected transaction", "hash", ltx.Hash, "eip155", w.chainConfig.EIP155Block)
+			env.skip(ltx.Hash, "replay protected before EIP-155")
 			txs.Pop()
 			continue
 		}
@@ -441,6 +479,7 @@ func (w *worker) commitTransactions(env *environment, plainTxs, blobTxs *transac
 		case errors.Is(err, core.ErrNonceTooLow):
 			// New head notification data race between the transaction pool and miner, shift
 			log.Trace("Skipping transaction with low nonce", "hash", ltx.Hash, "sender", from, "nonce", tx.Nonce())
+			env.skip(ltx.Hash, err.Error())
 			txs.Shift()
 
 		case errors.Is(err, nil):
@@ -451,6 +490,7 @@ func (w *worker) commitTransactions(env *environment, plainTxs, blobTxs *transac
 			// Transaction is regarded as invalid, drop all consecutive transactions from
 			// the same sender because of `nonce-too-high` clause.
 			log.Debug("Transaction failed, account skipped", "hash", ltx.Hash, "err", err)
+			env.skip(ltx.Hash, err.Error())
 			txs.Pop()
 		}
 	}
@@ -472,6 +512,9 @@ func (w *worker) commit(env *environment) (*types.Block, error) {
 	if err != nil {
 		return nil, err
 	}
+	if env.dryRun {
+		return block, nil
+	}
 
 	return w.handleResult(env, block, time.Now(), receipts)
 }
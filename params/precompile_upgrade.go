@@ -232,3 +232,50 @@ func (c *ChainConfig) EnabledStatefulPrecompiles(blockTimestamp uint64) Precompi
 
 	return statefulPrecompileConfigs
 }
+
+// PrecompileActivation describes a single scheduled change to the state of a
+// stateful precompile.
+type PrecompileActivation struct {
+	Key       string         `json:"key"`
+	Address   common.Address `json:"address"`
+	Timestamp uint64         `json:"timestamp"`
+	Disabled  bool           `json:"disabled"`
+}
+
+// PrecompileSchedule returns the activations and deactivations configured in
+// [c.PrecompileUpgrades] in the order they take effect.
+// Assumes [c.PrecompileUpgrades] has been verified.
+func (c *ChainConfig) PrecompileSchedule() []PrecompileActivation {
+	schedule := make([]PrecompileActivation, 0, len(c.PrecompileUpgrades))
+	for _, upgrade := range c.PrecompileUpgrades {
+		module, ok := modules.GetPrecompileModule(upgrade.Key())
+		if !ok {
+			continue
+		}
+		schedule = append(schedule, PrecompileActivation{
+			Key:       module.ConfigKey,
+			Address:   module.Address,
+			Timestamp: *upgrade.Timestamp(),
+			Disabled:  upgrade.IsDisabled(),
+		})
+	}
+	return schedule
+}
+
+// WithPrecompileUpgrades returns a copy of [c] with its precompile upgrades
+// replaced by [precompileUpgrades], keeping the rest of its upgrade config.
+// Returns an error if [precompileUpgrades] is not well formed or if it is not
+// compatible with [c] at [time], which is assumed to be the last accepted block
+// timestamp. This performs the same checks a node would perform when restarted
+// with [precompileUpgrades] configured.
+func (c *ChainConfig) WithPrecompileUpgrades(precompileUpgrades []PrecompileUpgrade, time uint64) (*ChainConfig, error) {
+	newCfg := *c
+	newCfg.UpgradeConfig.PrecompileUpgrades = precompileUpgrades
+	if err := newCfg.Verify(); err != nil {
+		return nil, err
+	}
+	if err := c.CheckPrecompilesCompatible(precompileUpgrades, time); err != nil {
+		return nil, err
+	}
+	return &newCfg, nil
+}
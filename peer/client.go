@@ -26,15 +26,28 @@ type NetworkClient interface {
 	// the request should be retried.
 	SendAppRequestAny(ctx context.Context, minVersion *version.Application, request []byte) ([]byte, ids.NodeID, error)
 
+	// SendAppRequestAnyWith is like SendAppRequestAny, except that the request
+	// sent is built by [buildRequest] for the chosen peer.
+	SendAppRequestAnyWith(ctx context.Context, minVersion *version.Application, buildRequest RequestBuilder) ([]byte, ids.NodeID, error)
+
 	// SendAppRequest synchronously sends request to the selected nodeID
 	// Returns response bytes, and ErrRequestFailed if the request should be retried.
 	SendAppRequest(ctx context.Context, nodeID ids.NodeID, request []byte) ([]byte, error)
 
 	// TrackBandwidth should be called for each valid request with the bandwidth
-	// (length of response divided by request time), and with 0 if the response is invalid.
-	TrackBandwidth(nodeID ids.NodeID, bandwidth float64)
+	// (length of response divided by request time) and the length of the
+	// response, and with 0 if the request failed.
+	TrackBandwidth(nodeID ids.NodeID, bandwidth float64, responseSize int)
+
+	// TrackInvalidResponse should be called when a response from [nodeID]
+	// fails verification.
+	TrackInvalidResponse(nodeID ids.NodeID)
 }
 
+// RequestBuilder returns the request to send to [nodeID], which runs
+// [nodeVersion].
+type RequestBuilder func(nodeID ids.NodeID, nodeVersion *version.Application) ([]byte, error)
+
 // client implements NetworkClient interface
 // provides ability to send request / responses through the Network and wait for a response
 // so that the caller gets the result synchronously.
@@ -63,6 +76,21 @@ func (c *client) SendAppRequestAny(ctx context.Context, minVersion *version.Appl
 	return response, nodeID, err
 }
 
+// SendAppRequestAnyWith synchronously sends the request built by
+// [buildRequest] for an arbitrary peer with a node version greater than or
+// equal to minVersion.
+// Returns response bytes, the ID of the chosen peer, and ErrRequestFailed if
+// the request should be retried.
+func (c *client) SendAppRequestAnyWith(ctx context.Context, minVersion *version.Application, buildRequest RequestBuilder) ([]byte, ids.NodeID, error) {
+	waitingHandler := newWaitingResponseHandler()
+	nodeID, err := c.network.SendAppRequestAnyWith(ctx, minVersion, buildRequest, waitingHandler)
+	if err != nil {
+		return nil, nodeID, err
+	}
+	response, err := waitingHandler.WaitForResult(ctx)
+	return response, nodeID, err
+}
+
 // SendAppRequest synchronously sends request to the specified nodeID
 // Returns response bytes and ErrRequestFailed if the request should be retried.
 func (c *client) SendAppRequest(ctx context.Context, nodeID ids.NodeID, request []byte) ([]byte, error) {
@@ -73,6 +101,10 @@ func (c *client) SendAppRequest(ctx context.Context, nodeID ids.NodeID, request
 	return waitingHandler.WaitForResult(ctx)
 }
 
-func (c *client) TrackBandwidth(nodeID ids.NodeID, bandwidth float64) {
-	c.network.TrackBandwidth(nodeID, bandwidth)
+func (c *client) TrackBandwidth(nodeID ids.NodeID, bandwidth float64, responseSize int) {
+	c.network.TrackBandwidth(nodeID, bandwidth, responseSize)
+}
+
+func (c *client) TrackInvalidResponse(nodeID ids.NodeID) {
+	c.network.TrackInvalidResponse(nodeID)
 }
@@ -48,6 +48,10 @@ type Network interface {
 	// be sent to a peer with the desired [minVersion].
 	SendAppRequestAny(ctx context.Context, minVersion *version.Application, message []byte, handler message.ResponseHandler) (ids.NodeID, error)
 
+	// SendAppRequestAnyWith is like SendAppRequestAny, except that the request
+	// sent is built by [buildRequest] for the chosen peer.
+	SendAppRequestAnyWith(ctx context.Context, minVersion *version.Application, buildRequest RequestBuilder, handler message.ResponseHandler) (ids.NodeID, error)
+
 	// SendAppRequest sends message to given nodeID, notifying handler when there's a response or timeout
 	SendAppRequest(ctx context.Context, nodeID ids.NodeID, message []byte, handler message.ResponseHandler) error
 
@@ -66,8 +70,17 @@ type Network interface {
 	Size() uint32
 
 	// TrackBandwidth should be called for each valid request with the bandwidth
-	// (length of response divided by request time), and with 0 if the response is invalid.
-	TrackBandwidth(nodeID ids.NodeID, bandwidth float64)
+	// (length of response divided by request time) and the length of the
+	// response, and with 0 if the request failed.
+	TrackBandwidth(nodeID ids.NodeID, bandwidth float64, responseSize int)
+
+	// TrackInvalidResponse should be called when a response from [nodeID]
+	// fails verification.
+	TrackInvalidResponse(nodeID ids.NodeID)
+
+	// PeerScores returns the scores of the connected peers. Peers whose score
+	// drops too low are temporarily excluded from SendAppRequestAny.
+	PeerScores() []PeerScore
 
 	// NewClient returns a client to send messages with for the given protocol
 	NewClient(protocol uint64, options ...p2p.ClientOption) *p2p.Client
@@ -123,6 +136,16 @@ func NewNetwork(p2pNetwork *p2p.Network, appSender common.AppSender, codec codec
 // Returns the ID of the chosen peer, and an error if the request could not
 // be sent to a peer with the desired [minVersion].
 func (n *network) SendAppRequestAny(ctx context.Context, minVersion *version.Application, request []byte, handler message.ResponseHandler) (ids.NodeID, error) {
+	return n.SendAppRequestAnyWith(ctx, minVersion, func(ids.NodeID, *version.Application) ([]byte, error) { return request, nil }, handler)
+}
+
+// SendAppRequestAnyWith synchronously sends the request built by [buildRequest]
+// for an arbitrary peer with a node version greater than or equal to
+// minVersion. If minVersion is nil, the request will be sent to any peer
+// regardless of their version.
+// Returns the ID of the chosen peer, and an error if the request could not
+// be built or sent to a peer with the desired [minVersion].
+func (n *network) SendAppRequestAnyWith(ctx context.Context, minVersion *version.Application, buildRequest RequestBuilder, handler message.ResponseHandler) (ids.NodeID, error) {
 	// If the context was cancelled, we can skip sending this request.
 	if err := ctx.Err(); err != nil {
 		return ids.EmptyNodeID, err
@@ -136,6 +159,11 @@ func (n *network) SendAppRequestAny(ctx context.Context, minVersion *version.App
 	n.lock.Lock()
 	defer n.lock.Unlock()
 	if nodeID, ok := n.peers.GetAnyPeer(minVersion); ok {
+		request, err := buildRequest(nodeID, n.peers.peerVersion(nodeID))
+		if err != nil {
+			n.activeAppRequests.Release(1)
+			return nodeID, err
+		}
 		return nodeID, n.sendAppRequest(ctx, nodeID, request, handler)
 	}
 
@@ -428,11 +456,25 @@ func (n *network) Size() uint32 {
 	return uint32(n.peers.Size())
 }
 
-func (n *network) TrackBandwidth(nodeID ids.NodeID, bandwidth float64) {
+func (n *network) TrackBandwidth(nodeID ids.NodeID, bandwidth float64, responseSize int) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.peers.TrackBandwidth(nodeID, bandwidth, responseSize)
+}
+
+func (n *network) TrackInvalidResponse(nodeID ids.NodeID) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.peers.TrackInvalidResponse(nodeID)
+}
+
+func (n *network) PeerScores() []PeerScore {
 	n.lock.Lock()
 	defer n.lock.Unlock()
 
-	n.peers.TrackBandwidth(nodeID, bandwidth)
+	return n.peers.PeerScores()
 }
 
 func (n *network) NewClient(protocol uint64, options ...p2p.ClientOption) *p2p.Client {
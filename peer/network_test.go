@@ -450,6 +450,51 @@ func TestRequestMinVersion(t *testing.T) {
 	assert.Equal(t, "this is a response", response.Message)
 }
 
+func TestRequestAnyWithBuildsRequestForPeer(t *testing.T) {
+	nodeID := ids.GenerateTestNodeID()
+	codecManager := buildCodec(t, TestMessage{})
+
+	var net Network
+	sender := testAppSender{
+		sendAppRequestFn: func(_ context.Context, nodes set.Set[ids.NodeID], reqID uint32, requestBytes []byte) error {
+			assert.True(t, nodes.Contains(nodeID), "request nodes should contain expected nodeID")
+
+			go func() {
+				// echo the request
+				err := net.AppResponse(context.Background(), nodeID, reqID, requestBytes)
+				assert.NoError(t, err)
+			}()
+			return nil
+		},
+	}
+
+	p2pNetwork, err := p2p.NewNetwork(logging.NoLog{}, nil, prometheus.NewRegistry(), "")
+	require.NoError(t, err)
+	net = NewNetwork(p2pNetwork, sender, codecManager, ids.EmptyNodeID, 1)
+	client := NewNetworkClient(net)
+	assert.NoError(t, net.Connected(context.Background(), nodeID, defaultPeerVersion))
+
+	// a failure to build the request releases the request slot
+	errBuild := errors.New("build failed")
+	_, _, err = client.SendAppRequestAnyWith(context.Background(), defaultPeerVersion, func(ids.NodeID, *version.Application) ([]byte, error) {
+		return nil, errBuild
+	})
+	assert.ErrorIs(t, err, errBuild)
+
+	responseBytes, responseNodeID, err := client.SendAppRequestAnyWith(context.Background(), defaultPeerVersion, func(requestNodeID ids.NodeID, nodeVersion *version.Application) ([]byte, error) {
+		assert.Equal(t, defaultPeerVersion, nodeVersion)
+		return message.RequestToBytes(codecManager, TestMessage{Message: requestNodeID.String()})
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, nodeID, responseNodeID)
+
+	var response message.Request
+	if _, err = codecManager.Unmarshal(responseBytes, &response); err != nil {
+		t.Fatal("unexpected error during unmarshal", err)
+	}
+	assert.Equal(t, nodeID.String(), response.(TestMessage).Message)
+}
+
 func TestOnRequestHonoursDeadline(t *testing.T) {
 	var net Network
 	responded := false
@@ -6,11 +6,13 @@ package peer
 import (
 	"math"
 	"math/rand"
+	"sort"
 	"time"
 
 	"github.com/ava-labs/avalanchego/ids"
 	utils_math "github.com/ava-labs/avalanchego/utils/math"
 	"github.com/ava-labs/avalanchego/utils/set"
+	"github.com/ava-labs/avalanchego/utils/units"
 	"github.com/ava-labs/avalanchego/version"
 
 	"github.com/ethereum/go-ethereum/log"
@@ -29,12 +31,58 @@ const (
 	// controls how often we prefer a random responsive peer over the most
 	// performant peer.
 	randomPeerProbability = 0.2
+
+	// Peers start with a score of zero, which is rewarded for valid responses
+	// up to [maxPeerScore] and penalized for failed requests, invalid and slow
+	// responses. A peer whose score drops to [banPeerScore] is not returned
+	// by GetAnyPeer for [peerBanDuration], after which it is tracked as a new
+	// peer with a score of zero.
+	maxPeerScore           = 100
+	banPeerScore           = -100
+	validResponseReward    = 1
+	slowResponsePenalty    = 2
+	failedRequestPenalty   = 5
+	invalidResponsePenalty = 25
+	peerBanDuration        = 10 * time.Minute
+
+	// responses of at least [minSlowResponseSize] bytes with a bandwidth below
+	// this fraction of the average bandwidth of all peers are penalized as
+	// slow. Smaller responses are not, as their bandwidth is dominated by the
+	// round trip time even for honest peers.
+	slowResponseBandwidthFraction = 0.1
+	minSlowResponseSize           = 64 * units.KiB
 )
 
 // information we track on a given peer
 type peerInfo struct {
 	version   *version.Application
 	bandwidth utils_math.Averager
+
+	score            int64
+	validResponses   uint64
+	invalidResponses uint64
+	failedRequests   uint64
+	slowResponses    uint64
+	bans             uint64
+	bannedUntil      time.Time
+}
+
+// PeerScore is the score of a connected peer, which reflects the validity and
+// throughput of its responses to requests.
+type PeerScore struct {
+	NodeID           ids.NodeID `json:"nodeID"`
+	Version          string     `json:"version"`
+	Score            int64      `json:"score"`
+	Bandwidth        float64    `json:"bandwidth"`
+	ValidResponses   uint64     `json:"validResponses"`
+	InvalidResponses uint64     `json:"invalidResponses"`
+	FailedRequests   uint64     `json:"failedRequests"`
+	SlowResponses    uint64     `json:"slowResponses"`
+	Bans             uint64     `json:"bans"`
+	Banned           bool       `json:"banned"`
+	// BannedUntil is when the last ban of the peer ends, zero if it was never
+	// banned.
+	BannedUntil time.Time `json:"bannedUntil"`
 }
 
 // peerTracker tracks the bandwidth of responses coming from peers,
@@ -50,6 +98,8 @@ type peerTracker struct {
 	bandwidthHeap          utils_math.AveragerHeap // tracks bandwidth peers are responding with
 	averageBandwidthMetric metrics.GaugeFloat64
 	averageBandwidth       utils_math.Averager
+	numBannedPeers         metrics.Gauge
+	bannedPeers            set.Set[ids.NodeID] // peers excluded from GetAnyPeer until their ban ends
 }
 
 func NewPeerTracker() *peerTracker {
@@ -62,6 +112,8 @@ func NewPeerTracker() *peerTracker {
 		bandwidthHeap:          utils_math.NewMaxAveragerHeap(),
 		averageBandwidthMetric: metrics.GetOrRegisterGaugeFloat64("net_average_bandwidth", nil),
 		averageBandwidth:       utils_math.NewAverager(0, bandwidthHalflife, time.Now()),
+		numBannedPeers:         metrics.GetOrRegisterGauge("net_banned_peers", nil),
+		bannedPeers:            make(set.Set[ids.NodeID]),
 	}
 }
 
@@ -95,15 +147,25 @@ func (p *peerTracker) getResponsivePeer() (ids.NodeID, utils_math.Averager, bool
 	return nodeID, peer.bandwidth, true
 }
 
+// peerVersion returns the version of the connected peer [nodeID], or nil if
+// it is not connected.
+func (p *peerTracker) peerVersion(nodeID ids.NodeID) *version.Application {
+	if peer := p.peers[nodeID]; peer != nil {
+		return peer.version
+	}
+	return nil
+}
+
 func (p *peerTracker) GetAnyPeer(minVersion *version.Application) (ids.NodeID, bool) {
+	p.unbanPeers(time.Now())
 	if p.shouldTrackNewPeer() {
 		for nodeID := range p.peers {
 			// if minVersion is specified and peer's version is less, skip
 			if minVersion != nil && p.peers[nodeID].version.Compare(minVersion) < 0 {
 				continue
 			}
-			// skip peers already tracked
-			if p.trackedPeers.Contains(nodeID) {
+			// skip peers already tracked or banned
+			if p.trackedPeers.Contains(nodeID) || p.bannedPeers.Contains(nodeID) {
 				continue
 			}
 			log.Debug("peer tracking: connecting to new peer", "trackedPeers", len(p.trackedPeers), "nodeID", nodeID)
@@ -126,8 +188,14 @@ func (p *peerTracker) GetAnyPeer(minVersion *version.Application) (ids.NodeID, b
 		log.Debug("peer tracking: popping peer", "nodeID", nodeID, "bandwidth", averager.Read(), "random", random)
 		return nodeID, true
 	}
-	// if no nodes found in the bandwidth heap, return a tracked node at random
-	return p.trackedPeers.Peek()
+	// if no nodes found in the bandwidth heap, return a tracked node that is
+	// not banned
+	for nodeID := range p.trackedPeers {
+		if !p.bannedPeers.Contains(nodeID) {
+			return nodeID, true
+		}
+	}
+	return ids.NodeID{}, false
 }
 
 func (p *peerTracker) TrackPeer(nodeID ids.NodeID) {
@@ -135,7 +203,10 @@ func (p *peerTracker) TrackPeer(nodeID ids.NodeID) {
 	p.numTrackedPeers.Update(int64(p.trackedPeers.Len()))
 }
 
-func (p *peerTracker) TrackBandwidth(nodeID ids.NodeID, bandwidth float64) {
+// TrackBandwidth records the bandwidth of a valid response of [responseSize]
+// bytes from [nodeID], or that a request to [nodeID] failed if [bandwidth] is
+// 0.
+func (p *peerTracker) TrackBandwidth(nodeID ids.NodeID, bandwidth float64, responseSize int) {
 	peer := p.peers[nodeID]
 	if peer == nil {
 		// we're not connected to this peer, nothing to do here
@@ -144,23 +215,139 @@ func (p *peerTracker) TrackBandwidth(nodeID ids.NodeID, bandwidth float64) {
 	}
 
 	now := time.Now()
+	if bandwidth == 0 {
+		peer.failedRequests++
+		p.penalize(nodeID, peer, failedRequestPenalty, now)
+	} else if responseSize >= minSlowResponseSize && bandwidth < p.averageBandwidth.Read()*slowResponseBandwidthFraction {
+		peer.slowResponses++
+		p.penalize(nodeID, peer, slowResponsePenalty, now)
+	} else {
+		peer.validResponses++
+		peer.score = min(peer.score+validResponseReward, maxPeerScore)
+	}
+
 	if peer.bandwidth == nil {
 		peer.bandwidth = utils_math.NewAverager(bandwidth, bandwidthHalflife, now)
 	} else {
 		peer.bandwidth.Observe(bandwidth, now)
 	}
+	if bandwidth != 0 {
+		p.averageBandwidth.Observe(bandwidth, now)
+		p.averageBandwidthMetric.Update(p.averageBandwidth.Read())
+	}
+	// A banned peer is only tracked again once its ban ends.
+	if p.bannedPeers.Contains(nodeID) {
+		return
+	}
 	p.bandwidthHeap.Add(nodeID, peer.bandwidth)
 
 	if bandwidth == 0 {
 		p.responsivePeers.Remove(nodeID)
 	} else {
 		p.responsivePeers.Add(nodeID)
-		p.averageBandwidth.Observe(bandwidth, now)
-		p.averageBandwidthMetric.Update(p.averageBandwidth.Read())
 	}
 	p.numResponsivePeers.Update(int64(p.responsivePeers.Len()))
 }
 
+// TrackInvalidResponse records that [nodeID] responded to a request with a
+// response that failed verification.
+func (p *peerTracker) TrackInvalidResponse(nodeID ids.NodeID) {
+	peer := p.peers[nodeID]
+	if peer == nil {
+		// we're not connected to this peer, nothing to do here
+		log.Debug("tracking invalid response for untracked peer", "nodeID", nodeID)
+		return
+	}
+
+	now := time.Now()
+	peer.invalidResponses++
+	p.penalize(nodeID, peer, invalidResponsePenalty, now)
+
+	if peer.bandwidth == nil {
+		peer.bandwidth = utils_math.NewAverager(0, bandwidthHalflife, now)
+	} else {
+		peer.bandwidth.Observe(0, now)
+	}
+	if p.bannedPeers.Contains(nodeID) {
+		return
+	}
+	p.bandwidthHeap.Add(nodeID, peer.bandwidth)
+	p.responsivePeers.Remove(nodeID)
+	p.numResponsivePeers.Update(int64(p.responsivePeers.Len()))
+}
+
+// penalize lowers the score of [peer] by [penalty], banning it if the score
+// drops to [banPeerScore].
+func (p *peerTracker) penalize(nodeID ids.NodeID, peer *peerInfo, penalty int64, now time.Time) {
+	peer.score -= penalty
+	if peer.score > banPeerScore || p.bannedPeers.Contains(nodeID) {
+		return
+	}
+	log.Info("peer tracking: banning peer", "nodeID", nodeID, "invalidResponses", peer.invalidResponses, "failedRequests", peer.failedRequests, "slowResponses", peer.slowResponses, "duration", peerBanDuration)
+	peer.bans++
+	peer.bannedUntil = now.Add(peerBanDuration)
+	p.bannedPeers.Add(nodeID)
+	p.numBannedPeers.Update(int64(p.bannedPeers.Len()))
+
+	// Stop tracking the peer so that it is treated as a new peer once its
+	// ban ends.
+	p.bandwidthHeap.Remove(nodeID)
+	p.trackedPeers.Remove(nodeID)
+	p.numTrackedPeers.Update(int64(p.trackedPeers.Len()))
+	p.responsivePeers.Remove(nodeID)
+	p.numResponsivePeers.Update(int64(p.responsivePeers.Len()))
+}
+
+// unbanPeers lifts the bans that ended by [now], resetting the scores of the
+// unbanned peers.
+func (p *peerTracker) unbanPeers(now time.Time) {
+	for nodeID := range p.bannedPeers {
+		peer := p.peers[nodeID]
+		if peer.bannedUntil.After(now) {
+			continue
+		}
+		log.Debug("peer tracking: unbanning peer", "nodeID", nodeID)
+		peer.score = 0
+		p.bannedPeers.Remove(nodeID)
+	}
+	p.numBannedPeers.Update(int64(p.bannedPeers.Len()))
+}
+
+// PeerScores returns the scores of the connected peers, ordered from the
+// highest score to the lowest.
+func (p *peerTracker) PeerScores() []PeerScore {
+	now := time.Now()
+	p.unbanPeers(now)
+	scores := make([]PeerScore, 0, len(p.peers))
+	for nodeID, peer := range p.peers {
+		score := PeerScore{
+			NodeID:           nodeID,
+			Score:            peer.score,
+			ValidResponses:   peer.validResponses,
+			InvalidResponses: peer.invalidResponses,
+			FailedRequests:   peer.failedRequests,
+			SlowResponses:    peer.slowResponses,
+			Bans:             peer.bans,
+			Banned:           p.bannedPeers.Contains(nodeID),
+			BannedUntil:      peer.bannedUntil,
+		}
+		if peer.version != nil {
+			score.Version = peer.version.String()
+		}
+		if peer.bandwidth != nil {
+			score.Bandwidth = peer.bandwidth.Read()
+		}
+		scores = append(scores, score)
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].NodeID.Compare(scores[j].NodeID) < 0
+	})
+	return scores
+}
+
 // Connected should be called when [nodeID] connects to this node
 func (p *peerTracker) Connected(nodeID ids.NodeID, nodeVersion *version.Application) {
 	if peer := p.peers[nodeID]; peer != nil {
@@ -168,11 +355,8 @@ func (p *peerTracker) Connected(nodeID ids.NodeID, nodeVersion *version.Applicat
 		// Log a warning message since the consensus engine should never call Connected on a peer
 		// that we have already marked as Connected.
 		if nodeVersion.Compare(peer.version) != 0 {
-			p.peers[nodeID] = &peerInfo{
-				version:   nodeVersion,
-				bandwidth: peer.bandwidth,
-			}
 			log.Warn("updating node version of already connected peer", "nodeID", nodeID, "storedVersion", peer.version, "nodeVersion", nodeVersion)
+			peer.version = nodeVersion
 		} else {
 			log.Warn("ignoring peer connected event for already connected peer with identical version", "nodeID", nodeID)
 		}
@@ -191,6 +375,8 @@ func (p *peerTracker) Disconnected(nodeID ids.NodeID) {
 	p.numTrackedPeers.Update(int64(p.trackedPeers.Len()))
 	p.responsivePeers.Remove(nodeID)
 	p.numResponsivePeers.Update(int64(p.responsivePeers.Len()))
+	p.bannedPeers.Remove(nodeID)
+	p.numBannedPeers.Update(int64(p.bannedPeers.Len()))
 	delete(p.peers, nodeID)
 }
 
@@ -5,6 +5,7 @@ package peer
 
 import (
 	"testing"
+	"time"
 
 	"github.com/ava-labs/avalanchego/ids"
 	"github.com/stretchr/testify/require"
@@ -43,10 +44,10 @@ func TestPeerTracker(t *testing.T) {
 	i := 0
 	for peer := range responsivePeers {
 		if i < desiredMinResponsivePeers {
-			p.TrackBandwidth(peer, 10)
+			p.TrackBandwidth(peer, 10, 10)
 		} else {
 			responsivePeers[peer] = false // remember which peers were not responsive
-			p.TrackBandwidth(peer, 0)
+			p.TrackBandwidth(peer, 0, 0)
 		}
 		i++
 	}
@@ -61,11 +62,11 @@ func TestPeerTracker(t *testing.T) {
 		responsive, ok := responsivePeers[peer]
 		if ok {
 			require.Truef(responsive, "expected connecting to a responsive peer, but got a peer that was not responsive: peer %s iteration %d", peer, i)
-			p.TrackBandwidth(peer, 10)
+			p.TrackBandwidth(peer, 10, 10)
 		} else {
 			responsivePeers[peer] = false // remember that we connected to this peer
 			p.TrackPeer(peer)             // mark the peer as having a message sent to it
-			p.TrackBandwidth(peer, 0)     // mark the peer as non-responsive
+			p.TrackBandwidth(peer, 0, 0)  // mark the peer as non-responsive
 		}
 	}
 
@@ -86,3 +87,98 @@ func TestPeerTracker(t *testing.T) {
 	require.True(ok)
 	require.Falsef(responsive, "expected connecting to a non-responsive peer, but got a peer that was responsive: peer %s", peer)
 }
+
+func TestPeerTrackerBansPeers(t *testing.T) {
+	require := require.New(t)
+	p := NewPeerTracker()
+
+	goodPeer, badPeer := ids.GenerateTestNodeID(), ids.GenerateTestNodeID()
+	p.Connected(goodPeer, defaultPeerVersion)
+	p.Connected(badPeer, defaultPeerVersion)
+	for _, nodeID := range []ids.NodeID{goodPeer, badPeer} {
+		p.TrackPeer(nodeID)
+		p.TrackBandwidth(nodeID, 10, 10)
+	}
+
+	// A peer is banned once invalid responses bring its score down to
+	// [banPeerScore].
+	numInvalid := 0
+	for p.peers[badPeer].score > banPeerScore {
+		p.TrackInvalidResponse(badPeer)
+		numInvalid++
+	}
+	scores := p.PeerScores()
+	require.Len(scores, 2)
+	require.Equal(goodPeer, scores[0].NodeID)
+	require.EqualValues(validResponseReward, scores[0].Score)
+	require.False(scores[0].Banned)
+	require.Equal(badPeer, scores[1].NodeID)
+	require.EqualValues(numInvalid, scores[1].InvalidResponses)
+	require.EqualValues(1, scores[1].Bans)
+	require.True(scores[1].Banned)
+
+	// A banned peer is not returned, even as the only peer left.
+	for i := 0; i < 20; i++ {
+		nodeID, ok := p.GetAnyPeer(nil)
+		require.True(ok)
+		require.Equal(goodPeer, nodeID)
+		p.TrackBandwidth(nodeID, 10, 10)
+	}
+	p.Disconnected(goodPeer)
+	_, ok := p.GetAnyPeer(nil)
+	require.False(ok)
+
+	// Responses received while banned do not make the peer tracked again.
+	p.TrackBandwidth(badPeer, 10, 10)
+	_, ok = p.GetAnyPeer(nil)
+	require.False(ok)
+
+	// Once the ban ends, the peer is tracked as a new peer with a reset score.
+	p.peers[badPeer].bannedUntil = time.Now().Add(-time.Second)
+	nodeID, ok := p.GetAnyPeer(nil)
+	require.True(ok)
+	require.Equal(badPeer, nodeID)
+	scores = p.PeerScores()
+	require.Len(scores, 1)
+	require.Zero(scores[0].Score)
+	require.False(scores[0].Banned)
+}
+
+func TestPeerTrackerPenalizesFailuresAndSlowResponses(t *testing.T) {
+	require := require.New(t)
+	p := NewPeerTracker()
+
+	fastPeer, smallPeer, slowPeer, failingPeer := ids.GenerateTestNodeID(), ids.GenerateTestNodeID(), ids.GenerateTestNodeID(), ids.GenerateTestNodeID()
+	for _, nodeID := range []ids.NodeID{fastPeer, smallPeer, slowPeer, failingPeer} {
+		p.Connected(nodeID, defaultPeerVersion)
+		p.TrackPeer(nodeID)
+	}
+	p.TrackBandwidth(fastPeer, 1_000_000, minSlowResponseSize)
+	p.TrackBandwidth(fastPeer, 1_000_000, minSlowResponseSize)
+	p.TrackBandwidth(smallPeer, 1000, minSlowResponseSize-1)
+	p.TrackBandwidth(slowPeer, 1000, minSlowResponseSize)
+	p.TrackBandwidth(failingPeer, 0, 0)
+
+	scores := p.PeerScores()
+	require.Len(scores, 4)
+	require.Equal(fastPeer, scores[0].NodeID)
+	require.EqualValues(2*validResponseReward, scores[0].Score)
+	// Slow responses below [minSlowResponseSize], such as small code
+	// responses or the last page of a trie, are not penalized.
+	require.Equal(smallPeer, scores[1].NodeID)
+	require.EqualValues(validResponseReward, scores[1].Score)
+	require.EqualValues(1, scores[1].ValidResponses)
+	require.Zero(scores[1].SlowResponses)
+	require.Equal(slowPeer, scores[2].NodeID)
+	require.EqualValues(-slowResponsePenalty, scores[2].Score)
+	require.EqualValues(1, scores[2].SlowResponses)
+	require.Equal(failingPeer, scores[3].NodeID)
+	require.EqualValues(-failedRequestPenalty, scores[3].Score)
+	require.EqualValues(1, scores[3].FailedRequests)
+
+	// Scores are capped at [maxPeerScore].
+	for i := 0; i < maxPeerScore+10; i++ {
+		p.TrackBandwidth(fastPeer, 1000, 1000)
+	}
+	require.EqualValues(maxPeerScore, p.PeerScores()[0].Score)
+}
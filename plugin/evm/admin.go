@@ -4,11 +4,22 @@
 package evm
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"sort"
 
 	"github.com/ava-labs/avalanchego/api"
+	"github.com/ava-labs/avalanchego/snow/engine/snowman/block"
+	avajson "github.com/ava-labs/avalanchego/utils/json"
 	"github.com/ava-labs/avalanchego/utils/profiler"
+	"github.com/ava-labs/coreth/params"
+	"github.com/ava-labs/coreth/peer"
+	"github.com/ava-labs/coreth/plugin/evm/message"
+	"github.com/ava-labs/coreth/sync/statesync"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
 )
 
@@ -89,3 +100,322 @@ func (p *Admin) GetVMConfig(_ *http.Request, _ *struct{}, reply *ConfigReply) er
 	reply.Config = &p.vm.config
 	return nil
 }
+
+type SimulatePrecompileUpgradeArgs struct {
+	// Upgrades is the proposed upgrade config, in the same format as the
+	// upgrade file (ex. {"precompileUpgrades": [...]}).
+	Upgrades json.RawMessage `json:"upgrades"`
+}
+
+type SimulatePrecompileUpgradeReply struct {
+	Compatible            bool                          `json:"compatible"`
+	Error                 string                        `json:"error,omitempty"`
+	LastAcceptedHeight    avajson.Uint64                `json:"lastAcceptedHeight"`
+	LastAcceptedTimestamp avajson.Uint64                `json:"lastAcceptedTimestamp"`
+	Schedule              []params.PrecompileActivation `json:"schedule"`
+	ActivePrecompiles     []string                      `json:"activePrecompiles"`
+}
+
+// SimulatePrecompileUpgrade checks the proposed precompile upgrades against the
+// current chain config and last accepted block, as the node would on restart,
+// and reports the resulting precompile schedule.
+// The proposed upgrades replace the full list of scheduled precompile upgrades.
+func (p *Admin) SimulatePrecompileUpgrade(_ *http.Request, args *SimulatePrecompileUpgradeArgs, reply *SimulatePrecompileUpgradeReply) error {
+	log.Info("EVM: SimulatePrecompileUpgrade called")
+
+	var upgradeConfig params.UpgradeConfig
+	if err := json.Unmarshal(args.Upgrades, &upgradeConfig); err != nil {
+		return fmt.Errorf("failed to parse upgrades: %w", err)
+	}
+
+	lastAccepted := p.vm.blockChain.LastAcceptedBlock()
+	reply.LastAcceptedHeight = avajson.Uint64(lastAccepted.NumberU64())
+	reply.LastAcceptedTimestamp = avajson.Uint64(lastAccepted.Time())
+
+	newCfg, err := p.vm.chainConfig.WithPrecompileUpgrades(upgradeConfig.PrecompileUpgrades, lastAccepted.Time())
+	if err != nil {
+		reply.Error = err.Error()
+		return nil
+	}
+	reply.Compatible = true
+	reply.Schedule = newCfg.PrecompileSchedule()
+	for key := range newCfg.EnabledStatefulPrecompiles(lastAccepted.Time()) {
+		reply.ActivePrecompiles = append(reply.ActivePrecompiles, key)
+	}
+	sort.Strings(reply.ActivePrecompiles)
+	return nil
+}
+
+type ExportSyncSnapshotArgs struct {
+	// Path is the file the sync snapshot is written to.
+	Path string `json:"path"`
+	// Height is the height of the syncable summary to export. Zero exports
+	// the last syncable summary.
+	Height avajson.Uint64 `json:"height"`
+}
+
+type ExportSyncSnapshotReply struct {
+	Height     avajson.Uint64 `json:"height"`
+	BlockHash  common.Hash    `json:"blockHash"`
+	BlockRoot  common.Hash    `json:"blockRoot"`
+	AtomicRoot common.Hash    `json:"atomicRoot"`
+	TrieNodes  avajson.Uint64 `json:"trieNodes"`
+	Code       avajson.Uint64 `json:"code"`
+	Blocks     avajson.Uint64 `json:"blocks"`
+}
+
+// ExportSyncSnapshot writes the state at a syncable summary to a file, which
+// nodes configured with state-sync-snapshot-file sync from instead of from
+// their peers if the network accepts its summary.
+func (p *Admin) ExportSyncSnapshot(r *http.Request, args *ExportSyncSnapshotArgs, reply *ExportSyncSnapshotReply) error {
+	log.Info("EVM: ExportSyncSnapshot called", "path", args.Path, "height", args.Height)
+
+	if args.Path == "" {
+		return errors.New("path must be specified")
+	}
+	var (
+		summary block.StateSummary
+		err     error
+	)
+	if args.Height == 0 {
+		summary, err = p.vm.GetLastStateSummary(r.Context())
+	} else {
+		summary, err = p.vm.GetStateSummary(r.Context(), uint64(args.Height))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get state summary: %w", err)
+	}
+	syncSummary, ok := summary.(message.SyncSummary)
+	if !ok {
+		return fmt.Errorf("unexpected state summary type %T", summary)
+	}
+
+	// Write to a temporary file so that [args.Path] only ever holds a
+	// complete snapshot.
+	tmpPath := args.Path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	stats, err := exportSyncSnapshot(
+		r.Context(),
+		file,
+		syncSummary,
+		p.vm.blockChain,
+		p.vm.blockChain.StateCache().TrieDB(),
+		p.vm.atomicTrie.TrieDB(),
+		p.vm.chaindb,
+	)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, args.Path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to export sync snapshot: %w", err)
+	}
+
+	reply.Height = avajson.Uint64(syncSummary.Height())
+	reply.BlockHash = syncSummary.BlockHash
+	reply.BlockRoot = syncSummary.BlockRoot
+	reply.AtomicRoot = syncSummary.AtomicRoot
+	reply.TrieNodes = avajson.Uint64(stats.TrieNodes)
+	reply.Code = avajson.Uint64(stats.Code)
+	reply.Blocks = avajson.Uint64(stats.Blocks)
+	return nil
+}
+
+type StartStateBackfillArgs struct {
+	// SnapshotFile is a sync snapshot file on the node holding the trusted
+	// state to re-execute from. If empty, re-execution starts from genesis.
+	SnapshotFile string `json:"snapshotFile"`
+}
+
+// StartStateBackfill starts reconstructing in the background the historical
+// state below the height the node state synced to, by fetching the earlier
+// blocks from peers and re-executing them.
+func (p *Admin) StartStateBackfill(_ *http.Request, args *StartStateBackfillArgs, reply *StateBackfillProgress) error {
+	log.Info("EVM: StartStateBackfill called", "snapshotFile", args.SnapshotFile)
+
+	p.vm.ctx.Lock.Lock()
+	defer p.vm.ctx.Lock.Unlock()
+
+	b, err := p.vm.startStateBackfill(args.SnapshotFile)
+	if err != nil {
+		return err
+	}
+	*reply = b.Progress()
+	return nil
+}
+
+// StateBackfillProgress returns the progress of the most recently started
+// state backfill.
+func (p *Admin) StateBackfillProgress(_ *http.Request, _ *struct{}, reply *StateBackfillProgress) error {
+	*reply = p.vm.stateBackfillProgress()
+	return nil
+}
+
+type PeerScoresReply struct {
+	Peers []peer.PeerScore `json:"peers"`
+}
+
+// GetPeerScores returns the scores of the connected peers as sources of state
+// sync data. Peers are penalized for failed requests, invalid and slow
+// responses, and temporarily not requested from once their score drops too
+// low.
+func (p *Admin) GetPeerScores(_ *http.Request, _ *struct{}, reply *PeerScoresReply) error {
+	reply.Peers = p.vm.Network.PeerScores()
+	return nil
+}
+
+// defaultCodeSyncQueueLimit is the number of code hashes returned by
+// GetCodeSyncQueue if no limit is specified.
+const defaultCodeSyncQueueLimit = 1000
+
+type GetCodeSyncQueueArgs struct {
+	// Limit is the maximum number of code hashes returned, or 0 for
+	// [defaultCodeSyncQueueLimit].
+	Limit avajson.Uint32 `json:"limit"`
+}
+
+type CodeSyncQueueReply struct {
+	CodeHashes []common.Hash  `json:"codeHashes"`
+	Total      avajson.Uint64 `json:"total"`
+}
+
+// GetCodeSyncQueue returns the code hashes persisted as pending to be fetched
+// by state sync.
+func (p *Admin) GetCodeSyncQueue(_ *http.Request, args *GetCodeSyncQueueArgs, reply *CodeSyncQueueReply) error {
+	log.Info("EVM: GetCodeSyncQueue called", "limit", args.Limit)
+
+	limit := int(args.Limit)
+	if limit == 0 {
+		limit = defaultCodeSyncQueueLimit
+	}
+	codeHashes, total, err := statesync.ReadCodeQueue(p.vm.chaindb, limit)
+	if err != nil {
+		return err
+	}
+	reply.CodeHashes = codeHashes
+	reply.Total = avajson.Uint64(total)
+	return nil
+}
+
+type VerifyCodeArgs struct {
+	// CodeHashes are the code hashes to verify the code of. If empty, all
+	// the code in the database is verified.
+	CodeHashes []common.Hash `json:"codeHashes"`
+	// Refetch fetches from peers the code that is invalid or missing.
+	Refetch bool `json:"refetch"`
+}
+
+type VerifyCodeReply struct {
+	Checked   avajson.Uint64 `json:"checked"`
+	Invalid   []common.Hash  `json:"invalid"`
+	Missing   []common.Hash  `json:"missing"`
+	Refetched avajson.Uint64 `json:"refetched"`
+}
+
+// VerifyCode checks that the code written to the database hashes to its code
+// hash.
+func (p *Admin) VerifyCode(r *http.Request, args *VerifyCodeArgs, reply *VerifyCodeReply) error {
+	log.Info("EVM: VerifyCode called", "codeHashes", len(args.CodeHashes), "refetch", args.Refetch)
+
+	result, err := statesync.VerifyCode(r.Context(), p.vm.chaindb, args.CodeHashes)
+	if err != nil {
+		return fmt.Errorf("failed to verify code: %w", err)
+	}
+	reply.Checked = avajson.Uint64(result.Checked)
+	reply.Invalid = result.Invalid
+	reply.Missing = result.Missing
+	if !args.Refetch {
+		return nil
+	}
+	refetch := make([]common.Hash, 0, len(result.Invalid)+len(result.Missing))
+	refetch = append(refetch, result.Invalid...)
+	refetch = append(refetch, result.Missing...)
+	if err := p.refetchCode(r, refetch); err != nil {
+		return err
+	}
+	reply.Refetched = avajson.Uint64(len(refetch))
+	return nil
+}
+
+type RefetchCodeArgs struct {
+	// CodeHashes are the code hashes to fetch the code of. If empty, the
+	// code hashes pending in the code sync queue are fetched.
+	CodeHashes []common.Hash `json:"codeHashes"`
+}
+
+type RefetchCodeReply struct {
+	Refetched avajson.Uint64 `json:"refetched"`
+}
+
+// RefetchCode fetches code from peers and writes it to the database,
+// replacing any code stored for the same code hashes.
+func (p *Admin) RefetchCode(r *http.Request, args *RefetchCodeArgs, reply *RefetchCodeReply) error {
+	log.Info("EVM: RefetchCode called", "codeHashes", len(args.CodeHashes))
+
+	codeHashes := args.CodeHashes
+	if len(codeHashes) == 0 {
+		var err error
+		codeHashes, _, err = statesync.ReadCodeQueue(p.vm.chaindb, 0)
+		if err != nil {
+			return err
+		}
+	}
+	if err := p.refetchCode(r, codeHashes); err != nil {
+		return err
+	}
+	reply.Refetched = avajson.Uint64(len(codeHashes))
+	return nil
+}
+
+type CheckCodeConsistencyArgs struct {
+	// Refetch fetches from peers the code that is missing.
+	Refetch bool `json:"refetch"`
+}
+
+type CheckCodeConsistencyReply struct {
+	Root      common.Hash    `json:"root"`
+	Accounts  avajson.Uint64 `json:"accounts"`
+	Missing   []common.Hash  `json:"missing"`
+	Refetched avajson.Uint64 `json:"refetched"`
+}
+
+// CheckCodeConsistency scans the accounts of the last accepted state for code
+// hashes whose code is missing from the database.
+func (p *Admin) CheckCodeConsistency(r *http.Request, args *CheckCodeConsistencyArgs, reply *CheckCodeConsistencyReply) error {
+	log.Info("EVM: CheckCodeConsistency called", "refetch", args.Refetch)
+
+	root := p.vm.blockChain.LastAcceptedBlock().Root()
+	accounts, missing, err := statesync.FindMissingCode(r.Context(), p.vm.blockChain.StateCache().TrieDB(), root, p.vm.chaindb)
+	if err != nil {
+		return fmt.Errorf("failed to check code consistency: %w", err)
+	}
+	reply.Root = root
+	reply.Accounts = avajson.Uint64(accounts)
+	reply.Missing = missing
+	if !args.Refetch {
+		return nil
+	}
+	if err := p.refetchCode(r, missing); err != nil {
+		return err
+	}
+	reply.Refetched = avajson.Uint64(len(missing))
+	return nil
+}
+
+// refetchCode fetches the code of [codeHashes] from peers.
+func (p *Admin) refetchCode(r *http.Request, codeHashes []common.Hash) error {
+	if len(codeHashes) == 0 {
+		return nil
+	}
+	client, err := p.vm.newPeerSyncClient()
+	if err != nil {
+		return err
+	}
+	return statesync.RefetchCode(r.Context(), client, p.vm.chaindb, codeHashes)
+}
@@ -0,0 +1,121 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/ava-labs/coreth/core/rawdb"
+	"github.com/ava-labs/coreth/params"
+	"github.com/ava-labs/coreth/precompile/contracts/warp"
+	"github.com/ava-labs/coreth/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulatePrecompileUpgrade(t *testing.T) {
+	_, vm, _, _, _ := GenesisVM(t, true, genesisJSONLatest, "", "")
+	defer func() {
+		require.NoError(t, vm.Shutdown(context.Background()))
+	}()
+	admin := NewAdminService(vm, "")
+	disableTime := vm.blockChain.LastAcceptedBlock().Time() + 100
+
+	marshalUpgrades := func(upgrades ...params.PrecompileUpgrade) []byte {
+		upgradeBytes, err := json.Marshal(params.UpgradeConfig{PrecompileUpgrades: upgrades})
+		require.NoError(t, err)
+		return upgradeBytes
+	}
+
+	tests := map[string]struct {
+		upgradeBytes       []byte
+		expectedErr        bool
+		expectedCompatible bool
+		expectedSchedule   []params.PrecompileActivation
+		expectedActive     []string
+	}{
+		"schedule warp deactivation": {
+			upgradeBytes: marshalUpgrades(
+				params.PrecompileUpgrade{Config: warp.NewDefaultConfig(utils.NewUint64(0))},
+				params.PrecompileUpgrade{Config: warp.NewDisableConfig(utils.NewUint64(disableTime))},
+			),
+			expectedCompatible: true,
+			expectedSchedule: []params.PrecompileActivation{
+				{Key: warp.ConfigKey, Address: warp.ContractAddress, Timestamp: 0},
+				{Key: warp.ConfigKey, Address: warp.ContractAddress, Timestamp: disableTime, Disabled: true},
+			},
+			expectedActive: []string{warp.ConfigKey},
+		},
+		"missing activated upgrade": {
+			upgradeBytes:       marshalUpgrades(),
+			expectedCompatible: false,
+		},
+		"disable before enable": {
+			upgradeBytes: marshalUpgrades(
+				params.PrecompileUpgrade{Config: warp.NewDisableConfig(utils.NewUint64(disableTime))},
+			),
+			expectedCompatible: false,
+		},
+		"unknown precompile": {
+			upgradeBytes: []byte(`{"precompileUpgrades": [{"unknownConfig": {"blockTimestamp": 100}}]}`),
+			expectedErr:  true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require := require.New(t)
+
+			reply := &SimulatePrecompileUpgradeReply{}
+			err := admin.SimulatePrecompileUpgrade(nil, &SimulatePrecompileUpgradeArgs{Upgrades: test.upgradeBytes}, reply)
+			if test.expectedErr {
+				require.Error(err)
+				return
+			}
+			require.NoError(err)
+			require.Equal(test.expectedCompatible, reply.Compatible, reply.Error)
+			if !test.expectedCompatible {
+				require.NotEmpty(reply.Error)
+				return
+			}
+			require.Empty(reply.Error)
+			require.Equal(test.expectedSchedule, reply.Schedule)
+			require.Equal(test.expectedActive, reply.ActivePrecompiles)
+		})
+	}
+}
+
+func TestCodeSyncAdmin(t *testing.T) {
+	_, vm, _, _, _ := GenesisVM(t, true, genesisJSONLatest, "", "")
+	defer func() {
+		require.NoError(t, vm.Shutdown(context.Background()))
+	}()
+	admin := NewAdminService(vm, "")
+	require := require.New(t)
+
+	queuedHash := crypto.Keccak256Hash([]byte("queued code"))
+	rawdb.AddCodeToFetch(vm.chaindb, queuedHash)
+	queueReply := CodeSyncQueueReply{}
+	require.NoError(admin.GetCodeSyncQueue(&http.Request{}, &GetCodeSyncQueueArgs{}, &queueReply))
+	require.Equal([]common.Hash{queuedHash}, queueReply.CodeHashes)
+	require.EqualValues(1, queueReply.Total)
+
+	invalidHash := crypto.Keccak256Hash([]byte("original code"))
+	rawdb.WriteCode(vm.chaindb, invalidHash, []byte("corrupted code"))
+	verifyReply := VerifyCodeReply{}
+	require.NoError(admin.VerifyCode(&http.Request{}, &VerifyCodeArgs{CodeHashes: []common.Hash{invalidHash, queuedHash}}, &verifyReply))
+	require.EqualValues(1, verifyReply.Checked)
+	require.Equal([]common.Hash{invalidHash}, verifyReply.Invalid)
+	require.Equal([]common.Hash{queuedHash}, verifyReply.Missing)
+	require.Zero(verifyReply.Refetched)
+
+	consistencyReply := CheckCodeConsistencyReply{}
+	require.NoError(admin.CheckCodeConsistency(&http.Request{}, &CheckCodeConsistencyArgs{}, &consistencyReply))
+	require.Equal(vm.blockChain.LastAcceptedBlock().Root(), consistencyReply.Root)
+	require.NotZero(consistencyReply.Accounts)
+	require.Empty(consistencyReply.Missing)
+}
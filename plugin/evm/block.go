@@ -168,6 +168,8 @@ func (b *Block) Accept(context.Context) error {
 		// Remove the accepted transaction from the mempool
 		vm.mempool.RemoveTx(tx)
 	}
+	// Remove the bundles which may no longer be included
+	vm.miner.RemoveIncludedBundles(b.ethBlock)
 
 	// Update VM state for atomic txs in this block. This includes updating the
 	// atomic tx repo, atomic trie, and shared memory.
@@ -10,6 +10,7 @@ import (
 	"github.com/ava-labs/avalanchego/utils/timer"
 	"github.com/ava-labs/coreth/core"
 	"github.com/ava-labs/coreth/core/txpool"
+	"github.com/ava-labs/coreth/miner"
 	"github.com/ava-labs/coreth/params"
 	"github.com/holiman/uint256"
 
@@ -30,6 +31,7 @@ type blockBuilder struct {
 
 	txPool  *txpool.TxPool
 	mempool *Mempool
+	miner   *miner.Miner
 
 	shutdownChan <-chan struct{}
 	shutdownWg   *sync.WaitGroup
@@ -58,6 +60,7 @@ func (vm *VM) NewBlockBuilder(notifyBuildBlockChan chan<- commonEng.Message) *bl
 		chainConfig:          vm.chainConfig,
 		txPool:               vm.txPool,
 		mempool:              vm.mempool,
+		miner:                vm.miner,
 		shutdownChan:         vm.shutdownChan,
 		shutdownWg:           &vm.shutdownWg,
 		notifyBuildBlockChan: notifyBuildBlockChan,
@@ -98,13 +101,13 @@ func (b *blockBuilder) handleGenerateBlock() {
 	b.buildBlockTimer.SetTimeoutIn(minBlockBuildingRetryDelay)
 }
 
-// needToBuild returns true if there are outstanding transactions to be issued
-// into a block.
+// needToBuild returns true if there are outstanding transactions or bundles
+// not yet tried to be issued into a block.
 func (b *blockBuilder) needToBuild() bool {
 	size := b.txPool.PendingSize(txpool.PendingFilter{
 		MinTip: uint256.MustFromBig(b.txPool.GasTip()),
 	})
-	return size > 0 || b.mempool.Len() > 0
+	return size > 0 || b.mempool.Len() > 0 || b.miner.PendingBundles() > 0
 }
 
 // markBuilding adds a PendingTxs message to the toEngine channel.
@@ -148,10 +151,13 @@ func (b *blockBuilder) awaitSubmittedTxs() {
 	// may orphan transactions that were previously in a preferred block.
 	txSubmitChan := make(chan core.NewTxsEvent)
 	b.txPool.SubscribeTransactions(txSubmitChan, true)
+	bundleSubmitChan := make(chan miner.NewBundleEvent)
+	bundleSub := b.miner.SubscribeNewBundles(bundleSubmitChan)
 
 	b.shutdownWg.Add(1)
 	go b.ctx.Log.RecoverAndPanic(func() {
 		defer b.shutdownWg.Done()
+		defer bundleSub.Unsubscribe()
 
 		for {
 			select {
@@ -161,6 +167,9 @@ func (b *blockBuilder) awaitSubmittedTxs() {
 			case <-b.mempool.Pending:
 				log.Trace("New atomic Tx detected, trying to generate a block")
 				b.signalTxsReady()
+			case <-bundleSubmitChan:
+				log.Trace("New bundle detected, trying to generate a block")
+				b.signalTxsReady()
 			case <-b.shutdownChan:
 				b.buildBlockTimer.Stop()
 				return
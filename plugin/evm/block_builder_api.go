@@ -0,0 +1,111 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/coreth/precompile/precompileconfig"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// BlockBuilderAPI offers debugging methods for block building.
+type BlockBuilderAPI struct{ vm *VM }
+
+// PendingBlockTx is a transaction included in a pending block.
+type PendingBlockTx struct {
+	Hash    common.Hash    `json:"hash"`
+	GasUsed hexutil.Uint64 `json:"gasUsed"`
+}
+
+// SkippedBlockTx is a pending transaction left out of a pending block.
+type SkippedBlockTx struct {
+	Hash   common.Hash `json:"hash"`
+	Reason string      `json:"reason"`
+}
+
+// BuildPendingBlockReply is the block that would currently be built.
+type BuildPendingBlockReply struct {
+	Number         *hexutil.Big     `json:"number"`
+	ParentHash     common.Hash      `json:"parentHash"`
+	Timestamp      hexutil.Uint64   `json:"timestamp"`
+	GasLimit       hexutil.Uint64   `json:"gasLimit"`
+	GasUsed        hexutil.Uint64   `json:"gasUsed"`
+	BaseFee        *hexutil.Big     `json:"baseFee"`
+	BlockGasCost   *hexutil.Big     `json:"blockGasCost"`
+	ExtDataGasUsed *hexutil.Big     `json:"extDataGasUsed"`
+	Transactions   []PendingBlockTx `json:"transactions"`
+	Skipped        []SkippedBlockTx `json:"skipped"`
+	AtomicTxs      []ids.ID         `json:"atomicTxs"`
+	// Error is set if the block could not be assembled, in which case the
+	// block gas cost and atomic transactions are not known.
+	Error string `json:"error,omitempty"`
+}
+
+// BuildPendingBlock builds the block that would currently be built on top of
+// the preferred block without issuing it, reporting the transactions that
+// would be included, the pending transactions that would be skipped along
+// with the reason, and the resulting fees.
+//
+// The block is built without a proposervm block context, so transactions with
+// predicates requiring it are reported as skipped. As when building a block,
+// atomic transactions failing verification are discarded from the mempool.
+func (api *BlockBuilderAPI) BuildPendingBlock(ctx context.Context) (*BuildPendingBlockReply, error) {
+	api.vm.ctx.Lock.Lock()
+	defer api.vm.ctx.Lock.Unlock()
+
+	predicateCtx := &precompileconfig.PredicateContext{
+		SnowCtx: api.vm.ctx,
+	}
+	pending, err := api.vm.miner.BuildPendingBlock(predicateCtx)
+	// Return the atomic transactions picked for the block to the mempool.
+	api.vm.mempool.CancelCurrentTxs()
+	if err != nil {
+		return nil, err
+	}
+
+	header := pending.Header
+	reply := &BuildPendingBlockReply{
+		Number:       (*hexutil.Big)(header.Number),
+		ParentHash:   header.ParentHash,
+		Timestamp:    hexutil.Uint64(header.Time),
+		GasLimit:     hexutil.Uint64(header.GasLimit),
+		GasUsed:      hexutil.Uint64(header.GasUsed),
+		BaseFee:      (*hexutil.Big)(header.BaseFee),
+		Transactions: make([]PendingBlockTx, 0, len(pending.Receipts)),
+		Skipped:      make([]SkippedBlockTx, 0, len(pending.Skipped)),
+		AtomicTxs:    []ids.ID{},
+	}
+	for _, receipt := range pending.Receipts {
+		reply.Transactions = append(reply.Transactions, PendingBlockTx{
+			Hash:    receipt.TxHash,
+			GasUsed: hexutil.Uint64(receipt.GasUsed),
+		})
+	}
+	for _, skipped := range pending.Skipped {
+		reply.Skipped = append(reply.Skipped, SkippedBlockTx{
+			Hash:   skipped.Hash,
+			Reason: skipped.Reason,
+		})
+	}
+	if pending.AssembleErr != nil {
+		reply.Error = pending.AssembleErr.Error()
+		return reply, nil
+	}
+
+	block := pending.Block
+	reply.BlockGasCost = (*hexutil.Big)(block.BlockGasCost())
+	reply.ExtDataGasUsed = (*hexutil.Big)(block.ExtDataGasUsed())
+	atomicTxs, err := ExtractAtomicTxs(block.ExtData(), api.vm.chainConfig.IsApricotPhase5(block.Time()), api.vm.codec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract atomic transactions: %w", err)
+	}
+	for _, tx := range atomicTxs {
+		reply.AtomicTxs = append(reply.AtomicTxs, tx.ID())
+	}
+	return reply, nil
+}
@@ -0,0 +1,60 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/crypto/secp256k1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildPendingBlock(t *testing.T) {
+	require := require.New(t)
+
+	importAmount := uint64(50000000)
+	issuer, vm, _, _, _ := GenesisVMWithUTXOs(t, true, "", "", "", map[ids.ShortID]uint64{
+		testShortIDAddrs[0]: importAmount,
+	})
+	defer func() {
+		require.NoError(vm.Shutdown(context.Background()))
+	}()
+	api := &BlockBuilderAPI{vm}
+	// The API acquires the context lock, which is held by the test.
+	buildPendingBlock := func() (*BuildPendingBlockReply, error) {
+		vm.ctx.Lock.Unlock()
+		defer vm.ctx.Lock.Lock()
+		return api.BuildPendingBlock(context.Background())
+	}
+
+	// Nothing to build.
+	reply, err := buildPendingBlock()
+	require.NoError(err)
+	require.Equal(uint64(1), reply.Number.ToInt().Uint64())
+	require.Contains(reply.Error, errEmptyBlock.Error())
+	require.Empty(reply.Transactions)
+	require.Empty(reply.AtomicTxs)
+
+	importTx, err := vm.newImportTx(vm.ctx.XChainID, testEthAddrs[0], initialBaseFee, []*secp256k1.PrivateKey{testKeys[0]})
+	require.NoError(err)
+	require.NoError(vm.mempool.AddLocalTx(importTx))
+	<-issuer
+
+	reply, err = buildPendingBlock()
+	require.NoError(err)
+	require.Empty(reply.Error)
+	require.Equal([]ids.ID{importTx.ID()}, reply.AtomicTxs)
+	require.NotNil(reply.BaseFee)
+	require.NotNil(reply.BlockGasCost)
+	require.NotZero(reply.ExtDataGasUsed.ToInt().Sign())
+
+	// The dry run leaves the atomic transaction in the mempool to be built.
+	require.True(vm.mempool.Has(importTx.ID()))
+	blk, err := vm.BuildBlock(context.Background())
+	require.NoError(err)
+	require.NoError(blk.Verify(context.Background()))
+	require.Equal(reply.Number.ToInt().Uint64(), blk.Height())
+}
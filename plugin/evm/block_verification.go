@@ -29,11 +29,13 @@ type BlockValidator interface {
 
 type blockValidator struct {
 	extDataHashes map[common.Hash]common.Hash
+	allowBlobs    bool
 }
 
-func NewBlockValidator(extDataHashes map[common.Hash]common.Hash) BlockValidator {
+func NewBlockValidator(extDataHashes map[common.Hash]common.Hash, allowBlobs bool) BlockValidator {
 	return &blockValidator{
 		extDataHashes: extDataHashes,
+		allowBlobs:    allowBlobs,
 	}
 }
 
@@ -289,7 +291,7 @@ func (v blockValidator) SyntacticVerify(b *Block, rules params.Rules) error {
 		}
 		if ethHeader.BlobGasUsed == nil {
 			return fmt.Errorf("blob gas used must not be nil in Cancun")
-		} else if *ethHeader.BlobGasUsed > 0 {
+		} else if *ethHeader.BlobGasUsed > 0 && !v.allowBlobs {
 			return fmt.Errorf("blobs not enabled on avalanche networks: used %d blob gas, expected 0", *ethHeader.BlobGasUsed)
 		}
 	}
@@ -17,6 +17,8 @@ import (
 	"github.com/ava-labs/avalanchego/utils/formatting/address"
 	"github.com/ava-labs/avalanchego/utils/json"
 	"github.com/ava-labs/avalanchego/utils/rpc"
+
+	"github.com/ava-labs/coreth/peer"
 )
 
 // Interface compliance
@@ -39,6 +41,15 @@ type Client interface {
 	LockProfile(ctx context.Context, options ...rpc.Option) error
 	SetLogLevel(ctx context.Context, level slog.Level, options ...rpc.Option) error
 	GetVMConfig(ctx context.Context, options ...rpc.Option) (*Config, error)
+	SimulatePrecompileUpgrade(ctx context.Context, upgradeBytes []byte, options ...rpc.Option) (*SimulatePrecompileUpgradeReply, error)
+	ExportSyncSnapshot(ctx context.Context, path string, height uint64, options ...rpc.Option) (*ExportSyncSnapshotReply, error)
+	StartStateBackfill(ctx context.Context, snapshotFile string, options ...rpc.Option) (*StateBackfillProgress, error)
+	StateBackfillProgress(ctx context.Context, options ...rpc.Option) (*StateBackfillProgress, error)
+	GetPeerScores(ctx context.Context, options ...rpc.Option) ([]peer.PeerScore, error)
+	GetCodeSyncQueue(ctx context.Context, limit uint32, options ...rpc.Option) (*CodeSyncQueueReply, error)
+	VerifyCode(ctx context.Context, codeHashes []common.Hash, refetch bool, options ...rpc.Option) (*VerifyCodeReply, error)
+	RefetchCode(ctx context.Context, codeHashes []common.Hash, options ...rpc.Option) (uint64, error)
+	CheckCodeConsistency(ctx context.Context, refetch bool, options ...rpc.Option) (*CheckCodeConsistencyReply, error)
 }
 
 // Client implementation for interacting with EVM [chain]
@@ -234,3 +245,82 @@ func (c *client) GetVMConfig(ctx context.Context, options ...rpc.Option) (*Confi
 	err := c.adminRequester.SendRequest(ctx, "admin.getVMConfig", struct{}{}, res, options...)
 	return res.Config, err
 }
+
+// SimulatePrecompileUpgrade checks [upgradeBytes] against the current chain config
+// and last accepted block and returns the resulting precompile schedule
+func (c *client) SimulatePrecompileUpgrade(ctx context.Context, upgradeBytes []byte, options ...rpc.Option) (*SimulatePrecompileUpgradeReply, error) {
+	res := &SimulatePrecompileUpgradeReply{}
+	err := c.adminRequester.SendRequest(ctx, "admin.simulatePrecompileUpgrade", &SimulatePrecompileUpgradeArgs{
+		Upgrades: upgradeBytes,
+	}, res, options...)
+	return res, err
+}
+
+// ExportSyncSnapshot writes the state at the syncable summary at [height], or
+// the last syncable summary if [height] is zero, to the file at [path] on the node
+func (c *client) ExportSyncSnapshot(ctx context.Context, path string, height uint64, options ...rpc.Option) (*ExportSyncSnapshotReply, error) {
+	res := &ExportSyncSnapshotReply{}
+	err := c.adminRequester.SendRequest(ctx, "admin.exportSyncSnapshot", &ExportSyncSnapshotArgs{
+		Path:   path,
+		Height: json.Uint64(height),
+	}, res, options...)
+	return res, err
+}
+
+// StartStateBackfill starts reconstructing the historical state below the height
+// the node state synced to, re-executing from the state in the sync snapshot
+// file at [snapshotFile] on the node, or from genesis if it is empty
+func (c *client) StartStateBackfill(ctx context.Context, snapshotFile string, options ...rpc.Option) (*StateBackfillProgress, error) {
+	res := &StateBackfillProgress{}
+	err := c.adminRequester.SendRequest(ctx, "admin.startStateBackfill", &StartStateBackfillArgs{
+		SnapshotFile: snapshotFile,
+	}, res, options...)
+	return res, err
+}
+
+// StateBackfillProgress returns the progress of the most recently started state backfill
+func (c *client) StateBackfillProgress(ctx context.Context, options ...rpc.Option) (*StateBackfillProgress, error) {
+	res := &StateBackfillProgress{}
+	err := c.adminRequester.SendRequest(ctx, "admin.stateBackfillProgress", struct{}{}, res, options...)
+	return res, err
+}
+
+// GetPeerScores returns the scores of the peers the node is connected to
+func (c *client) GetPeerScores(ctx context.Context, options ...rpc.Option) ([]peer.PeerScore, error) {
+	res := &PeerScoresReply{}
+	err := c.adminRequester.SendRequest(ctx, "admin.getPeerScores", struct{}{}, res, options...)
+	return res.Peers, err
+}
+
+func (c *client) GetCodeSyncQueue(ctx context.Context, limit uint32, options ...rpc.Option) (*CodeSyncQueueReply, error) {
+	res := &CodeSyncQueueReply{}
+	err := c.adminRequester.SendRequest(ctx, "admin.getCodeSyncQueue", &GetCodeSyncQueueArgs{
+		Limit: json.Uint32(limit),
+	}, res, options...)
+	return res, err
+}
+
+func (c *client) VerifyCode(ctx context.Context, codeHashes []common.Hash, refetch bool, options ...rpc.Option) (*VerifyCodeReply, error) {
+	res := &VerifyCodeReply{}
+	err := c.adminRequester.SendRequest(ctx, "admin.verifyCode", &VerifyCodeArgs{
+		CodeHashes: codeHashes,
+		Refetch:    refetch,
+	}, res, options...)
+	return res, err
+}
+
+func (c *client) RefetchCode(ctx context.Context, codeHashes []common.Hash, options ...rpc.Option) (uint64, error) {
+	res := &RefetchCodeReply{}
+	err := c.adminRequester.SendRequest(ctx, "admin.refetchCode", &RefetchCodeArgs{
+		CodeHashes: codeHashes,
+	}, res, options...)
+	return uint64(res.Refetched), err
+}
+
+func (c *client) CheckCodeConsistency(ctx context.Context, refetch bool, options ...rpc.Option) (*CheckCodeConsistencyReply, error) {
+	res := &CheckCodeConsistencyReply{}
+	err := c.adminRequester.SendRequest(ctx, "admin.checkCodeConsistency", &CheckCodeConsistencyArgs{
+		Refetch: refetch,
+	}, res, options...)
+	return res, err
+}
@@ -8,8 +8,10 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/ava-labs/avalanchego/utils/compression"
 	"github.com/ava-labs/coreth/core/txpool/legacypool"
 	"github.com/ava-labs/coreth/eth"
+	"github.com/ava-labs/coreth/miner"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/spf13/cast"
@@ -60,6 +62,15 @@ const (
 	// - state sync time: ~6 hrs.
 	defaultStateSyncMinBlocks   = 300_000
 	defaultStateSyncRequestSize = 1024 // the number of key/values to ask peers for per request
+	// the number of contiguous requests of key/values proven by a single range
+	// proof, when syncing from peers that support it. Compact leafs requests
+	// are disabled by default.
+	defaultStateSyncCompactLeafsBatch = 0
+	// the compression of the responses requested from peers that support it.
+	// Compression is disabled by default.
+	defaultStateSyncCompression         = "none"
+	defaultStateSyncAdaptiveRequestSize = true
+	defaultStateSyncVerifyRepair        = true
 )
 
 var (
@@ -90,9 +101,20 @@ type Config struct {
 	CorethAdminAPIEnabled bool   `json:"coreth-admin-api-enabled"` // Deprecated: use AdminAPIEnabled instead
 	CorethAdminAPIDir     string `json:"coreth-admin-api-dir"`     // Deprecated: use AdminAPIDir instead
 	WarpAPIEnabled        bool   `json:"warp-api-enabled"`
+	// BlockBuilderAPIEnabled enables debug_buildPendingBlock, which builds the
+	// next block without issuing it.
+	BlockBuilderAPIEnabled bool `json:"block-builder-api-enabled"`
+	// StateSyncAPIEnabled enables the statesync namespace, which reports the
+	// progress of state sync and of the state backfill.
+	StateSyncAPIEnabled bool `json:"state-sync-api-enabled"`
 
 	// EnabledEthAPIs is a list of Ethereum services that should be enabled
 	// If none is specified, then we use the default list [defaultEnabledAPIs]
+	// The services are "eth", "eth-filter", "net", "web3", "admin", "debug",
+	// "internal-eth", "internal-blockchain", "internal-transaction",
+	// "internal-tx-pool", "internal-debug", "internal-account",
+	// "internal-personal", "tx-pool-drops", which serves txpool_getDropReason
+	// and txpool_recentDrops, and "eth-bundle", which serves eth_sendBundle.
 	EnabledEthAPIs []string `json:"eth-apis"`
 
 	// Continuous Profiler
@@ -111,6 +133,11 @@ type Config struct {
 	TriePrefetcherParallelism int `json:"trie-prefetcher-parallelism"` // Max concurrent disk reads trie prefetcher should perform at once
 	SnapshotCache             int `json:"snapshot-cache"`              // Size of the snapshot disk layer clean cache (MB)
 
+	// TriePrefetcherAccessLists warms the accounts and storage slots accessed by
+	// the transactions of a block (senders, recipients and access lists)
+	// concurrently before verifying it, bounded by TriePrefetcherParallelism.
+	TriePrefetcherAccessLists bool `json:"trie-prefetcher-access-lists"`
+
 	// Eth Settings
 	Preimages      bool `json:"preimages-enabled"`
 	SnapshotWait   bool `json:"snapshot-wait"`
@@ -139,6 +166,15 @@ type Config struct {
 	TxPoolGlobalQueue  uint64   `json:"tx-pool-global-queue"`
 	TxPoolLifetime     Duration `json:"tx-pool-lifetime"`
 
+	// TxPoolJournalEnabled persists the local transactions of the transaction
+	// pool to the chain data directory, to be reloaded and gossiped on restart.
+	// Local transactions require LocalTxsEnabled.
+	TxPoolJournalEnabled bool `json:"tx-pool-journal-enabled"`
+	// TxPoolJournalRemotes additionally persists remote transactions, such as
+	// transactions issued over the RPC while LocalTxsEnabled is off.
+	TxPoolJournalRemotes bool     `json:"tx-pool-journal-remotes"`
+	TxPoolRejournal      Duration `json:"tx-pool-rejournal"` // Interval at which the journals are regenerated
+
 	APIMaxDuration           Duration      `json:"api-max-duration"`
 	WSCPURefillRate          Duration      `json:"ws-cpu-refill-rate"`
 	WSCPUMaxStored           Duration      `json:"ws-cpu-max-stored"`
@@ -180,9 +216,38 @@ type Config struct {
 	StateSyncSkipResume      bool   `json:"state-sync-skip-resume"` // Forces state sync to use the highest available summary block
 	StateSyncServerTrieCache int    `json:"state-sync-server-trie-cache"`
 	StateSyncIDs             string `json:"state-sync-ids"`
-	StateSyncCommitInterval  uint64 `json:"state-sync-commit-interval"`
+	StateSyncCommitInterval  uint64 `json:"state-sync-commit-interval"` // Interval of the heights of the state summaries served, a multiple of CommitInterval
 	StateSyncMinBlocks       uint64 `json:"state-sync-min-blocks"`
 	StateSyncRequestSize     uint16 `json:"state-sync-request-size"`
+	StateSyncSnapshotFile    string `json:"state-sync-snapshot-file"` // Sync snapshot file to state sync from if its summary is accepted
+	// StateSyncPinnedSummaryHeights are heights, multiples of CommitInterval,
+	// that state summaries are served for in addition to the multiples of
+	// StateSyncCommitInterval.
+	StateSyncPinnedSummaryHeights []uint64 `json:"state-sync-pinned-summary-heights"`
+	// StateSyncCompactLeafsBatch is the number of contiguous requests of
+	// key/values fetched from a peer with a single range proof, if the peer
+	// supports compact leafs requests. Values below 2 disable them.
+	StateSyncCompactLeafsBatch int `json:"state-sync-compact-leafs-batch"`
+	// StateSyncCompression is the compression of the responses requested from
+	// peers ("zstd" or "none"). Compression is only requested from peers whose
+	// version serves compressed responses. It is disabled for a peer that
+	// serves a failed compressed request again without compression, or after
+	// repeated responses that could not be decompressed.
+	StateSyncCompression string `json:"state-sync-compression"`
+	// StateSyncAdaptiveRequestSize adapts the number of key/values requested
+	// from each peer, up to StateSyncRequestSize, to its latency and response
+	// sizes.
+	StateSyncAdaptiveRequestSize bool `json:"state-sync-adaptive-request-size"`
+	// StateSyncVerify compares the snapshot with the synced tries before state
+	// sync finishes, and verifies the synced state in the background once it
+	// finishes: that the account and storage tries are complete and that all
+	// the code is present.
+	StateSyncVerify bool `json:"state-sync-verify"`
+	// StateSyncVerifyRepair repairs the gaps found by StateSyncVerify,
+	// fetching the broken ranges of the tries and the invalid code from peers,
+	// and regenerating a snapshot that differs from the tries. Otherwise they
+	// are only reported.
+	StateSyncVerifyRepair bool `json:"state-sync-verify-repair"`
 
 	// Database Settings
 	InspectDatabase bool `json:"inspect-database"` // Inspects the database on startup if enabled.
@@ -213,6 +278,33 @@ type Config struct {
 	// TxLookupLimit can be still used to control unindexing old transactions.
 	SkipTxIndexing bool `json:"skip-tx-indexing"`
 
+	// AssetTransferIndexing indexes multi-coin transfers by address and asset ID
+	// so they can be served by eth_getAssetTransfers. Only blocks accepted while
+	// the index is enabled are indexed.
+	AssetTransferIndexing bool `json:"asset-transfer-indexing"`
+
+	// OpcodeStatsEnabled collects the number of executions and the gas used per
+	// opcode across accepted blocks. The statistics are reported as metrics and
+	// served by debug_opcodeStats.
+	OpcodeStatsEnabled bool `json:"opcode-stats-enabled"`
+
+	// ParallelTxExecutionWorkers enables the experimental optimistic parallel
+	// execution of block transactions with the given number of workers.
+	// Transactions are executed sequentially if it is less than 2.
+	ParallelTxExecutionWorkers int `json:"parallel-tx-execution-workers"`
+
+	// BlobPoolEnabled enables the EIP-4844 blob transaction pool and allows
+	// blocks to include blob transactions. Blob transactions are not supported
+	// on Avalanche networks, so this is only allowed on local test networks.
+	BlobPoolEnabled bool `json:"blob-pool-enabled"`
+
+	// MinerOrdering selects the order in which the block builder includes
+	// pending transactions: "price" (highest tip first), "fcfs" (first come
+	// first served) or "priority" (transactions sent by
+	// MinerPriorityAddresses first, then by price).
+	MinerOrdering          string           `json:"miner-ordering"`
+	MinerPriorityAddresses []common.Address `json:"miner-priority-addresses"`
+
 	// WarpOffChainMessages encodes off-chain messages (unrelated to any on-chain event ie. block or AddressedCall)
 	// that the node should be willing to sign.
 	// Note: only supports AddressedCall payloads as defined here:
@@ -245,6 +337,7 @@ func (c *Config) SetDefaults() {
 	c.TxPoolAccountQueue = legacypool.DefaultConfig.AccountQueue
 	c.TxPoolGlobalQueue = legacypool.DefaultConfig.GlobalQueue
 	c.TxPoolLifetime.Duration = legacypool.DefaultConfig.Lifetime
+	c.TxPoolRejournal.Duration = legacypool.DefaultConfig.Rejournal
 
 	c.APIMaxDuration.Duration = defaultApiMaxDuration
 	c.WSCPURefillRate.Duration = defaultWsCpuRefillRate
@@ -278,8 +371,13 @@ func (c *Config) SetDefaults() {
 	c.StateSyncCommitInterval = defaultSyncableCommitInterval
 	c.StateSyncMinBlocks = defaultStateSyncMinBlocks
 	c.StateSyncRequestSize = defaultStateSyncRequestSize
+	c.StateSyncCompactLeafsBatch = defaultStateSyncCompactLeafsBatch
+	c.StateSyncCompression = defaultStateSyncCompression
+	c.StateSyncAdaptiveRequestSize = defaultStateSyncAdaptiveRequestSize
+	c.StateSyncVerifyRepair = defaultStateSyncVerifyRepair
 	c.AllowUnprotectedTxHashes = defaultAllowUnprotectedTxHashes
 	c.AcceptedCacheSize = defaultAcceptedCacheSize
+	c.MinerOrdering = miner.OrderingPriceAndNonce
 }
 
 func (d *Duration) UnmarshalJSON(data []byte) (err error) {
@@ -318,6 +416,31 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("cannot use commit interval of 0 with pruning enabled")
 	}
 
+	// State summaries are only served at heights where the state and atomic
+	// tries are committed.
+	if c.StateSyncCommitInterval == 0 {
+		return fmt.Errorf("cannot use state sync commit interval of 0")
+	}
+	if c.CommitInterval != 0 && c.StateSyncCommitInterval%c.CommitInterval != 0 {
+		return fmt.Errorf("state sync commit interval (%d) must be a multiple of commit interval (%d)", c.StateSyncCommitInterval, c.CommitInterval)
+	}
+	for _, height := range c.StateSyncPinnedSummaryHeights {
+		if height == 0 || (c.CommitInterval != 0 && height%c.CommitInterval != 0) {
+			return fmt.Errorf("pinned summary height %d must be a non-zero multiple of commit interval (%d)", height, c.CommitInterval)
+		}
+	}
+	if c.OfflinePruning && len(c.StateSyncPinnedSummaryHeights) > 0 {
+		return fmt.Errorf("cannot run offline pruning with pinned summary heights, as it deletes their state")
+	}
+
+	if _, err := miner.NewOrderingPolicy(c.MinerOrdering, c.MinerPriorityAddresses); err != nil {
+		return fmt.Errorf("invalid miner-ordering: %w", err)
+	}
+
+	if _, err := compression.TypeFromString(c.StateSyncCompression); err != nil {
+		return fmt.Errorf("invalid state-sync-compression %q: %w", c.StateSyncCompression, err)
+	}
+
 	if c.PushGossipPercentStake < 0 || c.PushGossipPercentStake > 1 {
 		return fmt.Errorf("push-gossip-percent-stake is %f but must be in the range [0, 1]", c.PushGossipPercentStake)
 	}
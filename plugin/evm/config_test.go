@@ -77,6 +77,12 @@ func TestUnmarshalConfig(t *testing.T) {
 			Config{StateSyncIDs: "NodeID-CaBYJ9kzHvrQFiYWowMkJGAQKGMJqZoat"},
 			false,
 		},
+		{
+			"state sync pinned summary heights",
+			[]byte(`{"state-sync-commit-interval": 65536, "state-sync-pinned-summary-heights": [4096, 8192]}`),
+			Config{StateSyncCommitInterval: 65536, StateSyncPinnedSummaryHeights: []uint64{4096, 8192}},
+			false,
+		},
 		{
 			"empty transaction history ",
 			[]byte(`{}`),
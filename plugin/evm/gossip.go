@@ -162,6 +162,10 @@ func (g *GossipEthTxPool) Subscribe(ctx context.Context) {
 			g.lock.Lock()
 			optimalElements := (g.mempool.PendingSize(txpool.PendingFilter{}) + len(pendingTxs.Txs)) * txGossipBloomChurnMultiplier
 			for _, pendingTx := range pendingTxs.Txs {
+				// Private transactions are not advertised to peers.
+				if g.mempool.IsPrivate(pendingTx.Hash()) {
+					continue
+				}
 				tx := &GossipEthTx{Tx: pendingTx}
 				g.bloom.Add(tx)
 				reset, err := gossip.ResetBloomFilterIfNeeded(g.bloom, optimalElements)
@@ -173,7 +177,7 @@ func (g *GossipEthTxPool) Subscribe(ctx context.Context) {
 				if reset {
 					log.Debug("resetting bloom filter", "reason", "reached max filled ratio")
 
-					g.mempool.IteratePending(func(tx *types.Transaction) bool {
+					g.mempool.IteratePublicPending(func(tx *types.Transaction) bool {
 						g.bloom.Add(&GossipEthTx{Tx: tx})
 						return true
 					})
@@ -196,8 +200,10 @@ func (g *GossipEthTxPool) Has(txID ids.ID) bool {
 	return g.mempool.Has(ethcommon.Hash(txID))
 }
 
+// Iterate calls [f] on the pending transactions, excluding the private ones
+// which must not be gossiped.
 func (g *GossipEthTxPool) Iterate(f func(tx *GossipEthTx) bool) {
-	g.mempool.IteratePending(func(tx *types.Transaction) bool {
+	g.mempool.IteratePublicPending(func(tx *types.Transaction) bool {
 		return f(&GossipEthTx{Tx: tx})
 	})
 }
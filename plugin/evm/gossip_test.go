@@ -198,6 +198,34 @@ func TestGossipSubscribe(t *testing.T) {
 	)
 }
 
+func TestGossipIterateExcludesPrivate(t *testing.T) {
+	require := require.New(t)
+	key, err := crypto.GenerateKey()
+	require.NoError(err)
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	txPool := setupPoolWithConfig(t, params.TestChainConfig, addr)
+	defer txPool.Close()
+	txPool.SetGasTip(common.Big1)
+	txPool.SetMinFee(common.Big0)
+
+	gossipTxPool, err := NewGossipEthTxPool(txPool, prometheus.NewRegistry())
+	require.NoError(err)
+
+	ethTxs := getValidEthTxs(key, 2, big.NewInt(226*params.GWei))
+	require.NoError(txPool.AddPrivate(ethTxs[0], 10))
+	for _, err := range txPool.AddRemotesSync(ethTxs[1:]) {
+		require.NoError(err)
+	}
+
+	var iterated []common.Hash
+	gossipTxPool.Iterate(func(tx *GossipEthTx) bool {
+		iterated = append(iterated, tx.Tx.Hash())
+		return true
+	})
+	require.Equal([]common.Hash{ethTxs[1].Hash()}, iterated)
+}
+
 func setupPoolWithConfig(t *testing.T, config *params.ChainConfig, fundedAddress common.Address) *txpool.TxPool {
 	diskdb := rawdb.NewMemoryDatabase()
 	engine := dummy.NewETHFaker()
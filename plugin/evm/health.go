@@ -3,12 +3,54 @@
 
 package evm
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+)
 
 // Health returns nil if this chain is healthy.
 // Also returns details, which should be one of:
 // string, []byte, map[string]string
+// If state sync or a state backfill has started, the details include its
+// progress. The chain is unhealthy if state sync failed, while the synced
+// state is verified for the first time, if the latest verification of the
+// synced state failed or found gaps that were not repaired, or if the snapshot
+// did not match the synced state and was not regenerated.
 func (vm *VM) HealthCheck(context.Context) (interface{}, error) {
-	// TODO perform actual health check
-	return nil, nil
+	if vm.StateSyncClient == nil {
+		return nil, nil
+	}
+	details := map[string]interface{}{}
+	if backfill := vm.stateBackfillProgress(); backfill.Stage != stateBackfillStageIdle {
+		details["stateBackfill"] = backfill
+	}
+	progress := vm.StateSyncClient.Progress()
+	if progress.Stage != stateSyncStageIdle {
+		details["stateSync"] = progress
+	}
+	if len(details) == 0 {
+		return nil, nil
+	}
+	if progress.Stage == stateSyncStageFailed {
+		return details, errors.New("state sync failed: " + progress.Error)
+	}
+	if progress.Verifying && progress.VerificationPasses == 0 {
+		return details, errors.New("synced state is being verified")
+	}
+	if progress.VerificationError != "" {
+		return details, errors.New("state verification failed: " + progress.VerificationError)
+	}
+	if progress.UnrepairedGaps > 0 {
+		return details, fmt.Errorf("state verification found %d gaps in the synced state", progress.UnrepairedGaps)
+	}
+	if !progress.SnapshotRegenerated {
+		if progress.SnapshotComparisonError != "" {
+			return details, errors.New("snapshot comparison failed: " + progress.SnapshotComparisonError)
+		}
+		if progress.SnapshotMismatches > 0 {
+			return details, fmt.Errorf("snapshot differs from the synced state in %d entries", progress.SnapshotMismatches)
+		}
+	}
+	return details, nil
 }
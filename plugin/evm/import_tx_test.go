@@ -5,8 +5,10 @@ package evm
 
 import (
 	"math/big"
+	"reflect"
 	"testing"
 
+	"github.com/ava-labs/coreth/core/types"
 	"github.com/ava-labs/coreth/params"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/holiman/uint256"
@@ -1281,6 +1283,75 @@ func TestImportTxEVMStateTransfer(t *testing.T) {
 				}
 			},
 		},
+		"non-AVAX UTXO with asset transfer indexing": {
+			setup: func(t *testing.T, vm *VM, sharedMemory *atomic.Memory) *Tx {
+				txID := ids.GenerateTestID()
+				utxo, err := addUTXO(sharedMemory, vm.ctx, txID, 0, assetID, 1, testShortIDAddrs[0])
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				tx := &Tx{UnsignedAtomicTx: &UnsignedImportTx{
+					NetworkID:    vm.ctx.NetworkID,
+					BlockchainID: vm.ctx.ChainID,
+					SourceChain:  vm.ctx.XChainID,
+					ImportedInputs: []*avax.TransferableInput{{
+						UTXOID: utxo.UTXOID,
+						Asset:  avax.Asset{ID: assetID},
+						In: &secp256k1fx.TransferInput{
+							Amt:   1,
+							Input: secp256k1fx.Input{SigIndices: []uint32{0}},
+						},
+					}},
+					Outs: []EVMOutput{{
+						Address: testEthAddrs[0],
+						Amount:  1,
+						AssetID: assetID,
+					}},
+				}}
+				if err := tx.Sign(vm.codec, [][]*secp256k1.PrivateKey{{testKeys[0]}}); err != nil {
+					t.Fatal(err)
+				}
+				return tx
+			},
+			checkState: func(t *testing.T, vm *VM) {
+				lastAcceptedBlock := vm.LastAcceptedBlockInternal().(*Block)
+
+				sdb, err := vm.blockChain.StateAt(lastAcceptedBlock.ethBlock.Root())
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				assetBalance := sdb.GetBalanceMultiCoin(testEthAddrs[0], common.Hash(assetID))
+				if assetBalance.Cmp(common.Big1) != 0 {
+					t.Fatalf("Expected asset balance to be %d, found balance: %d", common.Big1, assetBalance)
+				}
+				avaxBalance := sdb.GetBalance(testEthAddrs[0])
+				if avaxBalance.Cmp(common.U2560) != 0 {
+					t.Fatalf("Expected AVAX balance to be 0, found balance: %d", avaxBalance)
+				}
+
+				vm.blockChain.DrainAcceptorQueue()
+				transfers, err := vm.blockChain.GetAssetTransfers(testEthAddrs[0], common.Hash(assetID), 0, lastAcceptedBlock.ethBlock.NumberU64())
+				if err != nil {
+					t.Fatal(err)
+				}
+				expected := []*types.AssetTransfer{{
+					Address:     testEthAddrs[0],
+					AssetID:     common.Hash(assetID),
+					Amount:      common.Big1,
+					Incoming:    true,
+					TxHash:      common.Hash(lastAcceptedBlock.atomicTxs[0].ID()),
+					Atomic:      true,
+					BlockNumber: lastAcceptedBlock.ethBlock.NumberU64(),
+					BlockHash:   lastAcceptedBlock.ethBlock.Hash(),
+				}}
+				if !reflect.DeepEqual(expected, transfers) {
+					t.Fatalf("Expected asset transfers %v, found %v", expected, transfers)
+				}
+			},
+			configJSON: `{"asset-transfer-indexing": true}`,
+		},
 	}
 
 	for name, test := range tests {
@@ -45,6 +45,11 @@ func init() {
 		c.RegisterType(BlockSignatureRequest{}),
 		c.RegisterType(SignatureResponse{}),
 
+		// Compact state sync types, appended to keep the type IDs of the
+		// types above.
+		c.RegisterType(CompactLeafsRequest{}),
+		c.RegisterType(CompressedRequest{}),
+
 		Codec.RegisterCodec(Version, c),
 	)
 
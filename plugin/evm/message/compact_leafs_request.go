@@ -0,0 +1,66 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package message
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var _ Request = CompactLeafsRequest{}
+
+// CompactLeafsRequest is a request for a page of trie leaves at Root within the
+// Start and End byte range, like LeafsRequest, that is answered without a range
+// proof unless Proof is set. This lets the server serve pages from its snapshot
+// without proving each of them, and the client fetch several contiguous pages
+// from the same server and verify them with a single range proof.
+//
+// If Proof is set, ProofVals in the LeafsResponse contain the merkle-proofs of
+// ProofStart and of the last key in the response, which prove all the leaves
+// from ProofStart to that key, including those returned in previous pages.
+type CompactLeafsRequest struct {
+	Root       common.Hash `serialize:"true"`
+	Account    common.Hash `serialize:"true"`
+	Start      []byte      `serialize:"true"`
+	End        []byte      `serialize:"true"`
+	Limit      uint16      `serialize:"true"`
+	NodeType   NodeType    `serialize:"true"`
+	Proof      bool        `serialize:"true"`
+	ProofStart []byte      `serialize:"true"`
+}
+
+func (l CompactLeafsRequest) String() string {
+	return fmt.Sprintf(
+		"CompactLeafsRequest(Root=%s, Account=%s, Start=%s, End=%s, Limit=%d, NodeType=%s, Proof=%t, ProofStart=%s)",
+		l.Root, l.Account, common.Bytes2Hex(l.Start), common.Bytes2Hex(l.End), l.Limit, l.NodeType, l.Proof, common.Bytes2Hex(l.ProofStart),
+	)
+}
+
+// LeafsRequest returns the LeafsRequest for the same page of leaves.
+func (l CompactLeafsRequest) LeafsRequest() LeafsRequest {
+	return LeafsRequest{
+		Root:     l.Root,
+		Account:  l.Account,
+		Start:    l.Start,
+		End:      l.End,
+		Limit:    l.Limit,
+		NodeType: l.NodeType,
+	}
+}
+
+func (l CompactLeafsRequest) Handle(ctx context.Context, nodeID ids.NodeID, requestID uint32, handler RequestHandler) ([]byte, error) {
+	switch l.NodeType {
+	case StateTrieNode:
+		return handler.HandleStateTrieCompactLeafsRequest(ctx, nodeID, requestID, l)
+	case AtomicTrieNode:
+		return handler.HandleAtomicTrieCompactLeafsRequest(ctx, nodeID, requestID, l)
+	}
+
+	log.Debug("node type is not recognised, dropping request", "nodeID", nodeID, "requestID", requestID, "nodeType", l.NodeType)
+	return nil, nil
+}
@@ -0,0 +1,46 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package message
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/compression"
+)
+
+var _ Request = CompressedRequest{}
+
+// CompressedRequest is a request for the response to Request, a marshalled
+// BlockRequest, LeafsRequest, CompactLeafsRequest or CodeRequest, compressed
+// with Compression.
+// Servers that do not support Compression drop the request, so the client
+// sends a failed request again without compression.
+type CompressedRequest struct {
+	Compression compression.Type `serialize:"true"`
+	Request     []byte           `serialize:"true"`
+}
+
+func (c CompressedRequest) String() string {
+	return fmt.Sprintf("CompressedRequest(Compression=%s, RequestLen=%d)", c.Compression, len(c.Request))
+}
+
+func (c CompressedRequest) Handle(ctx context.Context, nodeID ids.NodeID, requestID uint32, handler RequestHandler) ([]byte, error) {
+	return handler.HandleCompressedRequest(ctx, nodeID, requestID, c)
+}
+
+// NewCompressor returns the compressor for [compressionType], which
+// decompresses messages of up to the maximum message size, or nil if
+// [compressionType] is [compression.TypeNone].
+func NewCompressor(compressionType compression.Type) (compression.Compressor, error) {
+	switch compressionType {
+	case compression.TypeNone:
+		return nil, nil
+	case compression.TypeZstd:
+		return compression.NewZstdCompressor(maxMessageSize)
+	default:
+		return nil, fmt.Errorf("unsupported compression type %s", compressionType)
+	}
+}
@@ -0,0 +1,63 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package message
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/compression"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMarshalCompressedRequest asserts that the structure or serialization logic hasn't changed, primarily to
+// ensure compatibility with the network.
+func TestMarshalCompressedRequest(t *testing.T) {
+	codeRequestBytes, err := RequestToBytes(Codec, CodeRequest{
+		Hashes: []common.Hash{common.BytesToHash([]byte("some code pls"))},
+	})
+	assert.NoError(t, err)
+	compressedRequest := CompressedRequest{
+		Compression: compression.TypeZstd,
+		Request:     codeRequestBytes,
+	}
+
+	base64CompressedRequest := "AAACAAAAKgAAAAAABwAAAAEAAAAAAAAAAAAAAAAAAAAAAAAAc29tZSBjb2RlIHBscw=="
+
+	compressedRequestBytes, err := Codec.Marshal(Version, compressedRequest)
+	assert.NoError(t, err)
+	assert.Equal(t, base64CompressedRequest, base64.StdEncoding.EncodeToString(compressedRequestBytes))
+
+	var c CompressedRequest
+	_, err = Codec.Unmarshal(compressedRequestBytes, &c)
+	assert.NoError(t, err)
+	assert.Equal(t, compressedRequest, c)
+
+	mockRequestHandler := &mockHandler{}
+	_, _ = c.Handle(context.Background(), ids.GenerateTestNodeID(), 1, mockRequestHandler)
+	assert.True(t, mockRequestHandler.handleCompressedRequestCalled)
+	assert.False(t, mockRequestHandler.handleCodeRequestCalled)
+}
+
+func TestNewCompressor(t *testing.T) {
+	compressor, err := NewCompressor(compression.TypeNone)
+	assert.NoError(t, err)
+	assert.Nil(t, compressor)
+
+	compressor, err = NewCompressor(compression.TypeZstd)
+	assert.NoError(t, err)
+	msg := make([]byte, maxMessageSize)
+	compressed, err := compressor.Compress(msg)
+	assert.NoError(t, err)
+	assert.Less(t, len(compressed), len(msg))
+	decompressed, err := compressor.Decompress(compressed)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, decompressed)
+
+	_, err = NewCompressor(compression.Type(0))
+	assert.Error(t, err)
+}
@@ -42,8 +42,11 @@ func (NoopMempoolGossipHandler) HandleEthTxs(nodeID ids.NodeID, msg EthTxsGossip
 type RequestHandler interface {
 	HandleStateTrieLeafsRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, leafsRequest LeafsRequest) ([]byte, error)
 	HandleAtomicTrieLeafsRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, leafsRequest LeafsRequest) ([]byte, error)
+	HandleStateTrieCompactLeafsRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, leafsRequest CompactLeafsRequest) ([]byte, error)
+	HandleAtomicTrieCompactLeafsRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, leafsRequest CompactLeafsRequest) ([]byte, error)
 	HandleBlockRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, request BlockRequest) ([]byte, error)
 	HandleCodeRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, codeRequest CodeRequest) ([]byte, error)
+	HandleCompressedRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, compressedRequest CompressedRequest) ([]byte, error)
 	HandleMessageSignatureRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, signatureRequest MessageSignatureRequest) ([]byte, error)
 	HandleBlockSignatureRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, signatureRequest BlockSignatureRequest) ([]byte, error)
 }
@@ -67,6 +70,14 @@ func (NoopRequestHandler) HandleAtomicTrieLeafsRequest(ctx context.Context, node
 	return nil, nil
 }
 
+func (NoopRequestHandler) HandleStateTrieCompactLeafsRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, leafsRequest CompactLeafsRequest) ([]byte, error) {
+	return nil, nil
+}
+
+func (NoopRequestHandler) HandleAtomicTrieCompactLeafsRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, leafsRequest CompactLeafsRequest) ([]byte, error) {
+	return nil, nil
+}
+
 func (NoopRequestHandler) HandleBlockRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, request BlockRequest) ([]byte, error) {
 	return nil, nil
 }
@@ -75,6 +86,10 @@ func (NoopRequestHandler) HandleCodeRequest(ctx context.Context, nodeID ids.Node
 	return nil, nil
 }
 
+func (NoopRequestHandler) HandleCompressedRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, compressedRequest CompressedRequest) ([]byte, error) {
+	return nil, nil
+}
+
 func (NoopRequestHandler) HandleMessageSignatureRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, signatureRequest MessageSignatureRequest) ([]byte, error) {
 	return nil, nil
 }
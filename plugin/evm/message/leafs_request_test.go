@@ -178,6 +178,7 @@ type mockHandler struct {
 	handleAtomicTrieCalled,
 	handleBlockRequestCalled,
 	handleCodeRequestCalled,
+	handleCompressedRequestCalled,
 	handleMessageSignatureCalled,
 	handleBlockSignatureCalled bool
 }
@@ -192,6 +193,16 @@ func (m *mockHandler) HandleAtomicTrieLeafsRequest(context.Context, ids.NodeID,
 	return nil, nil
 }
 
+func (m *mockHandler) HandleStateTrieCompactLeafsRequest(context.Context, ids.NodeID, uint32, CompactLeafsRequest) ([]byte, error) {
+	m.handleStateTrieCalled = true
+	return nil, nil
+}
+
+func (m *mockHandler) HandleAtomicTrieCompactLeafsRequest(context.Context, ids.NodeID, uint32, CompactLeafsRequest) ([]byte, error) {
+	m.handleAtomicTrieCalled = true
+	return nil, nil
+}
+
 func (m *mockHandler) HandleBlockRequest(context.Context, ids.NodeID, uint32, BlockRequest) ([]byte, error) {
 	m.handleBlockRequestCalled = true
 	return nil, nil
@@ -202,6 +213,11 @@ func (m *mockHandler) HandleCodeRequest(context.Context, ids.NodeID, uint32, Cod
 	return nil, nil
 }
 
+func (m *mockHandler) HandleCompressedRequest(context.Context, ids.NodeID, uint32, CompressedRequest) ([]byte, error) {
+	m.handleCompressedRequestCalled = true
+	return nil, nil
+}
+
 func (m *mockHandler) HandleMessageSignatureRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, signatureRequest MessageSignatureRequest) ([]byte, error) {
 	m.handleMessageSignatureCalled = true
 	return nil, nil
@@ -216,4 +232,5 @@ func (m *mockHandler) reset() {
 	m.handleAtomicTrieCalled = false
 	m.handleBlockRequestCalled = false
 	m.handleCodeRequestCalled = false
+	m.handleCompressedRequestCalled = false
 }
@@ -16,6 +16,7 @@ import (
 	"github.com/ava-labs/coreth/warp"
 	warpHandlers "github.com/ava-labs/coreth/warp/handlers"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
 )
 
 var _ message.RequestHandler = &networkHandler{}
@@ -26,6 +27,7 @@ type networkHandler struct {
 	blockRequestHandler           *syncHandlers.BlockRequestHandler
 	codeRequestHandler            *syncHandlers.CodeRequestHandler
 	signatureRequestHandler       *warpHandlers.SignatureRequestHandler
+	codec                         codec.Manager
 }
 
 // newNetworkHandler constructs the handler for serving network requests.
@@ -44,6 +46,7 @@ func newNetworkHandler(
 		blockRequestHandler:           syncHandlers.NewBlockRequestHandler(provider, networkCodec, syncStats),
 		codeRequestHandler:            syncHandlers.NewCodeRequestHandler(diskDB, networkCodec, syncStats),
 		signatureRequestHandler:       warpHandlers.NewSignatureRequestHandler(warpBackend, networkCodec),
+		codec:                         networkCodec,
 	}
 }
 
@@ -55,6 +58,14 @@ func (n networkHandler) HandleAtomicTrieLeafsRequest(ctx context.Context, nodeID
 	return n.atomicTrieLeafsRequestHandler.OnLeafsRequest(ctx, nodeID, requestID, leafsRequest)
 }
 
+func (n networkHandler) HandleStateTrieCompactLeafsRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, leafsRequest message.CompactLeafsRequest) ([]byte, error) {
+	return n.stateTrieLeafsRequestHandler.OnCompactLeafsRequest(ctx, nodeID, requestID, leafsRequest)
+}
+
+func (n networkHandler) HandleAtomicTrieCompactLeafsRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, leafsRequest message.CompactLeafsRequest) ([]byte, error) {
+	return n.atomicTrieLeafsRequestHandler.OnCompactLeafsRequest(ctx, nodeID, requestID, leafsRequest)
+}
+
 func (n networkHandler) HandleBlockRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, blockRequest message.BlockRequest) ([]byte, error) {
 	return n.blockRequestHandler.OnBlockRequest(ctx, nodeID, requestID, blockRequest)
 }
@@ -63,6 +74,39 @@ func (n networkHandler) HandleCodeRequest(ctx context.Context, nodeID ids.NodeID
 	return n.codeRequestHandler.OnCodeRequest(ctx, nodeID, requestID, codeRequest)
 }
 
+// HandleCompressedRequest handles the state sync request in [compressedRequest]
+// and compresses the response. Requests for unsupported compression types or
+// for other requests are dropped.
+func (n networkHandler) HandleCompressedRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, compressedRequest message.CompressedRequest) ([]byte, error) {
+	compressor, err := message.NewCompressor(compressedRequest.Compression)
+	if err != nil || compressor == nil {
+		log.Debug("dropping compressed request with unsupported compression", "nodeID", nodeID, "requestID", requestID, "compression", compressedRequest.Compression)
+		return nil, nil
+	}
+	request, err := message.BytesToRequest(n.codec, compressedRequest.Request)
+	if err != nil {
+		log.Debug("failed to unmarshal compressed request", "nodeID", nodeID, "requestID", requestID, "err", err)
+		return nil, nil
+	}
+	switch request.(type) {
+	case message.BlockRequest, message.LeafsRequest, message.CompactLeafsRequest, message.CodeRequest:
+	default:
+		log.Debug("dropping compressed request of unsupported type", "nodeID", nodeID, "requestID", requestID, "request", request)
+		return nil, nil
+	}
+
+	responseBytes, err := request.Handle(ctx, nodeID, requestID, n)
+	if err != nil || len(responseBytes) == 0 {
+		return responseBytes, err
+	}
+	compressedBytes, err := compressor.Compress(responseBytes)
+	if err != nil {
+		log.Warn("failed to compress response", "nodeID", nodeID, "requestID", requestID, "request", request, "err", err)
+		return nil, nil
+	}
+	return compressedBytes, nil
+}
+
 func (n networkHandler) HandleMessageSignatureRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, messageSignatureRequest message.MessageSignatureRequest) ([]byte, error) {
 	return n.signatureRequestHandler.OnMessageSignatureRequest(ctx, nodeID, requestID, messageSignatureRequest)
 }
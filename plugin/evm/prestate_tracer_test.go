@@ -12,20 +12,87 @@ import (
 	"testing"
 	"unicode"
 
+	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/coreth/core"
 	"github.com/ava-labs/coreth/core/rawdb"
 	"github.com/ava-labs/coreth/core/types"
 	"github.com/ava-labs/coreth/core/vm"
 	"github.com/ava-labs/coreth/eth/tracers"
+	"github.com/ava-labs/coreth/params"
 	"github.com/ava-labs/coreth/tests"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPrestateWithDiffModeANTTracer(t *testing.T) {
 	testPrestateDiffTracer("prestateTracer", "prestate_tracer_ant", t)
 }
 
+func TestPrestateWithDiffModeNativeAssetCall(t *testing.T) {
+	require := require.New(t)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(err)
+	var (
+		config    = params.TestApricotPhase2Config
+		from      = crypto.PubkeyToAddress(key.PublicKey)
+		recipient = common.HexToAddress("0x7dc9c9730689ff0b0fd506c67db815f12d90a448")
+		assetID   = common.Hash{0xaa}
+		alloc     = types.GenesisAlloc{from: {Balance: big.NewInt(params.Ether)}}
+		state     = tests.MakePreState(rawdb.NewMemoryDatabase(), alloc, false, rawdb.HashScheme)
+	)
+	defer state.Close()
+	state.StateDB.SetBalanceMultiCoin(from, assetID, big.NewInt(100))
+
+	signer := types.LatestSigner(config)
+	tx, err := types.SignNewTx(key, signer, &types.LegacyTx{
+		Nonce:    0,
+		To:       &vm.NativeAssetCallAddr,
+		Gas:      100_000,
+		GasPrice: big.NewInt(params.ApricotPhase1MinGasPrice),
+		Data:     vm.PackNativeAssetCallInput(recipient, assetID, big.NewInt(40), nil),
+	})
+	require.NoError(err)
+
+	tracer, err := tracers.DefaultDirectory.New("prestateTracer", new(tracers.Context), json.RawMessage(`{"diffMode": true}`))
+	require.NoError(err)
+	context := vm.BlockContext{
+		CanTransfer:       core.CanTransfer,
+		CanTransferMC:     core.CanTransferMC,
+		Transfer:          core.Transfer,
+		TransferMultiCoin: core.TransferMultiCoin,
+		BlockNumber:       big.NewInt(1),
+		Time:              1,
+		Difficulty:        big.NewInt(1),
+		GasLimit:          params.ApricotPhase1GasLimit,
+	}
+	msg, err := core.TransactionToMessage(tx, signer, nil)
+	require.NoError(err)
+	evm := vm.NewEVM(context, core.NewEVMTxContext(msg), state.StateDB, config, vm.Config{Tracer: tracer})
+	res, err := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.Gas())).TransitionDb()
+	require.NoError(err)
+	require.NoError(res.Err)
+
+	result, err := tracer.GetResult()
+	require.NoError(err)
+	type accountResult struct {
+		MultiCoinBalances map[ids.ID]*hexutil.Big `json:"multiCoinBalances"`
+	}
+	var diff struct {
+		Pre  map[common.Address]accountResult `json:"pre"`
+		Post map[common.Address]accountResult `json:"post"`
+	}
+	require.NoError(json.Unmarshal(result, &diff))
+
+	require.Equal(big.NewInt(100), diff.Pre[from].MultiCoinBalances[ids.ID(assetID)].ToInt())
+	require.Zero(diff.Pre[recipient].MultiCoinBalances[ids.ID(assetID)].ToInt().Sign())
+	require.Equal(big.NewInt(60), diff.Post[from].MultiCoinBalances[ids.ID(assetID)].ToInt())
+	require.Equal(big.NewInt(40), diff.Post[recipient].MultiCoinBalances[ids.ID(assetID)].ToInt())
+}
+
 // testPrestateDiffTracer is adapted from the original testPrestateDiffTracer in
 // eth/tracers/internal/tracetest/prestate_test.go.
 func testPrestateDiffTracer(tracerName string, dirPath string, t *testing.T) {
@@ -0,0 +1,422 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/coreth/consensus"
+	"github.com/ava-labs/coreth/consensus/dummy"
+	"github.com/ava-labs/coreth/core"
+	"github.com/ava-labs/coreth/core/rawdb"
+	"github.com/ava-labs/coreth/core/state"
+	"github.com/ava-labs/coreth/core/vm"
+	"github.com/ava-labs/coreth/params"
+	"github.com/ava-labs/coreth/plugin/evm/message"
+	syncclient "github.com/ava-labs/coreth/sync/client"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// Stages of a state backfill, as reported by [StateBackfillProgress].
+const (
+	stateBackfillStageIdle        = "idle"        // The backfill has not started
+	stateBackfillStageStarting    = "starting"    // Determining the trusted state to start from
+	stateBackfillStageImporting   = "importing"   // Importing the trusted state snapshot file
+	stateBackfillStageDownloading = "downloading" // Fetching the blocks below the state synced height
+	stateBackfillStageExecuting   = "executing"   // Re-executing the blocks to reconstruct their state
+	stateBackfillStageDone        = "done"
+	stateBackfillStageFailed      = "failed"
+
+	// backfillParentsPerRequest is the number of blocks requested from peers at
+	// a time.
+	backfillParentsPerRequest = 32
+	// backfillLogInterval is the time between progress logs.
+	backfillLogInterval = time.Minute
+)
+
+var (
+	errBackfillRunning         = errors.New("state backfill is already running")
+	errBackfillNotSynced       = errors.New("node did not state sync, all historical state is available")
+	errBackfillNoGenesis       = errors.New("genesis state is not available")
+	errBackfillNotBootstrapped = errors.New("state backfill requires the node to be bootstrapped")
+)
+
+// StateBackfillProgress is the progress of reconstructing the historical state
+// below the height the node state synced to.
+type StateBackfillProgress struct {
+	Stage string `json:"stage"`
+	// StartHeight is the height of the state re-execution starts from.
+	StartHeight uint64 `json:"startHeight"`
+	// TargetHeight is the height the node state synced to, whose state and
+	// the state of later blocks is already available.
+	TargetHeight uint64 `json:"targetHeight"`
+	// LowestBlock is the lowest block available below [TargetHeight].
+	LowestBlock      uint64 `json:"lowestBlock"`
+	BlocksDownloaded uint64 `json:"blocksDownloaded"`
+	// ExecutedHeight is the height of the last block whose state was
+	// reconstructed.
+	ExecutedHeight  uint64  `json:"executedHeight"`
+	BlocksPerSecond float64 `json:"blocksPerSecond"`
+	// ETASeconds is the estimated time remaining to re-execute the remaining
+	// blocks, zero until execution starts.
+	ETASeconds float64 `json:"etaSeconds"`
+	Error      string  `json:"error,omitempty"`
+}
+
+type stateBackfillConfig struct {
+	chain       *core.BlockChain
+	chaindb     ethdb.Database
+	chainConfig *params.ChainConfig
+	// engine re-executes accepted blocks without verifying their atomic
+	// transactions against, or adding them to, the atomic backend.
+	engine consensus.Engine
+	// client fetches the blocks below the state synced height from peers.
+	client syncclient.Client
+	// snapshotFile is a sync snapshot file holding the trusted state at the
+	// height to start re-executing from. If empty, re-execution starts from
+	// genesis.
+	snapshotFile string
+}
+
+// stateBackfiller reconstructs the historical state of a node that state
+// synced, by fetching the blocks below the state synced height and
+// re-executing them from a trusted state, so that the node serves historical
+// state like an archive node. Progress is persisted so that a backfill
+// interrupted by a restart resumes where it stopped when started again.
+type stateBackfiller struct {
+	stateBackfillConfig
+
+	lock     sync.Mutex
+	progress StateBackfillProgress
+	// execStart and execStartHeight are the time and height execution
+	// started at, used to estimate the time remaining.
+	execStart       time.Time
+	execStartHeight uint64
+}
+
+// Running returns whether the backfill has started and not yet ended.
+func (p StateBackfillProgress) Running() bool {
+	switch p.Stage {
+	case stateBackfillStageIdle, stateBackfillStageDone, stateBackfillStageFailed:
+		return false
+	default:
+		return true
+	}
+}
+
+func newStateBackfiller(config stateBackfillConfig) *stateBackfiller {
+	return &stateBackfiller{
+		stateBackfillConfig: config,
+		progress:            StateBackfillProgress{Stage: stateBackfillStageStarting},
+	}
+}
+
+// Progress returns the progress of the backfill.
+func (b *stateBackfiller) Progress() StateBackfillProgress {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	p := b.progress
+	if p.Stage == stateBackfillStageExecuting && p.ExecutedHeight > b.execStartHeight {
+		elapsed := time.Since(b.execStart).Seconds()
+		executed := float64(p.ExecutedHeight - b.execStartHeight)
+		p.BlocksPerSecond = executed / elapsed
+		p.ETASeconds = float64(p.TargetHeight-1-p.ExecutedHeight) / p.BlocksPerSecond
+	}
+	return p
+}
+
+func (b *stateBackfiller) update(fn func(p *StateBackfillProgress)) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	fn(&b.progress)
+}
+
+// run performs the backfill, blocking until it completes, fails or [ctx] is
+// cancelled.
+func (b *stateBackfiller) run(ctx context.Context) error {
+	err := b.backfill(ctx)
+	b.update(func(p *StateBackfillProgress) {
+		if err != nil {
+			p.Stage = stateBackfillStageFailed
+			p.Error = err.Error()
+		} else {
+			p.Stage = stateBackfillStageDone
+		}
+	})
+	log.Info("state backfill finished", "err", err)
+	return err
+}
+
+func (b *stateBackfiller) backfill(ctx context.Context) error {
+	target := rawdb.GetLatestSyncPerformed(b.chaindb)
+	if target == 0 {
+		return errBackfillNotSynced
+	}
+	targetHash := rawdb.ReadCanonicalHash(b.chaindb, target)
+	if targetHash == (common.Hash{}) {
+		return fmt.Errorf("state synced block %d not found", target)
+	}
+
+	// Determine the trusted state to start from: the state reconstructed by
+	// a previous run, the state snapshot file, or genesis.
+	var (
+		start     uint64
+		startHash common.Hash
+		startRoot common.Hash
+	)
+	if b.snapshotFile != "" {
+		b.update(func(p *StateBackfillProgress) { p.Stage = stateBackfillStageImporting })
+		summary, err := b.importSnapshotFile(ctx, target)
+		if err != nil {
+			return err
+		}
+		start, startHash, startRoot = summary.BlockNumber, summary.BlockHash, summary.BlockRoot
+	} else {
+		genesis := b.chain.Genesis()
+		start, startHash, startRoot = 0, genesis.Hash(), genesis.Root()
+		if !b.chain.HasState(startRoot) {
+			return errBackfillNoGenesis
+		}
+	}
+	b.update(func(p *StateBackfillProgress) {
+		p.Stage = stateBackfillStageDownloading
+		p.StartHeight = start
+		p.TargetHeight = target
+	})
+	log.Info("state backfill starting", "start", start, "target", target)
+
+	if err := b.downloadBlocks(ctx, targetHash, target, start); err != nil {
+		return err
+	}
+	if hash := rawdb.ReadCanonicalHash(b.chaindb, start); hash != startHash {
+		return fmt.Errorf("trusted state block %d (%s) is not canonical (%s)", start, startHash, hash)
+	}
+	if header := rawdb.ReadHeader(b.chaindb, startHash, start); header == nil || header.Root != startRoot {
+		return fmt.Errorf("trusted state root %s does not match block %d", startRoot, start)
+	}
+
+	// Resume from the last block whose state was reconstructed.
+	height, ok, err := rawdb.ReadStateBackfillHeight(b.chaindb)
+	if err != nil {
+		return err
+	}
+	if ok && height > start && height < target {
+		if header := b.chain.GetHeaderByNumber(height); header != nil && b.chain.HasState(header.Root) {
+			start = height
+		}
+	}
+	return b.execute(ctx, start, target)
+}
+
+// importSnapshotFile writes the state of [b.snapshotFile] to the database and
+// returns the summary it holds, which must be below [target].
+func (b *stateBackfiller) importSnapshotFile(ctx context.Context, target uint64) (message.SyncSummary, error) {
+	sr, err := openSyncSnapshot(b.snapshotFile)
+	if err != nil {
+		return message.SyncSummary{}, fmt.Errorf("failed to open state snapshot file: %w", err)
+	}
+	defer sr.Close()
+
+	summary, err := sr.summary(nil)
+	if err != nil {
+		return message.SyncSummary{}, fmt.Errorf("failed to parse state snapshot summary: %w", err)
+	}
+	if summary.BlockNumber >= target {
+		return message.SyncSummary{}, fmt.Errorf("state snapshot height %d is not below the state synced height %d", summary.BlockNumber, target)
+	}
+	if b.chain.HasState(summary.BlockRoot) {
+		return summary, nil
+	}
+	log.Info("state backfill: importing state snapshot file", "file", b.snapshotFile, "summary", summary)
+	if err := sr.importTo(ctx, b.chaindb); err != nil {
+		return message.SyncSummary{}, fmt.Errorf("failed to import state snapshot file: %w", err)
+	}
+	if !b.chain.HasState(summary.BlockRoot) {
+		return message.SyncSummary{}, fmt.Errorf("state snapshot file does not hold the state root %s", summary.BlockRoot)
+	}
+	return summary, nil
+}
+
+// downloadBlocks fetches the ancestors of the block [hash] at [height] down to
+// [start] that are not available on disk, and writes them as canonical.
+func (b *stateBackfiller) downloadBlocks(ctx context.Context, hash common.Hash, height uint64, start uint64) error {
+	lastLog := time.Now()
+	for {
+		// Skip the blocks already on disk, which includes the parents fetched
+		// by state sync, the blocks fetched by a previous run and genesis.
+		// Blocks imported from a state snapshot file are not yet marked
+		// canonical.
+		for {
+			block := rawdb.ReadBlock(b.chaindb, hash, height)
+			if block == nil {
+				break
+			}
+			if rawdb.ReadCanonicalHash(b.chaindb, height) != hash {
+				rawdb.WriteCanonicalHash(b.chaindb, hash, height)
+			}
+			if height == start {
+				b.update(func(p *StateBackfillProgress) { p.LowestBlock = height })
+				return nil
+			}
+			hash, height = block.ParentHash(), height-1
+		}
+		b.update(func(p *StateBackfillProgress) { p.LowestBlock = height + 1 })
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		parents := uint16(backfillParentsPerRequest)
+		if remaining := height - start + 1; remaining < uint64(parents) {
+			parents = uint16(remaining)
+		}
+		blocks, err := b.client.GetBlocks(ctx, hash, height, parents)
+		if err != nil {
+			return fmt.Errorf("failed to fetch blocks below %d: %w", height+1, err)
+		}
+		batch := b.chaindb.NewBatch()
+		for _, block := range blocks {
+			rawdb.WriteBlock(batch, block)
+			rawdb.WriteCanonicalHash(batch, block.Hash(), block.NumberU64())
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		b.update(func(p *StateBackfillProgress) { p.BlocksDownloaded += uint64(len(blocks)) })
+		if time.Since(lastLog) > backfillLogInterval {
+			log.Info("state backfill: fetching blocks", "lowest", height+1-uint64(len(blocks)), "start", start)
+			lastLog = time.Now()
+		}
+	}
+}
+
+// execute re-executes the blocks after [start] up to [target], exclusive,
+// committing the state of each block.
+func (b *stateBackfiller) execute(ctx context.Context, start uint64, target uint64) error {
+	var (
+		stateDB   = state.NewDatabase(b.chaindb)
+		processor = core.NewStateProcessor(b.chainConfig, b.chain, b.engine)
+		validator = core.NewBlockValidator(b.chainConfig, b.chain, b.engine)
+		parent    = b.chain.GetHeaderByNumber(start)
+		lastLog   = time.Now()
+	)
+	if parent == nil {
+		return fmt.Errorf("block %d not found", start)
+	}
+	b.lock.Lock()
+	b.progress.Stage = stateBackfillStageExecuting
+	b.progress.ExecutedHeight = start
+	b.execStart, b.execStartHeight = time.Now(), start
+	b.lock.Unlock()
+
+	for number := start + 1; number < target; number++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		block := rawdb.ReadBlock(b.chaindb, rawdb.ReadCanonicalHash(b.chaindb, number), number)
+		if block == nil {
+			return fmt.Errorf("block %d not found", number)
+		}
+		statedb, err := state.New(parent.Root, stateDB, nil)
+		if err != nil {
+			return fmt.Errorf("failed to open state of block %d: %w", parent.Number, err)
+		}
+		receipts, _, usedGas, err := processor.Process(block, parent, statedb, vm.Config{})
+		if err != nil {
+			return fmt.Errorf("failed to re-execute block %d: %w", number, err)
+		}
+		if err := validator.ValidateState(block, statedb, receipts, usedGas); err != nil {
+			return fmt.Errorf("invalid state after re-executing block %d: %w", number, err)
+		}
+		root, err := statedb.Commit(number, b.chainConfig.IsEIP158(block.Number()), false)
+		if err != nil {
+			return err
+		}
+		if err := stateDB.TrieDB().Commit(root, false); err != nil {
+			return err
+		}
+		batch := b.chaindb.NewBatch()
+		rawdb.WriteReceipts(batch, block.Hash(), number, receipts)
+		rawdb.WriteTxLookupEntriesByBlock(batch, block)
+		if err := rawdb.WriteStateBackfillHeight(batch, number); err != nil {
+			return err
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		b.update(func(p *StateBackfillProgress) { p.ExecutedHeight = number })
+		if time.Since(lastLog) > backfillLogInterval {
+			log.Info("state backfill: re-executing blocks", "number", number, "target", target)
+			lastLog = time.Now()
+		}
+		parent = block.Header()
+	}
+	return nil
+}
+
+// startStateBackfill starts reconstructing the historical state below the
+// height the node state synced to in the background, re-executing from the
+// state in [snapshotFile] or from genesis if it is empty.
+// Assumes ctx.Lock is held.
+func (vm *VM) startStateBackfill(snapshotFile string) (*stateBackfiller, error) {
+	if !vm.bootstrapped {
+		return nil, errBackfillNotBootstrapped
+	}
+	if b := vm.stateBackfiller.Get(); b != nil && b.Progress().Running() {
+		return nil, errBackfillRunning
+	}
+	if rawdb.GetLatestSyncPerformed(vm.chaindb) == 0 {
+		return nil, errBackfillNotSynced
+	}
+	client, err := vm.newPeerSyncClient()
+	if err != nil {
+		return nil, err
+	}
+	b := newStateBackfiller(stateBackfillConfig{
+		chain:       vm.blockChain,
+		chaindb:     vm.chaindb,
+		chainConfig: vm.chainConfig,
+		engine: dummy.NewFakerWithModeAndClock(
+			dummy.ConsensusCallbacks{OnExtraStateChange: vm.replayExtraStateChange},
+			dummy.Mode{ModeAllowBlobs: vm.config.BlobPoolEnabled},
+			&vm.clock,
+		),
+		client:       client,
+		snapshotFile: snapshotFile,
+	})
+	vm.stateBackfiller.Set(b)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	vm.shutdownWg.Add(1)
+	go func() {
+		defer vm.shutdownWg.Done()
+		defer cancel()
+
+		go func() {
+			select {
+			case <-vm.shutdownChan:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		_ = b.run(ctx)
+	}()
+	return b, nil
+}
+
+// stateBackfillProgress returns the progress of the most recently started
+// state backfill.
+func (vm *VM) stateBackfillProgress() StateBackfillProgress {
+	if b := vm.stateBackfiller.Get(); b != nil {
+		return b.Progress()
+	}
+	return StateBackfillProgress{Stage: stateBackfillStageIdle}
+}
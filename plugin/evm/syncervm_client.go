@@ -7,7 +7,9 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
+	"github.com/ava-labs/avalanchego/codec"
 	"github.com/ava-labs/avalanchego/database"
 	"github.com/ava-labs/avalanchego/database/versiondb"
 	"github.com/ava-labs/avalanchego/ids"
@@ -20,6 +22,7 @@ import (
 	"github.com/ava-labs/coreth/params"
 	"github.com/ava-labs/coreth/plugin/evm/message"
 	syncclient "github.com/ava-labs/coreth/sync/client"
+	"github.com/ava-labs/coreth/sync/client/stats"
 	"github.com/ava-labs/coreth/sync/statesync"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethdb"
@@ -56,6 +59,20 @@ type stateSyncClientConfig struct {
 
 	client syncclient.Client
 
+	// verifyState verifies the synced state in the background once the sync
+	// has finished, and repairState repairs the gaps found instead of only
+	// reporting them.
+	verifyState bool
+	repairState bool
+
+	// snapshotFile is the path of a sync snapshot file to sync from instead
+	// of from peers, if the network accepts its summary. It is imported to a
+	// temporary database in [chainDataDir].
+	snapshotFile string
+	chainDataDir string
+	networkCodec codec.Manager
+	blockParser  syncclient.EthBlockParser
+
 	toEngine chan<- commonEng.Message
 }
 
@@ -70,8 +87,37 @@ type stateSyncerClient struct {
 	// State Sync results
 	syncSummary  message.SyncSummary
 	stateSyncErr error
+
+	// progress of the sync, reported by [Progress]
+	progressLock sync.Mutex
+	stage        string
+	startTime    time.Time
+	endTime      time.Time
+	syncErr      error
+	evmSyncer    interface{ Progress() statesync.Progress }
+	verifier     interface {
+		Progress() statesync.StateVerification
+	}
+	verifying       bool
+	verificationErr error
+	// verificationPasses is the number of state verifications that completed.
+	verificationPasses uint64
+	// unrepairedGaps is the number of gaps found by the latest state
+	// verification that were not repaired.
+	unrepairedGaps uint64
+	// snapshotComparison is the result of the comparison of the snapshot with
+	// the synced tries, snapshotComparisonErr its error, and
+	// snapshotRegenerated is set if the snapshot is generated again because
+	// of it.
+	snapshotComparison    statesync.SnapshotComparison
+	snapshotComparisonErr error
+	snapshotRegenerated   bool
 }
 
+// stateVerificationRetryInterval is the time to wait before verifying the
+// synced state again, when the verification found gaps or failed.
+var stateVerificationRetryInterval = 10 * time.Minute
+
 func NewStateSyncClient(config *stateSyncClientConfig) StateSyncClient {
 	return &stateSyncerClient{
 		stateSyncClientConfig: config,
@@ -88,6 +134,7 @@ type StateSyncClient interface {
 	ClearOngoingSummary() error
 	Shutdown() error
 	Error() error
+	Progress() StateSyncProgress
 }
 
 // Syncer represents a step in state sync,
@@ -106,15 +153,20 @@ func (client *stateSyncerClient) StateSyncEnabled(context.Context) (bool, error)
 
 // GetOngoingSyncStateSummary returns a state summary that was previously started
 // and not finished, and sets [resumableSummary] if one was found.
-// Returns [database.ErrNotFound] if no ongoing summary is found or if [client.skipResume] is true.
+// If no ongoing summary is found or if [client.skipResume] is true, returns the
+// summary of [client.snapshotFile] so the engine may select it, or
+// [database.ErrNotFound] if there is no snapshot file.
 func (client *stateSyncerClient) GetOngoingSyncStateSummary(context.Context) (block.StateSummary, error) {
 	if client.skipResume {
-		return nil, database.ErrNotFound
+		return readSyncSnapshotSummary(client.snapshotFile, client.acceptSyncSummary)
 	}
 
 	summaryBytes, err := client.metadataDB.Get(stateSyncSummaryKey)
+	if err == database.ErrNotFound {
+		return readSyncSnapshotSummary(client.snapshotFile, client.acceptSyncSummary)
+	}
 	if err != nil {
-		return nil, err // includes the [database.ErrNotFound] case
+		return nil, err
 	}
 
 	summary, err := message.NewSyncSummaryFromBytes(summaryBytes, client.acceptSyncSummary)
@@ -145,19 +197,79 @@ func (client *stateSyncerClient) ParseStateSummary(_ context.Context, summaryByt
 // stateSync blockingly performs the state sync for the EVM state and the atomic state
 // to [client.syncSummary]. returns an error if one occurred.
 func (client *stateSyncerClient) stateSync(ctx context.Context) error {
+	if client.snapshotFile != "" {
+		source, err := client.openSyncSnapshot(ctx)
+		if err != nil {
+			return err
+		}
+		if source != nil {
+			defer source.Close()
+			// The state verification repairs gaps from peers after the
+			// snapshot file is closed.
+			peers := client.client
+			defer func() { client.client = peers }()
+			// Responses from the snapshot file go through the same verification
+			// as responses from peers. Missing data will not appear on retry.
+			client.client = syncclient.NewClient(&syncclient.ClientConfig{
+				NetworkClient: source,
+				Codec:         client.networkCodec,
+				Stats:         stats.NewNoOpStats(),
+				BlockParser:   client.blockParser,
+				MaxAttempts:   1,
+			})
+		}
+	}
+
+	client.setStage(stateSyncStageBlocks)
 	if err := client.syncBlocks(ctx, client.syncSummary.BlockHash, client.syncSummary.BlockNumber, parentsToGet); err != nil {
 		return err
 	}
 
 	// Sync the EVM trie and then the atomic trie. These steps could be done
 	// in parallel or in the opposite order. Keeping them serial for simplicity for now.
+	client.setStage(stateSyncStageStateTrie)
 	if err := client.syncStateTrie(ctx); err != nil {
 		return err
 	}
 
+	if client.verifyState {
+		client.setStage(stateSyncStageSnapshot)
+		if err := client.compareSnapshot(ctx); err != nil {
+			return err
+		}
+	}
+
+	client.setStage(stateSyncStageAtomicTrie)
 	return client.syncAtomicTrie(ctx)
 }
 
+// openSyncSnapshot imports [client.snapshotFile] if it was exported at
+// [client.syncSummary]. Returns nil if the file is at a different summary, in
+// which case state is fetched from peers.
+func (client *stateSyncerClient) openSyncSnapshot(ctx context.Context) (*syncSnapshotSource, error) {
+	sr, err := openSyncSnapshot(client.snapshotFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sync snapshot file: %w", err)
+	}
+	defer sr.Close()
+
+	fileSummary, err := sr.summary(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse sync snapshot summary: %w", err)
+	}
+	if fileSummary.BlockHash != client.syncSummary.BlockHash {
+		log.Warn("sync snapshot file is not at the accepted summary, syncing from peers",
+			"file", client.snapshotFile, "fileHeight", fileSummary.Height(), "summaryHeight", client.syncSummary.Height())
+		return nil, nil
+	}
+	log.Info("importing sync snapshot file", "file", client.snapshotFile, "summary", fileSummary)
+	source, err := newSyncSnapshotSource(ctx, sr, client.chainDataDir, client.networkCodec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import sync snapshot file: %w", err)
+	}
+	return source, nil
+}
+
 // acceptSyncSummary returns true if sync will be performed and launches the state sync process
 // in a goroutine.
 func (client *stateSyncerClient) acceptSyncSummary(proposedSummary message.SyncSummary) (block.StateSyncMode, error) {
@@ -212,13 +324,19 @@ func (client *stateSyncerClient) acceptSyncSummary(proposedSummary message.SyncS
 		if err := client.stateSync(ctx); err != nil {
 			client.stateSyncErr = err
 		} else {
+			client.setStage(stateSyncStageFinishing)
 			client.stateSyncErr = client.finishSync()
 		}
+		client.setResult(client.stateSyncErr)
 		// notify engine regardless of whether err == nil,
 		// this error will be propagated to the engine when it calls
 		// vm.SetState(snow.Bootstrapping)
 		log.Info("stateSync completed, notifying engine", "err", client.stateSyncErr)
 		client.toEngine <- commonEng.StateSyncDone
+
+		if client.stateSyncErr == nil && client.verifyState {
+			client.verifySyncedState(ctx)
+		}
 	}()
 	return block.StateSyncStatic, nil
 }
@@ -302,6 +420,9 @@ func (client *stateSyncerClient) syncStateTrie(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	client.progressLock.Lock()
+	client.evmSyncer = evmSyncer
+	client.progressLock.Unlock()
 	if err := evmSyncer.Start(ctx); err != nil {
 		return err
 	}
@@ -310,6 +431,90 @@ func (client *stateSyncerClient) syncStateTrie(ctx context.Context) error {
 	return err
 }
 
+// compareSnapshot compares the snapshot written by the sync with the synced
+// tries, before accepted blocks update it. If the snapshot differs from the
+// tries, or could not be compared, and [client.repairState] is set, the
+// snapshot is generated again from the tries once it is loaded. Only the
+// cancellation of [ctx] is returned, since the tries are verified after the
+// sync.
+func (client *stateSyncerClient) compareSnapshot(ctx context.Context) error {
+	result, err := statesync.CompareSnapshot(ctx, client.chaindb, client.syncSummary.BlockRoot)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		log.Warn("could not compare snapshot with synced state", "root", client.syncSummary.BlockRoot, "err", err)
+	}
+	regenerate := client.repairState && (err != nil || result.Mismatches > 0)
+	if regenerate {
+		log.Info("regenerating snapshot of synced state", "root", client.syncSummary.BlockRoot, "mismatches", result.Mismatches)
+		snapshot.RestartSnapshotGeneration(client.chaindb)
+	}
+	client.progressLock.Lock()
+	defer client.progressLock.Unlock()
+	client.snapshotComparison = result
+	client.snapshotComparisonErr = err
+	client.snapshotRegenerated = regenerate
+	return nil
+}
+
+// verifySyncedState verifies the synced state while the chain is running,
+// repairing the gaps found if [client.repairState] is set. The verification is
+// retried every [stateVerificationRetryInterval] until it finds no gaps, or
+// until [ctx] is cancelled. The gaps found by the latest verification are
+// reported by [Progress].
+func (client *stateSyncerClient) verifySyncedState(ctx context.Context) {
+	for {
+		client.progressLock.Lock()
+		client.verifying = true
+		client.progressLock.Unlock()
+
+		result, err := client.runStateVerifier(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		gaps := result.Gaps()
+		client.progressLock.Lock()
+		client.verifying = false
+		client.verificationErr = err
+		if err == nil {
+			client.verificationPasses++
+			client.unrepairedGaps = gaps
+		}
+		client.progressLock.Unlock()
+
+		if err == nil && gaps == 0 {
+			return
+		}
+		log.Warn("state verification did not complete, retrying",
+			"root", client.syncSummary.BlockRoot,
+			"gaps", gaps,
+			"retryInterval", stateVerificationRetryInterval,
+			"err", err,
+		)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(stateVerificationRetryInterval):
+		}
+	}
+}
+
+func (client *stateSyncerClient) runStateVerifier(ctx context.Context) (statesync.StateVerification, error) {
+	verifier := statesync.NewStateVerifier(statesync.StateVerifierConfig{
+		Root:        client.syncSummary.BlockRoot,
+		DB:          client.chaindb,
+		BatchSize:   ethdb.IdealBatchSize,
+		RequestSize: client.stateSyncRequestSize,
+		Repair:      client.repairState,
+		Client:      client.client,
+	})
+	client.progressLock.Lock()
+	client.verifier = verifier
+	client.progressLock.Unlock()
+	return verifier.Verify(ctx)
+}
+
 func (client *stateSyncerClient) Shutdown() error {
 	if client.cancel != nil {
 		client.cancel()
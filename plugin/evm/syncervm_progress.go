@@ -0,0 +1,193 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Stages of a state sync, as reported by [StateSyncProgress].
+const (
+	stateSyncStageIdle       = "idle"      // State sync has not started
+	stateSyncStageBlocks     = "blocks"    // Fetching the summary block and its parents
+	stateSyncStageStateTrie  = "state"     // Syncing the account and storage tries and code
+	stateSyncStageSnapshot   = "snapshot"  // Comparing the snapshot with the synced tries
+	stateSyncStageAtomicTrie = "atomic"    // Syncing the atomic trie
+	stateSyncStageFinishing  = "finishing" // Updating the chain to the synced block
+	stateSyncStageDone       = "done"
+	stateSyncStageFailed     = "failed"
+)
+
+// StateSyncProgress is the progress of the state sync of the VM.
+// The trie fields are only set once the state trie stage has started, the
+// snapshot fields once the snapshot stage has ended, and the verification
+// fields once the verification of the synced state has started, which runs in
+// the background after the sync is done.
+type StateSyncProgress struct {
+	Stage         string      `json:"stage"`
+	SummaryHeight uint64      `json:"summaryHeight"`
+	SummaryHash   common.Hash `json:"summaryHash"`
+	SummaryRoot   common.Hash `json:"summaryRoot"`
+	AtomicRoot    common.Hash `json:"atomicRoot"`
+	// ElapsedSeconds is the time since state sync started, or the time it
+	// took if it has ended.
+	ElapsedSeconds      float64 `json:"elapsedSeconds"`
+	LeafsFetched        uint64  `json:"leafsFetched"`
+	BytesFetched        uint64  `json:"bytesFetched"`
+	TriesSynced         int     `json:"triesSynced"`
+	TriesInProgress     int     `json:"triesInProgress"`
+	TriesRemaining      int     `json:"triesRemaining"`
+	SegmentsOutstanding int     `json:"segmentsOutstanding"`
+	CodeHashesPending   int     `json:"codeHashesPending"`
+	LeafsPerSecond      float64 `json:"leafsPerSecond"`
+	BytesPerSecond      float64 `json:"bytesPerSecond"`
+	// ETASeconds is the estimated time remaining to sync the state trie, zero
+	// until the first estimate is available.
+	ETASeconds float64 `json:"etaSeconds"`
+
+	// SnapshotAccountsCompared, SnapshotSlotsCompared and SnapshotMismatches
+	// are the result of the comparison of the snapshot with the synced tries,
+	// and SnapshotComparisonError its error, if it failed. SnapshotRegenerated
+	// is set if the snapshot is generated again because of it.
+	SnapshotAccountsCompared uint64 `json:"snapshotAccountsCompared"`
+	SnapshotSlotsCompared    uint64 `json:"snapshotSlotsCompared"`
+	SnapshotMismatches       uint64 `json:"snapshotMismatches"`
+	SnapshotComparisonError  string `json:"snapshotComparisonError,omitempty"`
+	SnapshotRegenerated      bool   `json:"snapshotRegenerated"`
+
+	// Verifying is set while the synced state is being verified, and
+	// VerificationPasses is the number of verifications that completed.
+	Verifying            bool   `json:"verifying"`
+	VerificationPasses   uint64 `json:"verificationPasses"`
+	AccountsVerified     uint64 `json:"accountsVerified"`
+	StorageTriesVerified uint64 `json:"storageTriesVerified"`
+	TrieNodesVerified    uint64 `json:"trieNodesVerified"`
+	// BrokenRanges is the number of ranges of the tries below missing or
+	// corrupt nodes.
+	BrokenRanges int `json:"brokenRanges"`
+	// InvalidCode is the number of code hashes with missing or invalid code.
+	InvalidCode int `json:"invalidCode"`
+	// UnrepairedGaps is the number of gaps found by the latest verification
+	// that were not repaired. It is cleared once a verification finds no gaps.
+	UnrepairedGaps uint64 `json:"unrepairedGaps"`
+	// VerificationError is the error of the latest verification, if it
+	// failed.
+	VerificationError string `json:"verificationError,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// Syncing returns whether state sync has started and not yet ended.
+func (p StateSyncProgress) Syncing() bool {
+	switch p.Stage {
+	case stateSyncStageIdle, stateSyncStageDone, stateSyncStageFailed:
+		return false
+	default:
+		return true
+	}
+}
+
+// setStage records that the sync entered [stage].
+func (client *stateSyncerClient) setStage(stage string) {
+	client.progressLock.Lock()
+	defer client.progressLock.Unlock()
+
+	if client.startTime.IsZero() {
+		client.startTime = time.Now()
+	}
+	client.stage = stage
+}
+
+// setResult records that the sync ended with [err].
+func (client *stateSyncerClient) setResult(err error) {
+	client.progressLock.Lock()
+	defer client.progressLock.Unlock()
+
+	client.endTime = time.Now()
+	client.syncErr = err
+	if err != nil {
+		client.stage = stateSyncStageFailed
+	} else {
+		client.stage = stateSyncStageDone
+	}
+}
+
+// Progress returns the progress of the state sync.
+func (client *stateSyncerClient) Progress() StateSyncProgress {
+	client.progressLock.Lock()
+	defer client.progressLock.Unlock()
+
+	if client.stage == "" {
+		return StateSyncProgress{Stage: stateSyncStageIdle}
+	}
+	p := StateSyncProgress{
+		Stage:         client.stage,
+		SummaryHeight: client.syncSummary.BlockNumber,
+		SummaryHash:   client.syncSummary.BlockHash,
+		SummaryRoot:   client.syncSummary.BlockRoot,
+		AtomicRoot:    client.syncSummary.AtomicRoot,
+	}
+	if client.endTime.IsZero() {
+		p.ElapsedSeconds = time.Since(client.startTime).Seconds()
+	} else {
+		p.ElapsedSeconds = client.endTime.Sub(client.startTime).Seconds()
+	}
+	if client.syncErr != nil {
+		p.Error = client.syncErr.Error()
+	}
+	if client.evmSyncer != nil {
+		evm := client.evmSyncer.Progress()
+		p.LeafsFetched = evm.LeafsFetched
+		p.BytesFetched = evm.BytesFetched
+		p.TriesSynced = evm.TriesSynced
+		p.TriesInProgress = evm.TriesInProgress
+		p.TriesRemaining = evm.TriesRemaining
+		p.SegmentsOutstanding = evm.SegmentsOutstanding
+		p.CodeHashesPending = evm.CodeHashesPending
+		p.LeafsPerSecond = evm.LeafsPerSecond
+		p.BytesPerSecond = evm.BytesPerSecond
+		if client.stage == stateSyncStageStateTrie {
+			p.ETASeconds = evm.ETA.Seconds()
+		}
+	}
+	if client.verifier != nil {
+		verification := client.verifier.Progress()
+		p.AccountsVerified = verification.Accounts
+		p.StorageTriesVerified = verification.StorageTries
+		p.TrieNodesVerified = verification.TrieNodes
+		p.BrokenRanges = len(verification.BrokenRanges)
+		p.InvalidCode = len(verification.InvalidCode)
+	}
+	p.SnapshotAccountsCompared = client.snapshotComparison.Accounts
+	p.SnapshotSlotsCompared = client.snapshotComparison.Slots
+	p.SnapshotMismatches = client.snapshotComparison.Mismatches
+	if client.snapshotComparisonErr != nil {
+		p.SnapshotComparisonError = client.snapshotComparisonErr.Error()
+	}
+	p.SnapshotRegenerated = client.snapshotRegenerated
+	p.Verifying = client.verifying
+	p.VerificationPasses = client.verificationPasses
+	p.UnrepairedGaps = client.unrepairedGaps
+	if client.verificationErr != nil {
+		p.VerificationError = client.verificationErr.Error()
+	}
+	return p
+}
+
+// StateSyncAPI reports the progress of state sync.
+type StateSyncAPI struct{ vm *VM }
+
+// Progress returns the progress of the state sync of the node.
+func (api *StateSyncAPI) Progress(context.Context) StateSyncProgress {
+	return api.vm.StateSyncClient.Progress()
+}
+
+// BackfillProgress returns the progress of the most recently started
+// reconstruction of the historical state of the node.
+func (api *StateSyncAPI) BackfillProgress(context.Context) StateBackfillProgress {
+	return api.vm.stateBackfillProgress()
+}
@@ -6,9 +6,11 @@ package evm
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	"github.com/ava-labs/avalanchego/database"
 	"github.com/ava-labs/avalanchego/snow/engine/snowman/block"
+	"github.com/ava-labs/avalanchego/utils/set"
 
 	"github.com/ava-labs/coreth/core"
 	"github.com/ava-labs/coreth/plugin/evm/message"
@@ -22,6 +24,9 @@ type stateSyncServerConfig struct {
 
 	// SyncableInterval is the interval at which blocks are eligible to provide syncable block summaries.
 	SyncableInterval uint64
+	// PinnedHeights are heights that are eligible to provide syncable block
+	// summaries in addition to the multiples of SyncableInterval.
+	PinnedHeights []uint64
 }
 
 type stateSyncServer struct {
@@ -29,6 +34,9 @@ type stateSyncServer struct {
 	atomicTrie AtomicTrie
 
 	syncableInterval uint64
+	pinnedHeights    set.Set[uint64]
+	// sortedPinnedHeights are [pinnedHeights] in decreasing order.
+	sortedPinnedHeights []uint64
 }
 
 type StateSyncServer interface {
@@ -37,10 +45,16 @@ type StateSyncServer interface {
 }
 
 func NewStateSyncServer(config *stateSyncServerConfig) StateSyncServer {
+	pinnedHeights := set.Of(config.PinnedHeights...)
+	sortedPinnedHeights := pinnedHeights.List()
+	slices.Sort(sortedPinnedHeights)
+	slices.Reverse(sortedPinnedHeights)
 	return &stateSyncServer{
-		chain:            config.Chain,
-		atomicTrie:       config.AtomicTrie,
-		syncableInterval: config.SyncableInterval,
+		chain:               config.Chain,
+		atomicTrie:          config.AtomicTrie,
+		syncableInterval:    config.SyncableInterval,
+		pinnedHeights:       pinnedHeights,
+		sortedPinnedHeights: sortedPinnedHeights,
 	}
 }
 
@@ -73,12 +87,29 @@ func (server *stateSyncServer) stateSummaryAtHeight(height uint64) (message.Sync
 
 // GetLastStateSummary returns the latest state summary.
 // State summary is calculated by the block nearest to last accepted
-// that is divisible by [syncableInterval]
+// that is divisible by [syncableInterval], or by the highest pinned height
+// between that block and last accepted if its state is available.
 // If no summary is available, [database.ErrNotFound] must be returned.
 func (server *stateSyncServer) GetLastStateSummary(context.Context) (block.StateSummary, error) {
 	lastHeight := server.chain.LastAcceptedBlock().NumberU64()
 	lastSyncSummaryNumber := lastHeight - lastHeight%server.syncableInterval
 
+	for _, height := range server.sortedPinnedHeights {
+		if height <= lastSyncSummaryNumber {
+			break
+		}
+		if height > lastHeight {
+			continue
+		}
+		summary, err := server.stateSummaryAtHeight(height)
+		if err != nil {
+			log.Debug("could not get state summary at pinned height", "height", height, "err", err)
+			continue
+		}
+		log.Debug("Serving syncable block at pinned height", "summary", summary)
+		return summary, nil
+	}
+
 	summary, err := server.stateSummaryAtHeight(lastSyncSummaryNumber)
 	if err != nil {
 		log.Debug("could not get latest state summary", "err", err)
@@ -95,7 +126,7 @@ func (server *stateSyncServer) GetStateSummary(_ context.Context, height uint64)
 	summaryBlock := server.chain.GetBlockByNumber(height)
 	if summaryBlock == nil ||
 		summaryBlock.NumberU64() > server.chain.LastAcceptedBlock().NumberU64() ||
-		summaryBlock.NumberU64()%server.syncableInterval != 0 {
+		(summaryBlock.NumberU64()%server.syncableInterval != 0 && !server.pinnedHeights.Contains(summaryBlock.NumberU64())) {
 		return nil, database.ErrNotFound
 	}
 
@@ -0,0 +1,489 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ava-labs/avalanchego/codec"
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow/engine/snowman/block"
+	"github.com/ava-labs/avalanchego/version"
+	"github.com/ava-labs/coreth/core/rawdb"
+	"github.com/ava-labs/coreth/core/state/snapshot"
+	"github.com/ava-labs/coreth/core/types"
+	"github.com/ava-labs/coreth/peer"
+	"github.com/ava-labs/coreth/plugin/evm/message"
+	syncHandlers "github.com/ava-labs/coreth/sync/handlers"
+	syncStats "github.com/ava-labs/coreth/sync/handlers/stats"
+	"github.com/ava-labs/coreth/trie"
+	"github.com/ava-labs/coreth/triedb"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// A sync snapshot file holds the state at a syncable summary, so that a node
+// can state sync from it instead of from its peers. The file starts with
+// [syncSnapshotMagic], followed by a gzip compressed RLP stream of a
+// [syncSnapshotHeader] and the [syncSnapshotRecord]s, the last of which is
+// a [syncSnapshotRecordEnd] record.
+//
+// The contents of the file are not trusted: they are served to the state sync
+// client, which verifies them against the summary roots as it would verify
+// responses from peers, and the summary must be accepted by the network. Trie
+// nodes, code and blocks are also verified against their hashes when imported,
+// so that a file cannot overwrite data keyed by hash with different contents.
+const (
+	syncSnapshotVersion = 1
+
+	syncSnapshotRecordEnd      = 0 // Value is the number of preceding records
+	syncSnapshotRecordTrieNode = 1 // Key is the node hash, Value the node blob
+	syncSnapshotRecordCode     = 2 // Key is the code hash, Value the code
+	syncSnapshotRecordBlock    = 3 // Value is the RLP encoded block
+
+	// syncSnapshotLogInterval is the number of records between progress logs.
+	syncSnapshotLogInterval = 1_000_000
+)
+
+var (
+	syncSnapshotMagic = []byte("coreth-sync-snapshot")
+
+	errSyncSnapshotMagic       = errors.New("not a sync snapshot file")
+	errSyncSnapshotTruncated   = errors.New("sync snapshot file is truncated")
+	errSyncSnapshotMissingData = errors.New("sync snapshot file is missing requested data")
+	errSyncSnapshotInvalid     = errors.New("sync snapshot record does not match its hash")
+)
+
+type syncSnapshotHeader struct {
+	Version uint64
+	Summary []byte
+}
+
+type syncSnapshotRecord struct {
+	Kind  uint8
+	Key   []byte
+	Value []byte
+}
+
+// SyncSnapshotStats counts the records written to a sync snapshot file.
+type SyncSnapshotStats struct {
+	TrieNodes uint64
+	Code      uint64
+	Blocks    uint64
+}
+
+// syncSnapshotWriter writes the records of a sync snapshot file.
+type syncSnapshotWriter struct {
+	buf     *bufio.Writer
+	gz      *gzip.Writer
+	records uint64
+	stats   SyncSnapshotStats
+}
+
+func newSyncSnapshotWriter(w io.Writer, summary message.SyncSummary) (*syncSnapshotWriter, error) {
+	buf := bufio.NewWriter(w)
+	if _, err := buf.Write(syncSnapshotMagic); err != nil {
+		return nil, err
+	}
+	sw := &syncSnapshotWriter{
+		buf: buf,
+		gz:  gzip.NewWriter(buf),
+	}
+	header := syncSnapshotHeader{
+		Version: syncSnapshotVersion,
+		Summary: summary.Bytes(),
+	}
+	if err := rlp.Encode(sw.gz, &header); err != nil {
+		return nil, err
+	}
+	return sw, nil
+}
+
+func (sw *syncSnapshotWriter) write(kind uint8, key []byte, value []byte) error {
+	record := syncSnapshotRecord{Kind: kind, Key: key, Value: value}
+	if err := rlp.Encode(sw.gz, &record); err != nil {
+		return err
+	}
+	sw.records++
+	switch kind {
+	case syncSnapshotRecordTrieNode:
+		sw.stats.TrieNodes++
+	case syncSnapshotRecordCode:
+		sw.stats.Code++
+	case syncSnapshotRecordBlock:
+		sw.stats.Blocks++
+	}
+	if sw.records%syncSnapshotLogInterval == 0 {
+		log.Info("exporting sync snapshot", "records", sw.records)
+	}
+	return nil
+}
+
+// writeTrie writes the nodes of the trie identified by [id] and returns them
+// through [onLeaf] if non-nil.
+func (sw *syncSnapshotWriter) writeTrie(ctx context.Context, db *triedb.Database, id *trie.ID, onLeaf func(key, value []byte) error) error {
+	t, err := trie.New(id, db)
+	if err != nil {
+		return fmt.Errorf("failed to open trie %s: %w", id.Root, err)
+	}
+	it, err := t.NodeIterator(nil)
+	if err != nil {
+		return err
+	}
+	for it.Next(true) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if it.Leaf() {
+			if onLeaf != nil {
+				if err := onLeaf(it.LeafKey(), it.LeafBlob()); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		// Nodes embedded in their parent have no hash and are not stored
+		// separately.
+		if hash := it.Hash(); hash != (common.Hash{}) {
+			if err := sw.write(syncSnapshotRecordTrieNode, hash[:], it.NodeBlob()); err != nil {
+				return err
+			}
+		}
+	}
+	return it.Error()
+}
+
+// close writes the end record and flushes the file.
+func (sw *syncSnapshotWriter) close() error {
+	if err := rlp.Encode(sw.gz, &syncSnapshotRecord{Kind: syncSnapshotRecordEnd, Value: rlp.AppendUint64(nil, sw.records)}); err != nil {
+		return err
+	}
+	if err := sw.gz.Close(); err != nil {
+		return err
+	}
+	return sw.buf.Flush()
+}
+
+// exportSyncSnapshot writes the state at [summary] to [w]: the [parentsToGet]
+// blocks ending at the summary block, the account trie and all storage tries
+// with the code they refer to, and the atomic trie.
+func exportSyncSnapshot(
+	ctx context.Context,
+	w io.Writer,
+	summary message.SyncSummary,
+	blocks syncHandlers.BlockProvider,
+	stateTrieDB *triedb.Database,
+	atomicTrieDB *triedb.Database,
+	codeReader ethdb.KeyValueReader,
+) (SyncSnapshotStats, error) {
+	sw, err := newSyncSnapshotWriter(w, summary)
+	if err != nil {
+		return SyncSnapshotStats{}, err
+	}
+
+	hash, height := summary.BlockHash, summary.BlockNumber
+	for i := 0; i < parentsToGet && hash != (common.Hash{}); i++ {
+		block := blocks.GetBlock(hash, height)
+		if block == nil {
+			return SyncSnapshotStats{}, fmt.Errorf("block not found (%s, %d)", hash, height)
+		}
+		blockBytes, err := rlp.EncodeToBytes(block)
+		if err != nil {
+			return SyncSnapshotStats{}, err
+		}
+		if err := sw.write(syncSnapshotRecordBlock, nil, blockBytes); err != nil {
+			return SyncSnapshotStats{}, err
+		}
+		if height == 0 {
+			break
+		}
+		hash, height = block.ParentHash(), height-1
+	}
+
+	var (
+		storageRoots = make(map[common.Hash]struct{})
+		codeHashes   = make(map[common.Hash]struct{})
+	)
+	err = sw.writeTrie(ctx, stateTrieDB, trie.StateTrieID(summary.BlockRoot), func(key, value []byte) error {
+		var acc types.StateAccount
+		if err := rlp.DecodeBytes(value, &acc); err != nil {
+			return fmt.Errorf("failed to decode account %x: %w", key, err)
+		}
+		if _, seen := storageRoots[acc.Root]; !seen && acc.Root != types.EmptyRootHash {
+			storageRoots[acc.Root] = struct{}{}
+			id := trie.StorageTrieID(summary.BlockRoot, common.BytesToHash(key), acc.Root)
+			if err := sw.writeTrie(ctx, stateTrieDB, id, nil); err != nil {
+				return err
+			}
+		}
+		codeHash := common.BytesToHash(acc.CodeHash)
+		if _, seen := codeHashes[codeHash]; !seen && codeHash != types.EmptyCodeHash {
+			codeHashes[codeHash] = struct{}{}
+			code := rawdb.ReadCode(codeReader, codeHash)
+			if len(code) == 0 {
+				return fmt.Errorf("code not found (%s)", codeHash)
+			}
+			if err := sw.write(syncSnapshotRecordCode, codeHash[:], code); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return SyncSnapshotStats{}, fmt.Errorf("failed to export state trie: %w", err)
+	}
+	if err := sw.writeTrie(ctx, atomicTrieDB, trie.TrieID(summary.AtomicRoot), nil); err != nil {
+		return SyncSnapshotStats{}, fmt.Errorf("failed to export atomic trie: %w", err)
+	}
+	return sw.stats, sw.close()
+}
+
+// syncSnapshotReader reads the records of a sync snapshot file.
+type syncSnapshotReader struct {
+	file   *os.File
+	gz     *gzip.Reader
+	stream *rlp.Stream
+	header syncSnapshotHeader
+}
+
+func openSyncSnapshot(path string) (*syncSnapshotReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	buf := bufio.NewReader(file)
+	magic := make([]byte, len(syncSnapshotMagic))
+	if _, err := io.ReadFull(buf, magic); err != nil || !bytes.Equal(magic, syncSnapshotMagic) {
+		file.Close()
+		return nil, fmt.Errorf("%w: %s", errSyncSnapshotMagic, path)
+	}
+	gz, err := gzip.NewReader(buf)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	sr := &syncSnapshotReader{
+		file:   file,
+		gz:     gz,
+		stream: rlp.NewStream(gz, 0),
+	}
+	if err := sr.stream.Decode(&sr.header); err != nil {
+		sr.Close()
+		return nil, fmt.Errorf("failed to decode sync snapshot header: %w", err)
+	}
+	if sr.header.Version != syncSnapshotVersion {
+		sr.Close()
+		return nil, fmt.Errorf("unsupported sync snapshot version %d", sr.header.Version)
+	}
+	return sr, nil
+}
+
+// summary returns the summary the file was exported at.
+func (sr *syncSnapshotReader) summary(acceptImpl func(message.SyncSummary) (block.StateSyncMode, error)) (message.SyncSummary, error) {
+	return message.NewSyncSummaryFromBytes(sr.header.Summary, acceptImpl)
+}
+
+// importTo writes the records of the file to [db].
+func (sr *syncSnapshotReader) importTo(ctx context.Context, db ethdb.KeyValueStore) error {
+	var (
+		batch   = db.NewBatch()
+		records uint64
+		start   = time.Now()
+	)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var record syncSnapshotRecord
+		if err := sr.stream.Decode(&record); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return errSyncSnapshotTruncated
+			}
+			return fmt.Errorf("failed to decode sync snapshot record %d: %w", records, err)
+		}
+		switch record.Kind {
+		case syncSnapshotRecordEnd:
+			count, _, err := rlp.SplitUint64(record.Value)
+			if err != nil || count != records {
+				return errSyncSnapshotTruncated
+			}
+			log.Info("imported sync snapshot", "records", records, "elapsed", time.Since(start))
+			return batch.Write()
+		case syncSnapshotRecordTrieNode:
+			hash := common.BytesToHash(record.Key)
+			if len(record.Key) != common.HashLength || crypto.Keccak256Hash(record.Value) != hash {
+				return fmt.Errorf("%w: trie node %x", errSyncSnapshotInvalid, record.Key)
+			}
+			rawdb.WriteLegacyTrieNode(batch, hash, record.Value)
+		case syncSnapshotRecordCode:
+			hash := common.BytesToHash(record.Key)
+			if len(record.Key) != common.HashLength || crypto.Keccak256Hash(record.Value) != hash {
+				return fmt.Errorf("%w: code %x", errSyncSnapshotInvalid, record.Key)
+			}
+			rawdb.WriteCode(batch, hash, record.Value)
+		case syncSnapshotRecordBlock:
+			block := new(types.Block)
+			if err := rlp.DecodeBytes(record.Value, block); err != nil {
+				return fmt.Errorf("failed to decode sync snapshot block: %w", err)
+			}
+			if err := verifySyncSnapshotBlock(block); err != nil {
+				return err
+			}
+			rawdb.WriteBlock(batch, block)
+		default:
+			return fmt.Errorf("unknown sync snapshot record kind %d", record.Kind)
+		}
+		records++
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+		if records%syncSnapshotLogInterval == 0 {
+			log.Info("importing sync snapshot", "records", records, "elapsed", time.Since(start))
+		}
+	}
+}
+
+// verifySyncSnapshotBlock checks that the body of [block] matches the hashes
+// committed to by its header, as the block is stored under its header hash.
+// The extra data is only checked if the header commits to it.
+func verifySyncSnapshotBlock(block *types.Block) error {
+	if hash := types.DeriveSha(block.Transactions(), trie.NewStackTrie(nil)); hash != block.TxHash() {
+		return fmt.Errorf("%w: block %s transactions root %s, want %s", errSyncSnapshotInvalid, block.Hash(), hash, block.TxHash())
+	}
+	if hash := types.CalcUncleHash(block.Uncles()); hash != block.UncleHash() {
+		return fmt.Errorf("%w: block %s uncle hash %s, want %s", errSyncSnapshotInvalid, block.Hash(), hash, block.UncleHash())
+	}
+	if want := block.Header().ExtDataHash; want != (common.Hash{}) {
+		if hash := types.CalcExtDataHash(block.ExtData()); hash != want {
+			return fmt.Errorf("%w: block %s extra data hash %s, want %s", errSyncSnapshotInvalid, block.Hash(), hash, want)
+		}
+	}
+	return nil
+}
+
+func (sr *syncSnapshotReader) Close() error {
+	return errors.Join(sr.gz.Close(), sr.file.Close())
+}
+
+var (
+	_ peer.NetworkClient            = &syncSnapshotSource{}
+	_ syncHandlers.SyncDataProvider = &syncSnapshotSource{}
+)
+
+// syncSnapshotSource serves state sync requests from an imported sync
+// snapshot file, using the same handlers as the node uses to serve its peers.
+type syncSnapshotSource struct {
+	dir     string
+	db      ethdb.Database
+	handler message.RequestHandler
+	codec   codec.Manager
+}
+
+// newSyncSnapshotSource imports the sync snapshot file read by [sr] to a
+// temporary database in [dir].
+func newSyncSnapshotSource(ctx context.Context, sr *syncSnapshotReader, dir string, networkCodec codec.Manager) (*syncSnapshotSource, error) {
+	tmpDir, err := os.MkdirTemp(dir, "sync-snapshot-")
+	if err != nil {
+		return nil, err
+	}
+	db, err := rawdb.NewLevelDBDatabase(tmpDir, 0, 0, "", false)
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		return nil, err
+	}
+	s := &syncSnapshotSource{
+		dir:   tmpDir,
+		db:    db,
+		codec: networkCodec,
+	}
+	if err := sr.importTo(ctx, db); err != nil {
+		s.Close()
+		return nil, err
+	}
+	// State and atomic trie nodes are both keyed by their hash, so both
+	// tries are served from the same database.
+	trieDB := triedb.NewDatabase(db, nil)
+	handlerStats := syncStats.NewNoopHandlerStats()
+	s.handler = networkHandler{
+		stateTrieLeafsRequestHandler:  syncHandlers.NewLeafsRequestHandler(trieDB, nil, networkCodec, handlerStats),
+		atomicTrieLeafsRequestHandler: syncHandlers.NewLeafsRequestHandler(trieDB, nil, networkCodec, handlerStats),
+		blockRequestHandler:           syncHandlers.NewBlockRequestHandler(s, networkCodec, handlerStats),
+		codeRequestHandler:            syncHandlers.NewCodeRequestHandler(db, networkCodec, handlerStats),
+		codec:                         networkCodec,
+	}
+	return s, nil
+}
+
+func (s *syncSnapshotSource) GetBlock(hash common.Hash, number uint64) *types.Block {
+	return rawdb.ReadBlock(s.db, hash, number)
+}
+
+// Snapshots returns nil, as leafs are served from the imported tries.
+func (s *syncSnapshotSource) Snapshots() *snapshot.Tree { return nil }
+
+func (s *syncSnapshotSource) SendAppRequestAny(ctx context.Context, _ *version.Application, request []byte) ([]byte, ids.NodeID, error) {
+	response, err := s.SendAppRequest(ctx, ids.EmptyNodeID, request)
+	return response, ids.EmptyNodeID, err
+}
+
+func (s *syncSnapshotSource) SendAppRequestAnyWith(ctx context.Context, minVersion *version.Application, buildRequest peer.RequestBuilder) ([]byte, ids.NodeID, error) {
+	request, err := buildRequest(ids.EmptyNodeID, nil)
+	if err != nil {
+		return nil, ids.EmptyNodeID, err
+	}
+	return s.SendAppRequestAny(ctx, minVersion, request)
+}
+
+func (s *syncSnapshotSource) SendAppRequest(ctx context.Context, nodeID ids.NodeID, request []byte) ([]byte, error) {
+	var req message.Request
+	if _, err := s.codec.Unmarshal(request, &req); err != nil {
+		return nil, err
+	}
+	response, err := req.Handle(ctx, nodeID, 0, s.handler)
+	if err != nil {
+		return nil, err
+	}
+	if response == nil {
+		return nil, fmt.Errorf("%w: %s", errSyncSnapshotMissingData, req)
+	}
+	return response, nil
+}
+
+func (s *syncSnapshotSource) TrackBandwidth(ids.NodeID, float64, int) {}
+
+func (s *syncSnapshotSource) TrackInvalidResponse(ids.NodeID) {}
+
+// Close closes and removes the imported database.
+func (s *syncSnapshotSource) Close() error {
+	return errors.Join(s.db.Close(), os.RemoveAll(s.dir))
+}
+
+// readSyncSnapshotSummary returns the summary of the sync snapshot file at
+// [path], or [database.ErrNotFound] if [path] is empty.
+func readSyncSnapshotSummary(path string, acceptImpl func(message.SyncSummary) (block.StateSyncMode, error)) (message.SyncSummary, error) {
+	if path == "" {
+		return message.SyncSummary{}, database.ErrNotFound
+	}
+	sr, err := openSyncSnapshot(path)
+	if err != nil {
+		return message.SyncSummary{}, err
+	}
+	defer sr.Close()
+	return sr.summary(acceptImpl)
+}
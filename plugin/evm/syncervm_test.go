@@ -4,10 +4,14 @@
 package evm
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"math/big"
 	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -32,18 +36,23 @@ import (
 	"github.com/ava-labs/coreth/constants"
 	"github.com/ava-labs/coreth/core"
 	"github.com/ava-labs/coreth/core/rawdb"
+	"github.com/ava-labs/coreth/core/state"
+	"github.com/ava-labs/coreth/core/state/snapshot"
 	"github.com/ava-labs/coreth/core/types"
 	"github.com/ava-labs/coreth/metrics"
 	"github.com/ava-labs/coreth/params"
+	"github.com/ava-labs/coreth/plugin/evm/message"
 	"github.com/ava-labs/coreth/predicate"
 	statesyncclient "github.com/ava-labs/coreth/sync/client"
 	"github.com/ava-labs/coreth/sync/statesync"
 	"github.com/ava-labs/coreth/trie"
 	"github.com/ava-labs/coreth/triedb"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/holiman/uint256"
 )
 
 func TestSkipStateSync(t *testing.T) {
@@ -83,6 +92,432 @@ func TestStateSyncFromScratchExceedParent(t *testing.T) {
 	testSyncerVM(t, vmSetup, test)
 }
 
+func TestStateSyncFromSnapshotFile(t *testing.T) {
+	rand.Seed(1)
+	var (
+		lock      sync.Mutex
+		responses int
+	)
+	test := syncTest{
+		syncableInterval:   256,
+		stateSyncMinBlocks: 50, // must be less than [syncableInterval] to perform sync
+		syncMode:           block.StateSyncStatic,
+		responseIntercept: func(syncerVM *VM, nodeID ids.NodeID, requestID uint32, response []byte) {
+			lock.Lock()
+			responses++
+			lock.Unlock()
+			require.NoError(t, syncerVM.AppResponse(context.Background(), nodeID, requestID, response))
+		},
+	}
+	vmSetup := createSyncServerAndClientVMs(t, test, parentsToGet)
+
+	path := filepath.Join(t.TempDir(), "snapshot")
+	admin := NewAdminService(vmSetup.serverVM, "")
+	reply := ExportSyncSnapshotReply{}
+	require.NoError(t, admin.ExportSyncSnapshot(&http.Request{}, &ExportSyncSnapshotArgs{Path: path}, &reply))
+	require.EqualValues(t, test.syncableInterval, reply.Height)
+	require.EqualValues(t, parentsToGet, reply.Blocks)
+	require.NotZero(t, reply.TrieNodes)
+
+	// The syncer proposes the summary of the file to the engine.
+	syncerClient := vmSetup.syncerVM.StateSyncClient.(*stateSyncerClient)
+	syncerClient.snapshotFile = path
+	serverSummary, err := vmSetup.serverVM.GetLastStateSummary(context.Background())
+	require.NoError(t, err)
+	fileSummary, err := vmSetup.syncerVM.GetOngoingSyncStateSummary(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, serverSummary.ID(), fileSummary.ID())
+
+	testSyncerVM(t, vmSetup, test)
+	require.Zero(t, responses, "expected state to be synced from the snapshot file")
+}
+
+func TestStateSyncVerify(t *testing.T) {
+	for _, repair := range []bool{false, true} {
+		t.Run(fmt.Sprintf("repair=%t", repair), func(t *testing.T) {
+			rand.Seed(1)
+			test := syncTest{
+				syncableInterval:   256,
+				stateSyncMinBlocks: 50, // must be less than [syncableInterval] to perform sync
+				syncMode:           block.StateSyncStatic,
+			}
+			vmSetup := createSyncServerAndClientVMs(t, test, parentsToGet)
+			syncerClient := vmSetup.syncerVM.StateSyncClient.(*stateSyncerClient)
+			syncerClient.verifyState = true
+			syncerClient.repairState = repair
+
+			testSyncerVM(t, vmSetup, test)
+			// The verification runs in the background after the sync is done.
+			require.Eventually(t, func() bool {
+				progress := syncerClient.Progress()
+				return !progress.Verifying && progress.AccountsVerified > 0
+			}, 30*time.Second, 10*time.Millisecond)
+			progress := syncerClient.Progress()
+			require.NotZero(t, progress.TrieNodesVerified)
+			require.Zero(t, progress.BrokenRanges)
+			require.Zero(t, progress.UnrepairedGaps)
+			require.Empty(t, progress.VerificationError)
+			require.EqualValues(t, 1, progress.VerificationPasses)
+			// The snapshot is compared with the synced tries before the sync
+			// is done.
+			require.NotZero(t, progress.SnapshotAccountsCompared)
+			require.Zero(t, progress.SnapshotMismatches)
+			require.False(t, progress.SnapshotRegenerated)
+			_, err := vmSetup.syncerVM.HealthCheck(context.Background())
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestStateSyncCompareSnapshot(t *testing.T) {
+	for _, repair := range []bool{false, true} {
+		t.Run(fmt.Sprintf("repair=%t", repair), func(t *testing.T) {
+			require := require.New(t)
+			db := rawdb.NewMemoryDatabase()
+			statedb, err := state.New(types.EmptyRootHash, state.NewDatabase(db), nil)
+			require.NoError(err)
+			addr := common.Address{0x01}
+			statedb.SetBalance(addr, uint256.NewInt(1))
+			statedb.SetState(addr, common.Hash{0x01}, common.Hash{0x02})
+			root, err := statedb.Commit(0, false, false)
+			require.NoError(err)
+			require.NoError(statedb.Database().TrieDB().Commit(root, false))
+			// The snapshot holds the account with a wrong balance and without
+			// its storage.
+			snapshot.ResetSnapshotGeneration(db)
+			rawdb.WriteAccountSnapshot(db, crypto.Keccak256Hash(addr[:]), types.SlimAccountRLP(types.StateAccount{
+				Nonce:    0,
+				Balance:  uint256.NewInt(2),
+				Root:     types.EmptyRootHash,
+				CodeHash: types.EmptyCodeHash[:],
+			}))
+
+			client := &stateSyncerClient{
+				stateSyncClientConfig: &stateSyncClientConfig{
+					chaindb:     db,
+					repairState: repair,
+				},
+				syncSummary: message.SyncSummary{BlockRoot: root},
+			}
+			client.setStage(stateSyncStageSnapshot)
+			require.NoError(client.compareSnapshot(context.Background()))
+			progress := client.Progress()
+			require.EqualValues(1, progress.SnapshotAccountsCompared)
+			require.EqualValues(1, progress.SnapshotSlotsCompared)
+			require.EqualValues(2, progress.SnapshotMismatches)
+			require.Empty(progress.SnapshotComparisonError)
+			require.Equal(repair, progress.SnapshotRegenerated)
+			vm := &VM{StateSyncClient: client}
+			if _, err := vm.HealthCheck(context.Background()); repair {
+				require.NoError(err)
+			} else {
+				require.ErrorContains(err, "snapshot differs from the synced state in 2 entries")
+			}
+
+			// With repair, the snapshot is generated again when it is loaded.
+			rawdb.WriteSnapshotBlockHash(db, common.Hash{0x01})
+			rawdb.WriteSnapshotRoot(db, root)
+			snaps, err := snapshot.New(snapshot.Config{CacheSize: 16, AsyncBuild: false, SkipVerify: true}, db, triedb.NewDatabase(db, nil), common.Hash{0x01}, root)
+			require.NoError(err)
+			defer snaps.Release()
+			if repair {
+				require.NoError(snaps.Verify(root))
+			} else {
+				require.Error(snaps.Verify(root))
+			}
+		})
+	}
+}
+
+func TestStateSyncVerifyRetries(t *testing.T) {
+	rand.Seed(1)
+	test := syncTest{
+		syncableInterval:   256,
+		stateSyncMinBlocks: 50, // must be less than [syncableInterval] to perform sync
+		syncMode:           block.StateSyncStatic,
+	}
+	vmSetup := createSyncServerAndClientVMs(t, test, parentsToGet)
+	syncerClient := vmSetup.syncerVM.StateSyncClient.(*stateSyncerClient)
+	testSyncerVM(t, vmSetup, test)
+
+	// The chain is unhealthy while the synced state is verified for the first
+	// time.
+	syncerClient.progressLock.Lock()
+	syncerClient.verifying = true
+	syncerClient.progressLock.Unlock()
+	_, err := vmSetup.syncerVM.HealthCheck(context.Background())
+	require.ErrorContains(t, err, "synced state is being verified")
+
+	retryInterval := stateVerificationRetryInterval
+	stateVerificationRetryInterval = 10 * time.Millisecond
+	t.Cleanup(func() { stateVerificationRetryInterval = retryInterval })
+
+	// Delete a node of the synced account trie.
+	chaindb := syncerClient.chaindb
+	tr, err := trie.New(trie.StateTrieID(syncerClient.syncSummary.BlockRoot), triedb.NewDatabase(chaindb, nil))
+	require.NoError(t, err)
+	nodeIt, err := tr.NodeIterator(nil)
+	require.NoError(t, err)
+	var node common.Hash
+	for nodeIt.Next(true) {
+		if hash := nodeIt.Hash(); hash != (common.Hash{}) && hash != syncerClient.syncSummary.BlockRoot {
+			node = hash
+		}
+	}
+	require.NoError(t, nodeIt.Error())
+	require.NotEqual(t, common.Hash{}, node)
+	blob := rawdb.ReadLegacyTrieNode(chaindb, node)
+	rawdb.DeleteLegacyTrieNode(chaindb, node)
+
+	// Without repair, the gap is reported until the node is restored.
+	syncerClient.repairState = false
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		syncerClient.verifySyncedState(ctx)
+	}()
+	require.Eventually(t, func() bool {
+		return syncerClient.Progress().UnrepairedGaps > 0
+	}, 30*time.Second, 10*time.Millisecond)
+	_, err = vmSetup.syncerVM.HealthCheck(context.Background())
+	require.ErrorContains(t, err, "gaps in the synced state")
+	rawdb.WriteLegacyTrieNode(chaindb, node, blob)
+	<-done
+	require.Zero(t, syncerClient.Progress().UnrepairedGaps)
+
+	// With repair, the node is fetched from peers.
+	rawdb.DeleteLegacyTrieNode(chaindb, node)
+	syncerClient.repairState = true
+	syncerClient.verifySyncedState(ctx)
+	require.Zero(t, syncerClient.Progress().UnrepairedGaps)
+	require.Equal(t, blob, rawdb.ReadLegacyTrieNode(chaindb, node))
+	_, err = vmSetup.syncerVM.HealthCheck(context.Background())
+	require.NoError(t, err)
+}
+
+func TestStateSyncServerPinnedHeights(t *testing.T) {
+	rand.Seed(1)
+	test := syncTest{
+		syncableInterval:   256,
+		stateSyncMinBlocks: 50, // must be less than [syncableInterval] to perform sync
+		syncMode:           block.StateSyncStatic,
+	}
+	vmSetup := createSyncServerAndClientVMs(t, test, parentsToGet)
+	serverVM := vmSetup.serverVM
+	expected, err := serverVM.GetLastStateSummary(context.Background())
+	require.NoError(t, err)
+	require.EqualValues(t, test.syncableInterval, expected.Height())
+
+	// With a larger interval, the summary at [syncableInterval] is only served
+	// if its height is pinned.
+	server := NewStateSyncServer(&stateSyncServerConfig{
+		Chain:            serverVM.blockChain,
+		AtomicTrie:       serverVM.atomicTrie,
+		SyncableInterval: 4 * test.syncableInterval,
+	})
+	_, err = server.GetStateSummary(context.Background(), test.syncableInterval)
+	require.ErrorIs(t, err, database.ErrNotFound)
+
+	server = NewStateSyncServer(&stateSyncServerConfig{
+		Chain:            serverVM.blockChain,
+		AtomicTrie:       serverVM.atomicTrie,
+		SyncableInterval: 4 * test.syncableInterval,
+		PinnedHeights:    []uint64{test.syncableInterval / 2, 2 * test.syncableInterval, test.syncableInterval},
+	})
+	summary, err := server.GetLastStateSummary(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, expected.ID(), summary.ID())
+	summary, err = server.GetStateSummary(context.Background(), test.syncableInterval)
+	require.NoError(t, err)
+	require.Equal(t, expected.ID(), summary.ID())
+	// Pinned heights above the last accepted block are not served.
+	_, err = server.GetStateSummary(context.Background(), 2*test.syncableInterval)
+	require.ErrorIs(t, err, database.ErrNotFound)
+}
+
+func TestSyncSnapshotTruncated(t *testing.T) {
+	summary, err := message.NewSyncSummary(common.Hash{1}, 1, types.EmptyRootHash, types.EmptyRootHash)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	sw, err := newSyncSnapshotWriter(&buf, summary)
+	require.NoError(t, err)
+	require.NoError(t, sw.write(syncSnapshotRecordCode, crypto.Keccak256([]byte{1}), []byte{1}))
+	require.NoError(t, sw.gz.Flush())
+	require.NoError(t, sw.buf.Flush())
+
+	path := filepath.Join(t.TempDir(), "snapshot")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+	sr, err := openSyncSnapshot(path)
+	require.NoError(t, err)
+	defer sr.Close()
+	_, err = newSyncSnapshotSource(context.Background(), sr, t.TempDir(), message.Codec)
+	require.ErrorIs(t, err, errSyncSnapshotTruncated)
+}
+
+func TestStateBackfillFromGenesis(t *testing.T) {
+	rand.Seed(1)
+	numToGen := parentsToGet + uint64(32)
+	test := syncTest{
+		syncableInterval:   numToGen,
+		stateSyncMinBlocks: 50, // must be less than [syncableInterval] to perform sync
+		syncMode:           block.StateSyncStatic,
+	}
+	vmSetup := createSyncServerAndClientVMs(t, test, int(numToGen))
+	testSyncerVM(t, vmSetup, test)
+
+	var (
+		serverVM = vmSetup.serverVM
+		syncerVM = vmSetup.syncerVM
+	)
+	// State sync only fetched the state at [numToGen] and its last
+	// [parentsToGet] blocks.
+	firstBlock := serverVM.blockChain.GetBlockByNumber(1)
+	require.Nil(t, syncerVM.blockChain.GetBlockByNumber(1))
+	require.False(t, syncerVM.blockChain.HasState(firstBlock.Root()))
+	require.Equal(t, stateBackfillStageIdle, syncerVM.stateBackfillProgress().Stage)
+
+	b, err := syncerVM.startStateBackfill("")
+	require.NoError(t, err)
+	_, err = syncerVM.startStateBackfill("")
+	require.ErrorIs(t, err, errBackfillRunning)
+	require.Eventually(t, func() bool {
+		return !b.Progress().Running()
+	}, 30*time.Second, 10*time.Millisecond)
+
+	progress := syncerVM.stateBackfillProgress()
+	require.Equal(t, stateBackfillStageDone, progress.Stage, progress.Error)
+	require.Zero(t, progress.StartHeight)
+	require.Equal(t, numToGen, progress.TargetHeight)
+	require.EqualValues(t, 32, progress.BlocksDownloaded)
+	require.Equal(t, numToGen-1, progress.ExecutedHeight)
+	details, err := syncerVM.HealthCheck(context.Background())
+	require.NoError(t, err)
+	require.Contains(t, details, "stateBackfill")
+
+	for number := uint64(1); number < numToGen; number++ {
+		serverBlock := serverVM.blockChain.GetBlockByNumber(number)
+		syncerBlock := syncerVM.blockChain.GetBlockByNumber(number)
+		require.NotNil(t, syncerBlock, "missing block %d", number)
+		require.Equal(t, serverBlock.Hash(), syncerBlock.Hash())
+		require.True(t, syncerVM.blockChain.HasState(syncerBlock.Root()), "missing state of block %d", number)
+	}
+	for _, tx := range firstBlock.Transactions() {
+		require.NotNil(t, rawdb.ReadTxLookupEntry(syncerVM.chaindb, tx.Hash()))
+	}
+
+	// A completed backfill can be restarted, and resumes from the last
+	// reconstructed state.
+	b, err = syncerVM.startStateBackfill("")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return !b.Progress().Running()
+	}, 30*time.Second, 10*time.Millisecond)
+	progress = b.Progress()
+	require.Equal(t, stateBackfillStageDone, progress.Stage, progress.Error)
+	require.Zero(t, progress.BlocksDownloaded)
+	require.Equal(t, numToGen-1, progress.ExecutedHeight)
+}
+
+func TestStateBackfillFromSnapshotFile(t *testing.T) {
+	rand.Seed(1)
+	numToGen := parentsToGet + uint64(32)
+	test := syncTest{
+		syncableInterval:   numToGen,
+		stateSyncMinBlocks: 50, // must be less than [syncableInterval] to perform sync
+		syncMode:           block.StateSyncStatic,
+	}
+	vmSetup := createSyncServerAndClientVMs(t, test, int(numToGen))
+	testSyncerVM(t, vmSetup, test)
+
+	var (
+		serverVM = vmSetup.serverVM
+		syncerVM = vmSetup.syncerVM
+		dir      = t.TempDir()
+	)
+	// Export the state of a recent block, which the server still holds.
+	height := numToGen - 8
+	trustedBlock := serverVM.blockChain.GetBlockByNumber(height)
+	require.True(t, serverVM.blockChain.HasState(trustedBlock.Root()))
+	summary, err := message.NewSyncSummary(trustedBlock.Hash(), height, trustedBlock.Root(), types.EmptyRootHash)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	_, err = exportSyncSnapshot(
+		context.Background(),
+		&buf,
+		summary,
+		serverVM.blockChain,
+		serverVM.blockChain.StateCache().TrieDB(),
+		serverVM.atomicTrie.TrieDB(),
+		serverVM.chaindb,
+	)
+	require.NoError(t, err)
+	path := filepath.Join(dir, "snapshot")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+
+	// Replace the value of the first trie node with another valid node blob.
+	tamperedPath := filepath.Join(dir, "tampered")
+	sr, err := openSyncSnapshot(path)
+	require.NoError(t, err)
+	tampered, err := os.Create(tamperedPath)
+	require.NoError(t, err)
+	sw, err := newSyncSnapshotWriter(tampered, summary)
+	require.NoError(t, err)
+	var (
+		record   syncSnapshotRecord
+		nodeBlob []byte
+	)
+	for {
+		require.NoError(t, sr.stream.Decode(&record))
+		if record.Kind == syncSnapshotRecordEnd {
+			break
+		}
+		if record.Kind == syncSnapshotRecordTrieNode {
+			if nodeBlob == nil {
+				nodeBlob = record.Value
+			} else if bytes.Equal(nodeBlob, record.Value) {
+				continue
+			}
+			record.Value = nodeBlob
+		}
+		require.NoError(t, sw.write(record.Kind, record.Key, record.Value))
+	}
+	require.NoError(t, sw.close())
+	require.NoError(t, tampered.Close())
+	require.NoError(t, sr.Close())
+
+	truncatedPath := filepath.Join(dir, "truncated")
+	require.NoError(t, os.WriteFile(truncatedPath, buf.Bytes()[:buf.Len()/2], 0o600))
+
+	backfill := func(path string) StateBackfillProgress {
+		b, err := syncerVM.startStateBackfill(path)
+		require.NoError(t, err)
+		require.Eventually(t, func() bool {
+			return !b.Progress().Running()
+		}, 30*time.Second, 10*time.Millisecond)
+		return b.Progress()
+	}
+
+	// Invalid files are rejected without importing their state.
+	progress := backfill(tamperedPath)
+	require.Equal(t, stateBackfillStageFailed, progress.Stage)
+	require.Contains(t, progress.Error, errSyncSnapshotInvalid.Error())
+	progress = backfill(truncatedPath)
+	require.Equal(t, stateBackfillStageFailed, progress.Stage)
+	require.False(t, syncerVM.blockChain.HasState(trustedBlock.Root()))
+
+	progress = backfill(path)
+	require.Equal(t, stateBackfillStageDone, progress.Stage, progress.Error)
+	require.Equal(t, height, progress.StartHeight)
+	require.Equal(t, numToGen-1, progress.ExecutedHeight)
+	for number := height; number < numToGen; number++ {
+		syncerBlock := syncerVM.blockChain.GetBlockByNumber(number)
+		require.NotNil(t, syncerBlock, "missing block %d", number)
+		require.True(t, syncerVM.blockChain.HasState(syncerBlock.Root()), "missing state of block %d", number)
+	}
+}
+
 func TestStateSyncToggleEnabledToDisabled(t *testing.T) {
 	rand.Seed(1)
 	// Hack: registering metrics uses global variables, so we need to disable metrics here so that we can initialize the VM twice.
@@ -487,14 +922,26 @@ func testSyncerVM(t *testing.T, vmSetup *syncVMSetup, test syncTest) {
 
 	// If the test is expected to error, assert the correct error is returned and finish the test.
 	err = syncerVM.StateSyncClient.Error()
+	progress := syncerVM.StateSyncClient.Progress()
+	require.Equal(retrievedSummary.Height(), progress.SummaryHeight)
+	require.False(progress.Syncing())
+	_, healthErr := syncerVM.HealthCheck(context.Background())
 	if test.expectedErr != nil {
 		require.ErrorIs(err, test.expectedErr)
+		require.Equal(stateSyncStageFailed, progress.Stage)
+		require.Error(healthErr)
 		// Note we re-open the database here to avoid a closed error when the test is for a shutdown VM.
 		chaindb := Database{prefixdb.NewNested(ethDBPrefix, syncerVM.db)}
 		assertSyncPerformedHeights(t, chaindb, map[uint64]struct{}{})
 		return
 	}
 	require.NoError(err, "state sync failed")
+	require.Equal(stateSyncStageDone, progress.Stage)
+	// The chain is unhealthy until the first verification of the synced
+	// state, if enabled, completes in the background.
+	if healthErr != nil {
+		require.ErrorContains(healthErr, "synced state is being verified")
+	}
 
 	// set [syncerVM] to bootstrapping and verify the last accepted block has been updated correctly
 	// and that we can bootstrap and process some blocks.
@@ -80,6 +80,7 @@ import (
 	"github.com/ava-labs/avalanchego/snow"
 	"github.com/ava-labs/avalanchego/snow/consensus/snowman"
 	"github.com/ava-labs/avalanchego/snow/engine/snowman/block"
+	"github.com/ava-labs/avalanchego/utils/compression"
 	"github.com/ava-labs/avalanchego/utils/crypto/secp256k1"
 	"github.com/ava-labs/avalanchego/utils/formatting/address"
 	"github.com/ava-labs/avalanchego/utils/logging"
@@ -136,6 +137,10 @@ const (
 	bytesToIDCacheSize     = 5 * units.MiB
 	warpSignatureCacheSize = 500
 
+	// Number of times requests to peers made outside of state sync, such as
+	// code refetches and state backfills, are attempted before failing
+	peerSyncMaxAttempts = 16
+
 	// Prefixes for metrics gatherers
 	ethMetricsPrefix        = "eth"
 	sdkMetricsPrefix        = "sdk"
@@ -143,6 +148,13 @@ const (
 
 	targetAtomicTxsSize = 40 * units.KiB
 
+	// Directory under the chain data directory storing the blob pool
+	blobPoolDataDir = "blobpool"
+
+	// Files under the chain data directory journaling the transaction pool
+	txPoolJournalFile       = "transactions.rlp"
+	txPoolRemoteJournalFile = "remote_transactions.rlp"
+
 	// gossip constants
 	pushGossipDiscardedElements          = 16_384
 	txGossipBloomMinTargetElements       = 8 * 1024
@@ -323,6 +335,8 @@ type VM struct {
 	// State sync server and client
 	StateSyncServer
 	StateSyncClient
+	// stateBackfiller is the most recently started state backfill, if any.
+	stateBackfiller avalancheUtils.Atomic[*stateBackfiller]
 
 	// Avalanche Warp Messaging backend
 	// Used to serve BLS signatures of warp messages over RPC
@@ -477,16 +491,22 @@ func (vm *VM) Initialize(
 	g.Config.AvalancheContext = params.AvalancheContext{
 		SnowCtx: chainCtx,
 	}
-	vm.syntacticBlockValidator = NewBlockValidator(extDataHashes)
+	vm.syntacticBlockValidator = NewBlockValidator(extDataHashes, vm.config.BlobPoolEnabled)
 
 	// Ensure that non-standard commit interval is not allowed for production networks
 	if avalanchegoConstants.ProductionNetworkIDs.Contains(chainCtx.NetworkID) {
 		if vm.config.CommitInterval != defaultCommitInterval {
 			return fmt.Errorf("cannot start non-local network with commit interval %d", vm.config.CommitInterval)
 		}
+		if vm.config.BlobPoolEnabled {
+			return errors.New("cannot start non-local network with the blob pool enabled")
+		}
 		if vm.config.StateSyncCommitInterval != defaultSyncableCommitInterval {
 			return fmt.Errorf("cannot start non-local network with syncable interval %d", vm.config.StateSyncCommitInterval)
 		}
+		if len(vm.config.StateSyncPinnedSummaryHeights) > 0 {
+			return errors.New("cannot start non-local network with pinned summary heights")
+		}
 	}
 
 	// Free the memory of the extDataHash map that is not used (i.e. if mainnet
@@ -522,6 +542,13 @@ func (vm *VM) Initialize(
 	vm.ethConfig.TxPool.AccountQueue = vm.config.TxPoolAccountQueue
 	vm.ethConfig.TxPool.GlobalQueue = vm.config.TxPoolGlobalQueue
 	vm.ethConfig.TxPool.Lifetime = vm.config.TxPoolLifetime.Duration
+	vm.ethConfig.TxPool.Rejournal = vm.config.TxPoolRejournal.Duration
+	if vm.config.TxPoolJournalEnabled && vm.ctx.ChainDataDir != "" {
+		vm.ethConfig.TxPool.Journal = filepath.Join(vm.ctx.ChainDataDir, txPoolJournalFile)
+		if vm.config.TxPoolJournalRemotes {
+			vm.ethConfig.TxPool.RemoteJournal = filepath.Join(vm.ctx.ChainDataDir, txPoolRemoteJournalFile)
+		}
+	}
 
 	vm.ethConfig.AllowUnfinalizedQueries = vm.config.AllowUnfinalizedQueries
 	vm.ethConfig.AllowUnprotectedTxs = vm.config.AllowUnprotectedTxs
@@ -532,6 +559,7 @@ func (vm *VM) Initialize(
 	vm.ethConfig.TrieDirtyCache = vm.config.TrieDirtyCache
 	vm.ethConfig.TrieDirtyCommitTarget = vm.config.TrieDirtyCommitTarget
 	vm.ethConfig.TriePrefetcherParallelism = vm.config.TriePrefetcherParallelism
+	vm.ethConfig.TriePrefetcherAccessLists = vm.config.TriePrefetcherAccessLists
 	vm.ethConfig.SnapshotCache = vm.config.SnapshotCache
 	vm.ethConfig.AcceptorQueueLimit = vm.config.AcceptorQueueLimit
 	vm.ethConfig.PopulateMissingTries = vm.config.PopulateMissingTries
@@ -548,6 +576,16 @@ func (vm *VM) Initialize(
 	vm.ethConfig.AcceptedCacheSize = vm.config.AcceptedCacheSize
 	vm.ethConfig.TransactionHistory = vm.config.TransactionHistory
 	vm.ethConfig.SkipTxIndexing = vm.config.SkipTxIndexing
+	vm.ethConfig.AssetTransferIndexing = vm.config.AssetTransferIndexing
+	vm.ethConfig.OpcodeStats = vm.config.OpcodeStatsEnabled
+	vm.ethConfig.ParallelTxExecutionWorkers = vm.config.ParallelTxExecutionWorkers
+	vm.ethConfig.Miner.Ordering = vm.config.MinerOrdering
+	vm.ethConfig.Miner.PriorityAddresses = vm.config.MinerPriorityAddresses
+	vm.ethConfig.BlobPoolEnabled = vm.config.BlobPoolEnabled
+	vm.ethConfig.BlobPool.Datadir = ""
+	if vm.config.BlobPoolEnabled && vm.ctx.ChainDataDir != "" {
+		vm.ethConfig.BlobPool.Datadir = filepath.Join(vm.ctx.ChainDataDir, blobPoolDataDir)
+	}
 
 	// Create directory for offline pruning
 	if len(vm.ethConfig.OfflinePruningDataDirectory) != 0 {
@@ -697,7 +735,7 @@ func (vm *VM) initializeChain(lastAcceptedHash common.Hash) error {
 		vm.chaindb,
 		vm.config.EthBackendSettings(),
 		lastAcceptedHash,
-		dummy.NewFakerWithClock(callbacks, &vm.clock),
+		dummy.NewFakerWithModeAndClock(callbacks, dummy.Mode{ModeAllowBlobs: vm.config.BlobPoolEnabled}, &vm.clock),
 		&vm.clock,
 	)
 	if err != nil {
@@ -765,16 +803,23 @@ func (vm *VM) initializeStateSyncClient(lastAcceptedHeight uint64) error {
 		}
 	}
 
+	stateSyncCompression, err := compression.TypeFromString(vm.config.StateSyncCompression)
+	if err != nil {
+		return fmt.Errorf("failed to parse state sync compression: %w", err)
+	}
 	vm.StateSyncClient = NewStateSyncClient(&stateSyncClientConfig{
 		chain: vm.eth,
 		state: vm.State,
 		client: statesyncclient.NewClient(
 			&statesyncclient.ClientConfig{
-				NetworkClient:    vm.client,
-				Codec:            vm.networkCodec,
-				Stats:            stats.NewClientSyncerStats(),
-				StateSyncNodeIDs: stateSyncIDs,
-				BlockParser:      vm,
+				NetworkClient:      vm.client,
+				Codec:              vm.networkCodec,
+				Stats:              stats.NewClientSyncerStats(),
+				StateSyncNodeIDs:   stateSyncIDs,
+				BlockParser:        vm,
+				CompactLeafsBatch:  vm.config.StateSyncCompactLeafsBatch,
+				Compression:        stateSyncCompression,
+				AdaptiveLeafsLimit: vm.config.StateSyncAdaptiveRequestSize,
 			},
 		),
 		enabled:              stateSyncEnabled,
@@ -787,6 +832,12 @@ func (vm *VM) initializeStateSyncClient(lastAcceptedHeight uint64) error {
 		acceptedBlockDB:      vm.acceptedBlockDB,
 		db:                   vm.db,
 		atomicBackend:        vm.atomicBackend,
+		verifyState:          vm.config.StateSyncVerify,
+		repairState:          vm.config.StateSyncVerifyRepair,
+		snapshotFile:         vm.config.StateSyncSnapshotFile,
+		chainDataDir:         vm.ctx.ChainDataDir,
+		networkCodec:         vm.networkCodec,
+		blockParser:          vm,
 		toEngine:             vm.toEngine,
 	})
 
@@ -799,12 +850,32 @@ func (vm *VM) initializeStateSyncClient(lastAcceptedHeight uint64) error {
 	return nil
 }
 
+// newPeerSyncClient returns a client fetching state sync data from peers
+// outside of state sync.
+func (vm *VM) newPeerSyncClient() (statesyncclient.Client, error) {
+	compressionType, err := compression.TypeFromString(vm.config.StateSyncCompression)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse state sync compression: %w", err)
+	}
+	return statesyncclient.NewClient(
+		&statesyncclient.ClientConfig{
+			NetworkClient: vm.client,
+			Codec:         vm.networkCodec,
+			Stats:         stats.NewNoOpStats(),
+			BlockParser:   vm,
+			MaxAttempts:   peerSyncMaxAttempts,
+			Compression:   compressionType,
+		},
+	), nil
+}
+
 // initializeHandlers should be called after [vm.chain] is initialized.
 func (vm *VM) initializeHandlers() {
 	vm.StateSyncServer = NewStateSyncServer(&stateSyncServerConfig{
 		Chain:            vm.blockChain,
 		AtomicTrie:       vm.atomicTrie,
 		SyncableInterval: vm.config.StateSyncCommitInterval,
+		PinnedHeights:    vm.config.StateSyncPinnedSummaryHeights,
 	})
 
 	// Add p2p warp message warpHandler
@@ -1010,10 +1081,8 @@ func (vm *VM) onFinalizeAndAssemble(header *types.Header, state *state.StateDB,
 
 func (vm *VM) onExtraStateChange(block *types.Block, state *state.StateDB) (*big.Int, *big.Int, error) {
 	var (
-		batchContribution *big.Int = big.NewInt(0)
-		batchGasUsed      *big.Int = big.NewInt(0)
-		header                     = block.Header()
-		rules                      = vm.chainConfig.Rules(header.Number, header.Time)
+		header = block.Header()
+		rules  = vm.chainConfig.Rules(header.Number, header.Time)
 	)
 
 	txs, err := ExtractAtomicTxs(block.ExtData(), rules.IsApricotPhase5, vm.codec)
@@ -1040,6 +1109,28 @@ func (vm *VM) onExtraStateChange(block *types.Block, state *state.StateDB) (*big
 			return nil, nil, err
 		}
 	}
+	return vm.applyAtomicTxs(block, state, txs, rules)
+}
+
+// replayExtraStateChange applies the atomic transactions of the accepted
+// [block] to [state] without verifying them or updating the atomic backend,
+// to re-execute blocks whose atomic operations were already applied.
+func (vm *VM) replayExtraStateChange(block *types.Block, state *state.StateDB) (*big.Int, *big.Int, error) {
+	rules := vm.chainConfig.Rules(block.Number(), block.Time())
+	txs, err := ExtractAtomicTxs(block.ExtData(), rules.IsApricotPhase5, vm.codec)
+	if err != nil {
+		return nil, nil, err
+	}
+	return vm.applyAtomicTxs(block, state, txs, rules)
+}
+
+// applyAtomicTxs applies the EVM state transfers of the atomic [txs] of
+// [block] to [state] and returns their block fee contribution and gas used.
+func (vm *VM) applyAtomicTxs(block *types.Block, state *state.StateDB, txs []*Tx, rules params.Rules) (*big.Int, *big.Int, error) {
+	var (
+		batchContribution *big.Int = big.NewInt(0)
+		batchGasUsed      *big.Int = big.NewInt(0)
+	)
 
 	// If there are no transactions, we can return early.
 	if len(txs) == 0 {
@@ -1047,6 +1138,8 @@ func (vm *VM) onExtraStateChange(block *types.Block, state *state.StateDB) (*big
 	}
 
 	for _, tx := range txs {
+		// Attribute the multi-coin balance changes of [tx] to its ID.
+		state.SetAtomicTxContext(common.Hash(tx.ID()))
 		if err := tx.UnsignedAtomicTx.EVMStateTransfer(vm.ctx, state); err != nil {
 			return nil, nil, err
 		}
@@ -1161,6 +1254,12 @@ func (vm *VM) initBlockBuilding() error {
 		vm.ethTxPushGossiper.Set(ethTxPushGossiper)
 	}
 
+	// Transactions restored from the transaction pool journals were never
+	// gossiped by this node, so push them to peers.
+	for _, tx := range vm.txPool.Restored() {
+		ethTxPushGossiper.Add(&GossipEthTx{tx})
+	}
+
 	if vm.atomicTxPushGossiper == nil {
 		vm.atomicTxPushGossiper, err = gossip.NewPushGossiper[*GossipAtomicTx](
 			atomicTxGossipMarshaller,
@@ -1535,6 +1634,20 @@ func (vm *VM) CreateHandlers(context.Context) (map[string]http.Handler, error) {
 		enabledAPIs = append(enabledAPIs, "warp")
 	}
 
+	if vm.config.BlockBuilderAPIEnabled {
+		if err := handler.RegisterName("debug", &BlockBuilderAPI{vm}); err != nil {
+			return nil, err
+		}
+		enabledAPIs = append(enabledAPIs, "debug-block-builder")
+	}
+
+	if vm.config.StateSyncAPIEnabled {
+		if err := handler.RegisterName("statesync", &StateSyncAPI{vm}); err != nil {
+			return nil, err
+		}
+		enabledAPIs = append(enabledAPIs, "statesync")
+	}
+
 	log.Info(fmt.Sprintf("Enabled APIs: %s", strings.Join(enabledAPIs, ", ")))
 	apis[ethRPCEndpoint] = handler
 	apis[ethWSEndpoint] = handler.WebsocketHandlerWithDuration(
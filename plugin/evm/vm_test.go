@@ -6,10 +6,12 @@ package evm
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
@@ -17,6 +19,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/holiman/uint256"
@@ -413,6 +416,39 @@ func TestVMConfigDefaults(t *testing.T) {
 	require.NoError(t, vm.Shutdown(context.Background()))
 }
 
+func TestSendBundleAPIDisabledByDefault(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		configJSON string
+		enabled    bool
+	}{
+		{name: "default"},
+		{name: "enabled", configJSON: `{"eth-apis": ["eth", "eth-bundle"]}`, enabled: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, vm, _, _, _ := GenesisVM(t, true, "", test.configJSON, "")
+			defer func() {
+				require.NoError(t, vm.Shutdown(context.Background()))
+			}()
+			handlers, err := vm.CreateHandlers(context.Background())
+			require.NoError(t, err)
+			server := httptest.NewServer(handlers[ethRPCEndpoint])
+			defer server.Close()
+			client, err := rpc.DialHTTP(server.URL)
+			require.NoError(t, err)
+			defer client.Close()
+
+			var hash common.Hash
+			err = client.Call(&hash, "eth_sendBundle", eth.SendBundleArgs{})
+			if test.enabled {
+				require.ErrorContains(t, err, "bundle has no transactions")
+			} else {
+				require.ErrorContains(t, err, "the method eth_sendBundle does not exist/is not available")
+			}
+		})
+	}
+}
+
 func TestVMNilConfig(t *testing.T) {
 	_, vm, _, _, _ := GenesisVM(t, false, "", "", "")
 
@@ -3988,6 +4024,67 @@ func TestNoBlobsAllowed(t *testing.T) {
 	require.ErrorContains(err, "blobs not enabled on avalanche networks")
 }
 
+func TestBlobPoolEnabled(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	issuer, vm, _, _, _ := GenesisVM(t, true, genesisJSONCancun, `{"blob-pool-enabled": true}`, "")
+	defer func() { require.NoError(vm.Shutdown(ctx)) }()
+
+	newTxPoolHeadChan := make(chan core.NewTxPoolReorgEvent, 1)
+	vm.txPool.SubscribeNewReorgEvent(newTxPoolHeadChan)
+
+	var (
+		blob          = kzg4844.Blob{}
+		commitment, _ = kzg4844.BlobToCommitment(blob)
+		proof, _      = kzg4844.ComputeBlobProof(blob, commitment)
+	)
+	tx, err := types.SignTx(types.NewTx(&types.BlobTx{
+		ChainID:    uint256.MustFromBig(vm.chainID),
+		Nonce:      0,
+		GasTipCap:  uint256.NewInt(1),
+		GasFeeCap:  uint256.MustFromBig(new(big.Int).Mul(initialBaseFee, big.NewInt(2))),
+		Gas:        params.TxGas,
+		To:         testEthAddrs[1],
+		BlobFeeCap: uint256.NewInt(params.BlobTxMinBlobGasprice),
+		BlobHashes: []common.Hash{kzg4844.CalcBlobHashV1(sha256.New(), &commitment)},
+		Value:      new(uint256.Int),
+		Sidecar: &types.BlobTxSidecar{
+			Blobs:       []kzg4844.Blob{blob},
+			Commitments: []kzg4844.Commitment{commitment},
+			Proofs:      []kzg4844.Proof{proof},
+		},
+	}), types.NewCancunSigner(vm.chainID), testKeys[0].ToECDSA())
+	require.NoError(err)
+	require.NoError(vm.txPool.Add([]*types.Transaction{tx}, true, true)[0])
+	require.True(vm.txPool.Has(tx.Hash()))
+
+	<-issuer
+
+	blk, err := vm.BuildBlock(ctx)
+	require.NoError(err)
+	require.NoError(blk.Verify(ctx))
+	require.NoError(vm.SetPreference(ctx, blk.ID()))
+	require.NoError(blk.Accept(ctx))
+
+	newHead := <-newTxPoolHeadChan
+	require.Equal(common.Hash(blk.ID()), newHead.Head.Hash())
+
+	// The blob transaction is included without its sidecar and the blob gas
+	// is accounted for in the header.
+	ethBlock := blk.(*chain.BlockWrapper).Block.(*Block).ethBlock
+	require.Len(ethBlock.Transactions(), 1)
+	require.Equal(tx.Hash(), ethBlock.Transactions()[0].Hash())
+	require.Nil(ethBlock.Transactions()[0].BlobTxSidecar())
+	require.Equal(uint64(params.BlobTxBlobGasPerBlob), *ethBlock.BlobGasUsed())
+	require.Zero(*ethBlock.ExcessBlobGas())
+
+	receipts := vm.blockChain.GetReceiptsByHash(ethBlock.Hash())
+	require.Len(receipts, 1)
+	require.Equal(uint64(params.BlobTxBlobGasPerBlob), receipts[0].BlobGasUsed)
+	require.False(vm.txPool.Has(tx.Hash()))
+}
+
 func TestMinFeeSetAtEtna(t *testing.T) {
 	require := require.New(t)
 	now := time.Now()
@@ -8,6 +8,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -17,6 +18,7 @@ import (
 	"github.com/ava-labs/coreth/sync/client/stats"
 
 	"github.com/ava-labs/avalanchego/codec"
+	"github.com/ava-labs/avalanchego/utils/compression"
 	"github.com/ava-labs/avalanchego/version"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -82,6 +84,25 @@ type client struct {
 	stateSyncNodeIdx uint32
 	stats            stats.ClientSyncerStats
 	blockParser      EthBlockParser
+	maxAttempts      int
+	// compactLeafsBatch is the number of contiguous pages of leafs fetched
+	// with a single range proof from peers serving compact leafs requests.
+	compactLeafsBatch int
+
+	// compression of the responses requested from peers, if [compressor] is
+	// not nil.
+	compression        compression.Type
+	compressor         compression.Compressor
+	adaptiveLeafsLimit bool
+
+	peersLock sync.Mutex
+	// compressionFailures are the number of responses to compressed requests
+	// from each peer that could not be decompressed, or
+	// [maxCompressionFailures] once a peer failed a compressed request but
+	// served it without compression.
+	compressionFailures map[ids.NodeID]int
+	// leafsLimits are the number of leafs to request from each peer.
+	leafsLimits map[ids.NodeID]uint16
 }
 
 type ClientConfig struct {
@@ -90,6 +111,27 @@ type ClientConfig struct {
 	Stats            stats.ClientSyncerStats
 	StateSyncNodeIDs []ids.NodeID
 	BlockParser      EthBlockParser
+	// MaxAttempts is the number of times a request is attempted before
+	// failing. Zero retries requests until their context expires.
+	MaxAttempts int
+	// CompactLeafsBatch is the number of contiguous pages of leafs requested
+	// from peers running at least [CompactLeafsVersion] with a single range
+	// proof. Values below 2 only send [message.LeafsRequest]s. Compact leafs
+	// requests are not sent to [StateSyncNodeIDs], whose versions are unknown.
+	CompactLeafsBatch int
+	// Compression is the compression of the responses requested from peers
+	// running at least [CompressedRequestVersion]. Zero or
+	// [compression.TypeNone] disables compression. Compression is not
+	// requested from [StateSyncNodeIDs], whose versions are unknown. A failed
+	// compressed request is sent again to the same peer without compression,
+	// and compression is no longer requested from peers that serve it, nor
+	// from peers after [maxCompressionFailures] of their responses could not
+	// be decompressed.
+	Compression compression.Type
+	// AdaptiveLeafsLimit adapts the number of leafs requested from each peer,
+	// up to the Limit of the requests, to the latency and size of its
+	// responses.
+	AdaptiveLeafsLimit bool
 }
 
 type EthBlockParser interface {
@@ -97,12 +139,24 @@ type EthBlockParser interface {
 }
 
 func NewClient(config *ClientConfig) *client {
+	compressionType := compressionOrNone(config.Compression)
+	compressor, err := message.NewCompressor(compressionType)
+	if err != nil {
+		log.Warn("disabling compression of state sync responses", "compression", compressionType, "err", err)
+	}
 	return &client{
-		networkClient:  config.NetworkClient,
-		codec:          config.Codec,
-		stats:          config.Stats,
-		stateSyncNodes: config.StateSyncNodeIDs,
-		blockParser:    config.BlockParser,
+		networkClient:       config.NetworkClient,
+		codec:               config.Codec,
+		stats:               config.Stats,
+		stateSyncNodes:      config.StateSyncNodeIDs,
+		blockParser:         config.BlockParser,
+		maxAttempts:         config.MaxAttempts,
+		compactLeafsBatch:   config.CompactLeafsBatch,
+		compression:         compressionType,
+		compressor:          compressor,
+		adaptiveLeafsLimit:  config.AdaptiveLeafsLimit,
+		compressionFailures: make(map[ids.NodeID]int),
+		leafsLimits:         make(map[ids.NodeID]uint16),
 	}
 }
 
@@ -112,6 +166,11 @@ func NewClient(config *ClientConfig) *client {
 // - response keys do not correspond to the requested range.
 // - response does not contain a valid merkle proof.
 func (c *client) GetLeafs(ctx context.Context, req message.LeafsRequest) (message.LeafsResponse, error) {
+	if c.compactLeafsBatch > 1 && len(c.stateSyncNodes) == 0 {
+		if response, ok := c.getCompactLeafs(ctx, req); ok {
+			return response, nil
+		}
+	}
 	data, err := c.get(ctx, req, parseLeafsResponse)
 	if err != nil {
 		return message.LeafsResponse{}, err
@@ -287,12 +346,6 @@ func parseCode(codec codec.Manager, req message.Request, data []byte) (interface
 // Returns the parsed interface returned from [parseFn].
 // Thread safe
 func (c *client) get(ctx context.Context, request message.Request, parseFn parseResponseFn) (interface{}, error) {
-	// marshal the request into requestBytes
-	requestBytes, err := message.RequestToBytes(c.codec, request)
-	if err != nil {
-		return nil, err
-	}
-
 	metric, err := c.stats.GetMetric(request)
 	if err != nil {
 		return nil, err
@@ -312,25 +365,50 @@ func (c *client) get(ctx context.Context, request message.Request, parseFn parse
 				return nil, ctxErr
 			}
 		}
+		if c.maxAttempts > 0 && attempt >= c.maxAttempts {
+			return nil, fmt.Errorf("request failed after %d attempts with last error %w", attempt, err)
+		}
 
 		metric.IncRequested()
 
 		var (
-			response []byte
-			nodeID   ids.NodeID
-			start    time.Time = time.Now()
+			response   []byte
+			nodeID     ids.NodeID
+			sent       message.Request
+			compressed bool
+			start      time.Time = time.Now()
 		)
+		// buildRequest marshals [request] as sent to [nodeID], which is
+		// recorded in [sent] and [compressed].
+		buildRequest := func(nodeID ids.NodeID, nodeVersion *version.Application) ([]byte, error) {
+			var (
+				requestBytes []byte
+				err          error
+			)
+			sent, requestBytes, compressed, err = c.buildRequest(request, nodeID, nodeVersion)
+			return requestBytes, err
+		}
 		if len(c.stateSyncNodes) == 0 {
-			response, nodeID, err = c.networkClient.SendAppRequestAny(ctx, StateSyncVersion, requestBytes)
+			response, nodeID, err = c.networkClient.SendAppRequestAnyWith(ctx, StateSyncVersion, buildRequest)
 		} else {
 			// get the next nodeID using the nodeIdx offset. If we're out of nodes, loop back to 0
 			// we do this every attempt to ensure we get a different node each time if possible.
 			nodeIdx := atomic.AddUint32(&c.stateSyncNodeIdx, 1)
 			nodeID = c.stateSyncNodes[nodeIdx%uint32(len(c.stateSyncNodes))]
 
-			response, err = c.networkClient.SendAppRequest(ctx, nodeID, requestBytes)
+			var requestBytes []byte
+			if requestBytes, err = buildRequest(nodeID, nil); err == nil {
+				response, err = c.networkClient.SendAppRequest(ctx, nodeID, requestBytes)
+			}
+		}
+		if err != nil && compressed {
+			start = time.Now()
+			if response, err = c.retryUncompressed(ctx, nodeID, sent); err == nil {
+				compressed = false
+			}
 		}
-		metric.UpdateRequestLatency(time.Since(start))
+		latency := time.Since(start)
+		metric.UpdateRequestLatency(latency)
 
 		if err != nil {
 			ctx := make([]interface{}, 0, 8)
@@ -340,22 +418,32 @@ func (c *client) get(ctx context.Context, request message.Request, parseFn parse
 			ctx = append(ctx, "attempt", attempt, "request", request, "err", err)
 			log.Debug("request failed, retrying", ctx...)
 			metric.IncFailed()
-			c.networkClient.TrackBandwidth(nodeID, 0)
+			c.networkClient.TrackBandwidth(nodeID, 0, 0)
+			if leafsRequest, ok := sent.(message.LeafsRequest); ok {
+				c.onLeafsRequestFailed(nodeID, leafsRequest.Limit, request.(message.LeafsRequest).Limit)
+			}
 			time.Sleep(failedRequestSleepInterval)
 			continue
 		} else {
-			responseIntf, numElements, err = parseFn(c.codec, request, response)
+			var decompressed []byte
+			decompressed, err = c.decompressResponse(nodeID, response, compressed)
+			if err == nil {
+				responseIntf, numElements, err = parseFn(c.codec, sent, decompressed)
+			}
 			if err != nil {
 				lastErr = err
 				log.Debug("could not validate response, retrying", "nodeID", nodeID, "attempt", attempt, "request", request, "err", err)
-				c.networkClient.TrackBandwidth(nodeID, 0)
+				c.networkClient.TrackInvalidResponse(nodeID)
 				metric.IncFailed()
 				metric.IncInvalidResponse()
 				continue
 			}
 
 			bandwidth := float64(len(response)) / (time.Since(start).Seconds() + epsilon)
-			c.networkClient.TrackBandwidth(nodeID, bandwidth)
+			c.networkClient.TrackBandwidth(nodeID, bandwidth, len(response))
+			if leafsRequest, ok := sent.(message.LeafsRequest); ok {
+				c.updateLeafsLimit(nodeID, leafsRequest.Limit, request.(message.LeafsRequest).Limit, numElements, len(response), latency)
+			}
 			metric.IncSucceeded()
 			metric.IncReceived(int64(numElements))
 			return responseIntf, nil
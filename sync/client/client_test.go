@@ -6,6 +6,7 @@ package statesyncclient
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"strings"
@@ -26,6 +27,7 @@ import (
 	handlerstats "github.com/ava-labs/coreth/sync/handlers/stats"
 	"github.com/ava-labs/coreth/sync/syncutils"
 	"github.com/ava-labs/coreth/triedb"
+	"github.com/ava-labs/coreth/utils"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 )
@@ -854,6 +856,23 @@ func TestGetLeafsRetries(t *testing.T) {
 	assert.True(t, strings.Contains(err.Error(), context.Canceled.Error()))
 }
 
+func TestGetCodeMaxAttempts(t *testing.T) {
+	mockNetClient := &mockNetwork{}
+	client := NewClient(&ClientConfig{
+		NetworkClient: mockNetClient,
+		Codec:         message.Codec,
+		Stats:         clientstats.NewNoOpStats(),
+		BlockParser:   mockBlockParser,
+		MaxAttempts:   2,
+	})
+
+	invalidResponse := []byte("invalid response")
+	mockNetClient.mockResponse(3, nil, invalidResponse)
+	_, err := client.GetCode(context.Background(), []common.Hash{{1}})
+	assert.Error(t, err)
+	assert.Equal(t, uint(2), mockNetClient.numCalls)
+}
+
 func TestStateSyncNodes(t *testing.T) {
 	mockNetClient := &mockNetwork{}
 
@@ -892,3 +911,155 @@ func TestStateSyncNodes(t *testing.T) {
 	assert.Contains(t, mockNetClient.nodesRequested, stateSyncNodes[2])
 	assert.Contains(t, mockNetClient.nodesRequested, stateSyncNodes[3])
 }
+
+func TestGetCompactLeafs(t *testing.T) {
+	rand.Seed(1)
+
+	const (
+		leafsLimit = 1024
+		batchSize  = 4
+	)
+
+	trieDB := triedb.NewDatabase(rawdb.NewMemoryDatabase(), nil)
+	largeTrieRoot, _, _ := syncutils.GenerateTrie(t, trieDB, 100_000, common.HashLength)
+	smallTrieRoot, _, _ := syncutils.GenerateTrie(t, trieDB, leafsLimit+leafsLimit/2, common.HashLength)
+
+	handler := handlers.NewLeafsRequestHandler(trieDB, nil, message.Codec, handlerstats.NewNoopHandlerStats())
+	mockNetClient := &mockNetwork{}
+	client := NewClient(&ClientConfig{
+		NetworkClient:     mockNetClient,
+		Codec:             message.Codec,
+		Stats:             clientstats.NewNoOpStats(),
+		BlockParser:       mockBlockParser,
+		CompactLeafsBatch: batchSize,
+	})
+
+	ctx := context.Background()
+	newRequest := func(root common.Hash) message.LeafsRequest {
+		return message.LeafsRequest{
+			Root:     root,
+			Start:    bytes.Repeat([]byte{0x00}, common.HashLength),
+			End:      bytes.Repeat([]byte{0xff}, common.HashLength),
+			Limit:    leafsLimit,
+			NodeType: message.StateTrieNode,
+		}
+	}
+	// getPages returns the responses to the compact leafs requests for
+	// [numPages] pages of [request], with a proof for the last page.
+	getPages := func(t *testing.T, request message.LeafsRequest, numPages int) [][]byte {
+		t.Helper()
+		pageReq := message.CompactLeafsRequest{
+			Root:       request.Root,
+			Start:      request.Start,
+			End:        request.End,
+			Limit:      request.Limit,
+			NodeType:   request.NodeType,
+			ProofStart: request.Start,
+		}
+		pages := make([][]byte, 0, numPages)
+		for i := 0; i < numPages; i++ {
+			pageReq.Proof = i == numPages-1
+			responseBytes, err := handler.OnCompactLeafsRequest(ctx, ids.GenerateTestNodeID(), 1, pageReq)
+			assert.NoError(t, err)
+			pages = append(pages, responseBytes)
+
+			var response message.LeafsResponse
+			if _, err := message.Codec.Unmarshal(responseBytes, &response); err != nil {
+				t.Fatal(err)
+			}
+			if len(response.Keys) == leafsLimit {
+				pageReq.Start = common.CopyBytes(response.Keys[len(response.Keys)-1])
+				utils.IncrOne(pageReq.Start)
+			}
+		}
+		return pages
+	}
+
+	t.Run("full batch", func(t *testing.T) {
+		request := newRequest(largeTrieRoot)
+		mockNetClient.mockResponses(nil, getPages(t, request, batchSize)...)
+
+		res, err := client.GetLeafs(ctx, request)
+		assert.NoError(t, err)
+		assert.Len(t, res.Keys, batchSize*leafsLimit)
+		assert.Len(t, res.Vals, batchSize*leafsLimit)
+		assert.True(t, res.More)
+		assert.Equal(t, uint(batchSize), mockNetClient.numCalls)
+		assert.Equal(t, CompactLeafsVersion, mockNetClient.requestedVersion)
+	})
+
+	t.Run("batch ending with partial page", func(t *testing.T) {
+		request := newRequest(smallTrieRoot)
+		pages := getPages(t, request, 2)
+		// The partial page is requested again with the proof.
+		unprovenPages := getPages(t, request, 3)
+		mockNetClient.mockResponses(nil, unprovenPages[0], unprovenPages[1], pages[1])
+
+		res, err := client.GetLeafs(ctx, request)
+		assert.NoError(t, err)
+		assert.Len(t, res.Keys, leafsLimit+leafsLimit/2)
+		assert.False(t, res.More)
+		assert.Equal(t, uint(3), mockNetClient.numCalls)
+	})
+
+	t.Run("falls back to leafs request if verification fails", func(t *testing.T) {
+		request := newRequest(largeTrieRoot)
+		pages := getPages(t, request, batchSize)
+		var stalePage message.LeafsResponse
+		if _, err := message.Codec.Unmarshal(pages[1], &stalePage); err != nil {
+			t.Fatal(err)
+		}
+		stalePage.Vals[10] = []byte("stale value")
+		stalePageBytes, err := message.Codec.Marshal(message.Version, stalePage)
+		if err != nil {
+			t.Fatal(err)
+		}
+		leafsResponse, err := handler.OnLeafsRequest(ctx, ids.GenerateTestNodeID(), 1, request)
+		assert.NoError(t, err)
+		mockNetClient.mockResponses(nil, pages[0], stalePageBytes, pages[2], pages[3], leafsResponse)
+		mockNetClient.bandwidths, mockNetClient.invalidResponses = nil, 0
+
+		res, err := client.GetLeafs(ctx, request)
+		assert.NoError(t, err)
+		assert.Len(t, res.Keys, leafsLimit)
+		assert.True(t, res.More)
+		assert.Equal(t, uint(batchSize+1), mockNetClient.numCalls)
+		// The peer serving the batch is penalized as for a failed request.
+		assert.Contains(t, mockNetClient.bandwidths, float64(0))
+		assert.Zero(t, mockNetClient.invalidResponses)
+	})
+
+	t.Run("falls back to leafs request on empty batch", func(t *testing.T) {
+		request := newRequest(largeTrieRoot)
+		emptyPage, err := message.Codec.Marshal(message.Version, message.LeafsResponse{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		emptyProvenPage, err := message.Codec.Marshal(message.Version, message.LeafsResponse{ProofVals: [][]byte{{0x01}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		leafsResponse, err := handler.OnLeafsRequest(ctx, ids.GenerateTestNodeID(), 1, request)
+		assert.NoError(t, err)
+		mockNetClient.mockResponses(nil, emptyPage, emptyProvenPage, leafsResponse)
+
+		res, err := client.GetLeafs(ctx, request)
+		assert.NoError(t, err)
+		assert.Len(t, res.Keys, leafsLimit)
+		assert.Equal(t, uint(3), mockNetClient.numCalls)
+	})
+
+	t.Run("falls back to leafs request without compact peers", func(t *testing.T) {
+		request := newRequest(largeTrieRoot)
+		leafsResponse, err := handler.OnLeafsRequest(ctx, ids.GenerateTestNodeID(), 1, request)
+		assert.NoError(t, err)
+		mockNetClient.mockResponses(nil, nil, leafsResponse)
+		mockNetClient.requestErr = []error{errors.New("no peers running compact leafs version"), nil}
+
+		res, err := client.GetLeafs(ctx, request)
+		assert.NoError(t, err)
+		assert.Len(t, res.Keys, leafsLimit)
+		assert.Equal(t, uint(2), mockNetClient.numCalls)
+		assert.Equal(t, StateSyncVersion, mockNetClient.requestedVersion)
+	})
+}
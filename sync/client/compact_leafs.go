@@ -0,0 +1,225 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package statesyncclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/version"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/ava-labs/coreth/core/rawdb"
+	"github.com/ava-labs/coreth/plugin/evm/message"
+	"github.com/ava-labs/coreth/trie"
+	"github.com/ava-labs/coreth/utils"
+)
+
+// CompactLeafsVersion is the minimum version of peers that serve
+// [message.CompactLeafsRequest].
+var CompactLeafsVersion = &version.Application{
+	Major: 1,
+	Minor: 11,
+	Patch: 13,
+}
+
+var errMissingProof = errors.New("response to request with proof does not include proof")
+
+// getCompactLeafs fetches up to [c.compactLeafsBatch] contiguous pages of
+// leafs starting at [req.Start] from a single peer running at least
+// [CompactLeafsVersion], requesting a range proof only for the last page,
+// which proves the leafs of all the pages.
+// Returns false if the leafs could not be fetched or verified, in which case
+// they should be requested with [message.LeafsRequest] instead.
+func (c *client) getCompactLeafs(ctx context.Context, req message.LeafsRequest) (message.LeafsResponse, bool) {
+	metric, err := c.stats.GetMetric(req)
+	if err != nil {
+		return message.LeafsResponse{}, false
+	}
+
+	var (
+		nodeID     ids.NodeID
+		keys, vals [][]byte
+		received   int
+		start      = time.Now()
+
+		// The page to request next starts at [pageStart], after the first
+		// [pageOffset] leafs of [keys]. The last full page is kept to be
+		// requested again with the proof if the batch ends with an empty
+		// page.
+		pageStart      = req.Start
+		pageOffset     int
+		lastPageStart  = req.Start
+		lastPageOffset int
+		proofRequested bool
+		response       message.LeafsResponse
+	)
+	metric.IncRequested()
+	for page := 0; ; page++ {
+		proof := proofRequested || page == c.compactLeafsBatch-1
+		pageReq := message.CompactLeafsRequest{
+			Root:       req.Root,
+			Account:    req.Account,
+			Start:      pageStart,
+			End:        req.End,
+			Limit:      req.Limit,
+			NodeType:   req.NodeType,
+			Proof:      proof,
+			ProofStart: req.Start,
+		}
+		pageReq, response, err = c.getCompactLeafsPage(ctx, &nodeID, pageReq)
+		if err != nil {
+			log.Debug("compact leafs request failed, falling back to leafs request", "nodeID", nodeID, "request", pageReq, "err", err)
+			if nodeID != ids.EmptyNodeID {
+				c.networkClient.TrackBandwidth(nodeID, 0, 0)
+			}
+			metric.IncFailed()
+			return message.LeafsResponse{}, false
+		}
+		for i := range response.Keys {
+			received += len(response.Keys[i]) + len(response.Vals[i])
+		}
+		keys = append(keys[:pageOffset], response.Keys...)
+		vals = append(vals[:pageOffset], response.Vals...)
+		if proof {
+			break
+		}
+
+		// Request the proof of the batch with the page that ends it: this page
+		// if it is the last page of leafs, or the last full page if this page
+		// is empty.
+		if len(response.Keys) < int(pageReq.Limit) {
+			if len(response.Keys) == 0 {
+				pageStart, pageOffset = lastPageStart, lastPageOffset
+			}
+			proofRequested = true
+			continue
+		}
+		lastKey := response.Keys[len(response.Keys)-1]
+		nextKey := common.CopyBytes(lastKey)
+		utils.IncrOne(nextKey)
+		if bytes.Compare(nextKey, lastKey) <= 0 {
+			// [lastKey] is the largest possible key.
+			proofRequested = true
+			continue
+		}
+		lastPageStart, lastPageOffset = pageStart, pageOffset
+		pageStart, pageOffset = nextKey, len(keys)
+	}
+	metric.UpdateRequestLatency(time.Since(start))
+
+	if len(keys) == 0 {
+		// The client does not know the key length of the trie, so it cannot
+		// verify an empty range from a nil start. An empty range may also be
+		// caused by the snapshot of the peer lagging behind the trie.
+		log.Debug("empty compact leafs response, falling back to leafs request", "nodeID", nodeID, "request", req)
+		metric.IncFailed()
+		return message.LeafsResponse{}, false
+	}
+	more, err := verifyLeafs(req.Root, req.Start, keys, vals, response.ProofVals)
+	if err != nil {
+		// This may be caused by the snapshot of the peer changing while the
+		// batch was served, so the peer is penalized as for a failed request
+		// rather than for an invalid response.
+		log.Debug("could not verify compact leafs, falling back to leafs request", "nodeID", nodeID, "request", req, "err", err)
+		c.networkClient.TrackBandwidth(nodeID, 0, 0)
+		metric.IncFailed()
+		return message.LeafsResponse{}, false
+	}
+	c.networkClient.TrackBandwidth(nodeID, float64(received)/(time.Since(start).Seconds()+epsilon), received)
+	metric.IncSucceeded()
+	metric.IncReceived(int64(len(keys)))
+	return message.LeafsResponse{Keys: keys, Vals: vals, More: more}, true
+}
+
+// getCompactLeafsPage sends [req] to [nodeID], or to any peer running at least
+// [CompactLeafsVersion] if [nodeID] is empty, in which case [nodeID] is set to
+// the peer the request was sent to.
+// Returns [req] with the limit adapted to the peer, as it was sent.
+func (c *client) getCompactLeafsPage(ctx context.Context, nodeID *ids.NodeID, req message.CompactLeafsRequest) (message.CompactLeafsRequest, message.LeafsResponse, error) {
+	var (
+		sent          message.Request
+		compressed    bool
+		responseBytes []byte
+		err           error
+		start         = time.Now()
+	)
+	buildRequest := func(nodeID ids.NodeID, nodeVersion *version.Application) ([]byte, error) {
+		var (
+			requestBytes []byte
+			err          error
+		)
+		sent, requestBytes, compressed, err = c.buildRequest(req, nodeID, nodeVersion)
+		return requestBytes, err
+	}
+	if *nodeID == ids.EmptyNodeID {
+		responseBytes, *nodeID, err = c.networkClient.SendAppRequestAnyWith(ctx, CompactLeafsVersion, buildRequest)
+	} else {
+		// The peer was chosen for the first page of the batch, so it runs at
+		// least [CompactLeafsVersion].
+		var requestBytes []byte
+		if requestBytes, err = buildRequest(*nodeID, CompactLeafsVersion); err == nil {
+			responseBytes, err = c.networkClient.SendAppRequest(ctx, *nodeID, requestBytes)
+		}
+	}
+	if err != nil && compressed {
+		start = time.Now()
+		if responseBytes, err = c.retryUncompressed(ctx, *nodeID, sent); err == nil {
+			compressed = false
+		}
+	}
+	pageReq, ok := sent.(message.CompactLeafsRequest)
+	if !ok {
+		return req, message.LeafsResponse{}, err
+	}
+	if err != nil {
+		c.onLeafsRequestFailed(*nodeID, pageReq.Limit, req.Limit)
+		return pageReq, message.LeafsResponse{}, err
+	}
+	latency := time.Since(start)
+
+	decompressed, err := c.decompressResponse(*nodeID, responseBytes, compressed)
+	if err != nil {
+		return pageReq, message.LeafsResponse{}, fmt.Errorf("%w: %w", errUnmarshalResponse, err)
+	}
+	var response message.LeafsResponse
+	if _, err := c.codec.Unmarshal(decompressed, &response); err != nil {
+		return pageReq, message.LeafsResponse{}, fmt.Errorf("%w: %w", errUnmarshalResponse, err)
+	}
+	if len(response.Keys) > int(pageReq.Limit) || len(response.Vals) != len(response.Keys) {
+		return pageReq, message.LeafsResponse{}, fmt.Errorf("%w: (%d keys, %d vals) > %d", errTooManyLeaves, len(response.Keys), len(response.Vals), pageReq.Limit)
+	}
+	if pageReq.Proof && len(response.ProofVals) == 0 {
+		return pageReq, message.LeafsResponse{}, errMissingProof
+	}
+	c.updateLeafsLimit(*nodeID, pageReq.Limit, req.Limit, len(response.Keys), len(responseBytes), latency)
+	return pageReq, response, nil
+}
+
+// verifyLeafs verifies that [keys] and [vals] are all the leafs of the trie
+// at [root] from [start] to the last key, with the range proof [proofVals].
+// Returns whether there are more leafs after the last key.
+func verifyLeafs(root common.Hash, start []byte, keys, vals [][]byte, proofVals [][]byte) (bool, error) {
+	proof := rawdb.NewMemoryDatabase()
+	defer proof.Close()
+	for _, proofVal := range proofVals {
+		if err := proof.Put(crypto.Keccak256(proofVal), proofVal); err != nil {
+			return false, err
+		}
+	}
+	if len(start) == 0 && len(keys) > 0 {
+		start = bytes.Repeat([]byte{0x00}, len(keys[len(keys)-1]))
+	}
+	more, err := trie.VerifyRangeProof(root, start, keys, vals, proof)
+	if err != nil {
+		return false, fmt.Errorf("%s due to %w", errInvalidRangeProof, err)
+	}
+	return more, nil
+}
@@ -27,6 +27,12 @@ type mockNetwork struct {
 	callback       func() // callback is called prior to processing each mock call
 	requestErr     []error
 	nodesRequested []ids.NodeID
+	// nodeVersion is the version of the peer requests are built for.
+	nodeVersion *version.Application
+
+	// captured peer scoring
+	bandwidths       []float64
+	invalidResponses uint
 }
 
 func (t *mockNetwork) SendAppRequestAny(ctx context.Context, minVersion *version.Application, request []byte) ([]byte, ids.NodeID, error) {
@@ -40,6 +46,14 @@ func (t *mockNetwork) SendAppRequestAny(ctx context.Context, minVersion *version
 	return response, ids.EmptyNodeID, err
 }
 
+func (t *mockNetwork) SendAppRequestAnyWith(ctx context.Context, minVersion *version.Application, buildRequest peer.RequestBuilder) ([]byte, ids.NodeID, error) {
+	request, err := buildRequest(ids.EmptyNodeID, t.nodeVersion)
+	if err != nil {
+		return nil, ids.EmptyNodeID, err
+	}
+	return t.SendAppRequestAny(ctx, minVersion, request)
+}
+
 func (t *mockNetwork) SendAppRequest(ctx context.Context, nodeID ids.NodeID, request []byte) ([]byte, error) {
 	if len(t.response) == 0 {
 		return nil, errors.New("no mocked response to return in mockNetwork")
@@ -93,4 +107,10 @@ func (t *mockNetwork) mockResponses(callback func(), responses ...[]byte) {
 	t.numCalls = 0
 }
 
-func (t *mockNetwork) TrackBandwidth(ids.NodeID, float64) {}
+func (t *mockNetwork) TrackBandwidth(_ ids.NodeID, bandwidth float64, _ int) {
+	t.bandwidths = append(t.bandwidths, bandwidth)
+}
+
+func (t *mockNetwork) TrackInvalidResponse(ids.NodeID) {
+	t.invalidResponses++
+}
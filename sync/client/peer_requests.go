@@ -0,0 +1,194 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package statesyncclient
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/compression"
+	"github.com/ava-labs/avalanchego/utils/units"
+	"github.com/ava-labs/avalanchego/version"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/ava-labs/coreth/plugin/evm/message"
+)
+
+const (
+	// minLeafsLimit is the smallest number of leafs requested from a peer
+	// when adapting the limit of leafs requests.
+	minLeafsLimit = 32
+	// targetLeafsResponseTime and targetLeafsResponseSize are the latency and
+	// the size of the responses that the limit of leafs requests to each peer
+	// is adapted for.
+	targetLeafsResponseTime = time.Second
+	targetLeafsResponseSize = units.MiB
+	// maxCompressionFailures is the number of responses to compressed requests
+	// from a peer that fail to decompress before compression is no longer
+	// requested from the peer.
+	maxCompressionFailures = 3
+)
+
+// CompressedRequestVersion is the minimum version of peers that serve
+// [message.CompressedRequest]. It must not be above [CompactLeafsVersion], as
+// peers serving compact leafs requests are assumed to serve compressed
+// requests.
+var CompressedRequestVersion = &version.Application{
+	Major: 1,
+	Minor: 11,
+	Patch: 13,
+}
+
+// buildRequest returns [request] as sent to [nodeID], which runs
+// [nodeVersion], with the limit of the leafs requests adapted to [nodeID],
+// and its bytes, wrapped in a [message.CompressedRequest] if the responses of
+// [nodeID] are compressed.
+func (c *client) buildRequest(request message.Request, nodeID ids.NodeID, nodeVersion *version.Application) (message.Request, []byte, bool, error) {
+	switch req := request.(type) {
+	case message.LeafsRequest:
+		req.Limit = c.leafsLimit(nodeID, req.Limit)
+		request = req
+	case message.CompactLeafsRequest:
+		req.Limit = c.leafsLimit(nodeID, req.Limit)
+		request = req
+	}
+	requestBytes, err := message.RequestToBytes(c.codec, request)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if !c.compressResponses(nodeID, nodeVersion) {
+		return request, requestBytes, false, nil
+	}
+	requestBytes, err = message.RequestToBytes(c.codec, message.CompressedRequest{
+		Compression: c.compression,
+		Request:     requestBytes,
+	})
+	return request, requestBytes, true, err
+}
+
+// compressResponses returns whether to request compressed responses from
+// [nodeID], which runs [nodeVersion].
+func (c *client) compressResponses(nodeID ids.NodeID, nodeVersion *version.Application) bool {
+	if c.compressor == nil || nodeVersion == nil || nodeVersion.Compare(CompressedRequestVersion) < 0 {
+		return false
+	}
+	c.peersLock.Lock()
+	defer c.peersLock.Unlock()
+	return c.compressionFailures[nodeID] < maxCompressionFailures
+}
+
+// onDecompressionFailed records that a response from [nodeID] to a compressed
+// request could not be decompressed. After [maxCompressionFailures] of them,
+// requests are sent to [nodeID] without compression.
+func (c *client) onDecompressionFailed(nodeID ids.NodeID) {
+	if nodeID == ids.EmptyNodeID {
+		return
+	}
+	c.peersLock.Lock()
+	defer c.peersLock.Unlock()
+	c.compressionFailures[nodeID]++
+	if c.compressionFailures[nodeID] == maxCompressionFailures {
+		log.Debug("compressed responses failed to decompress, disabling compression for peer", "nodeID", nodeID)
+	}
+}
+
+// retryUncompressed sends [sent] to [nodeID] again without compression after
+// a compressed request to [nodeID] failed, as peers that do not serve
+// [message.CompressedRequest] drop it. If the uncompressed request succeeds,
+// requests are sent to [nodeID] without compression afterwards. If it fails
+// too, the compressed request most likely failed for another reason, such as
+// a timeout, and compression is still requested from [nodeID].
+func (c *client) retryUncompressed(ctx context.Context, nodeID ids.NodeID, sent message.Request) ([]byte, error) {
+	requestBytes, err := message.RequestToBytes(c.codec, sent)
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.networkClient.SendAppRequest(ctx, nodeID, requestBytes)
+	if err != nil {
+		return nil, err
+	}
+	c.peersLock.Lock()
+	defer c.peersLock.Unlock()
+	if c.compressionFailures[nodeID] < maxCompressionFailures {
+		log.Debug("compressed request failed, disabling compression for peer", "nodeID", nodeID)
+		c.compressionFailures[nodeID] = maxCompressionFailures
+	}
+	return response, nil
+}
+
+// leafsLimit returns the number of leafs to request from [nodeID], up to
+// [maxLimit].
+func (c *client) leafsLimit(nodeID ids.NodeID, maxLimit uint16) uint16 {
+	if !c.adaptiveLeafsLimit {
+		return maxLimit
+	}
+	c.peersLock.Lock()
+	defer c.peersLock.Unlock()
+	if limit, ok := c.leafsLimits[nodeID]; ok && limit < maxLimit {
+		return limit
+	}
+	return maxLimit
+}
+
+// updateLeafsLimit adapts the number of leafs requested from [nodeID], up to
+// [maxLimit], after it returned [numLeafs] leafs in [responseSize] bytes in
+// [latency] to a request for [limit] leafs. The limit moves halfway towards
+// the number of leafs [nodeID] can return within [targetLeafsResponseTime]
+// and [targetLeafsResponseSize] at that rate, and only grows if the
+// response was full.
+func (c *client) updateLeafsLimit(nodeID ids.NodeID, limit, maxLimit uint16, numLeafs, responseSize int, latency time.Duration) {
+	if !c.adaptiveLeafsLimit || numLeafs == 0 {
+		return
+	}
+	scale := math.Min(
+		float64(targetLeafsResponseTime)/float64(max(latency, 1)),
+		float64(targetLeafsResponseSize)/float64(max(responseSize, 1)),
+	)
+	estimate := float64(numLeafs) * scale
+	if numLeafs < int(limit) && estimate > float64(limit) {
+		return
+	}
+	c.setLeafsLimit(nodeID, (float64(limit)+estimate)/2, maxLimit)
+}
+
+// onLeafsRequestFailed halves the number of leafs requested from [nodeID]
+// after a request for [limit] leafs failed.
+func (c *client) onLeafsRequestFailed(nodeID ids.NodeID, limit, maxLimit uint16) {
+	if !c.adaptiveLeafsLimit || nodeID == ids.EmptyNodeID {
+		return
+	}
+	c.setLeafsLimit(nodeID, float64(limit)/2, maxLimit)
+}
+
+func (c *client) setLeafsLimit(nodeID ids.NodeID, limit float64, maxLimit uint16) {
+	limit = math.Max(math.Min(limit, float64(maxLimit)), minLeafsLimit)
+
+	c.peersLock.Lock()
+	defer c.peersLock.Unlock()
+	c.leafsLimits[nodeID] = uint16(limit)
+}
+
+// decompressResponse returns [response] from [nodeID] decompressed if
+// [compressed].
+func (c *client) decompressResponse(nodeID ids.NodeID, response []byte, compressed bool) ([]byte, error) {
+	if !compressed {
+		return response, nil
+	}
+	decompressed, err := c.compressor.Decompress(response)
+	if err != nil {
+		c.onDecompressionFailed(nodeID)
+	}
+	return decompressed, err
+}
+
+// compressionOrNone returns [compressionType], or [compression.TypeNone] if
+// it is not set.
+func compressionOrNone(compressionType compression.Type) compression.Type {
+	if compressionType == 0 {
+		return compression.TypeNone
+	}
+	return compressionType
+}
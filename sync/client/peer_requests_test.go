@@ -0,0 +1,231 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package statesyncclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/compression"
+	"github.com/ava-labs/avalanchego/utils/units"
+	"github.com/ava-labs/avalanchego/version"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ava-labs/coreth/core/rawdb"
+	"github.com/ava-labs/coreth/plugin/evm/message"
+	clientstats "github.com/ava-labs/coreth/sync/client/stats"
+	"github.com/ava-labs/coreth/sync/handlers"
+	handlerstats "github.com/ava-labs/coreth/sync/handlers/stats"
+	"github.com/ava-labs/coreth/sync/syncutils"
+	"github.com/ava-labs/coreth/triedb"
+)
+
+func TestGetLeafsCompression(t *testing.T) {
+	rand.Seed(1)
+
+	trieDB := triedb.NewDatabase(rawdb.NewMemoryDatabase(), nil)
+	root, _, _ := syncutils.GenerateTrie(t, trieDB, 10_000, common.HashLength)
+	handler := handlers.NewLeafsRequestHandler(trieDB, nil, message.Codec, handlerstats.NewNoopHandlerStats())
+
+	mockNetClient := &mockNetwork{nodeVersion: CompressedRequestVersion}
+	client := NewClient(&ClientConfig{
+		NetworkClient: mockNetClient,
+		Codec:         message.Codec,
+		Stats:         clientstats.NewNoOpStats(),
+		BlockParser:   mockBlockParser,
+		Compression:   compression.TypeZstd,
+	})
+
+	ctx := context.Background()
+	request := message.LeafsRequest{
+		Root:     root,
+		Start:    bytes.Repeat([]byte{0x00}, common.HashLength),
+		End:      bytes.Repeat([]byte{0xff}, common.HashLength),
+		Limit:    1024,
+		NodeType: message.StateTrieNode,
+	}
+	response, err := handler.OnLeafsRequest(ctx, ids.GenerateTestNodeID(), 1, request)
+	assert.NoError(t, err)
+	compressor, err := message.NewCompressor(compression.TypeZstd)
+	assert.NoError(t, err)
+	compressedResponse, err := compressor.Compress(response)
+	assert.NoError(t, err)
+
+	// sentRequest returns the last request sent, and whether it was
+	// compressed.
+	sentRequest := func(t *testing.T) (message.Request, bool) {
+		t.Helper()
+		req, err := message.BytesToRequest(message.Codec, mockNetClient.request)
+		assert.NoError(t, err)
+		compressedRequest, ok := req.(message.CompressedRequest)
+		if !ok {
+			return req, false
+		}
+		assert.Equal(t, compression.TypeZstd, compressedRequest.Compression)
+		req, err = message.BytesToRequest(message.Codec, compressedRequest.Request)
+		assert.NoError(t, err)
+		return req, true
+	}
+
+	mockNetClient.mockResponse(1, nil, compressedResponse)
+	res, err := client.GetLeafs(ctx, request)
+	assert.NoError(t, err)
+	assert.Len(t, res.Keys, 1024)
+	req, compressed := sentRequest(t)
+	assert.True(t, compressed)
+	assert.Equal(t, request, req)
+
+	// Peers running an earlier version are sent requests without
+	// compression.
+	mockNetClient.nodeVersion = &version.Application{Major: 1, Minor: 11, Patch: 12}
+	mockNetClient.mockResponse(1, nil, response)
+	_, err = client.GetLeafs(ctx, request)
+	assert.NoError(t, err)
+	_, compressed = sentRequest(t)
+	assert.False(t, compressed)
+	mockNetClient.nodeVersion = CompressedRequestVersion
+
+	// An uncompressed response to a compressed request is invalid.
+	mockNetClient.mockResponses(nil, response, compressedResponse)
+	_, err = client.GetLeafs(ctx, request)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), mockNetClient.numCalls)
+
+	// A failed compressed request is sent again without compression. If that
+	// fails too, the peer is penalized and compression stays enabled.
+	errTimeout := errors.New("request timed out")
+	mockNetClient.mockResponses(nil, nil, nil, compressedResponse)
+	mockNetClient.requestErr = []error{errTimeout, errTimeout}
+	mockNetClient.bandwidths = nil
+	_, err = client.GetLeafs(ctx, request)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), mockNetClient.numCalls)
+	assert.Contains(t, mockNetClient.bandwidths, float64(0))
+	_, compressed = sentRequest(t)
+	assert.True(t, compressed)
+
+	// If the peer serves the request without compression, the peer is not
+	// penalized and compression is no longer requested from it.
+	mockNetClient.mockResponses(nil, nil, response)
+	mockNetClient.requestErr = []error{errTimeout}
+	mockNetClient.bandwidths = nil
+	res, err = client.GetLeafs(ctx, request)
+	assert.NoError(t, err)
+	assert.Len(t, res.Keys, 1024)
+	assert.Equal(t, uint(2), mockNetClient.numCalls)
+	assert.NotContains(t, mockNetClient.bandwidths, float64(0))
+	req, compressed = sentRequest(t)
+	assert.False(t, compressed)
+	assert.Equal(t, request, req)
+
+	mockNetClient.mockResponse(1, nil, response)
+	_, err = client.GetLeafs(ctx, request)
+	assert.NoError(t, err)
+	_, compressed = sentRequest(t)
+	assert.False(t, compressed)
+}
+
+func TestCompressionDisabledAfterDecompressionFailures(t *testing.T) {
+	client := NewClient(&ClientConfig{
+		Codec:       message.Codec,
+		Stats:       clientstats.NewNoOpStats(),
+		BlockParser: mockBlockParser,
+		Compression: compression.TypeZstd,
+	})
+	nodeID := ids.GenerateTestNodeID()
+
+	// Compression is only requested from peers whose version is known to
+	// serve compressed requests.
+	assert.False(t, client.compressResponses(nodeID, nil))
+	assert.False(t, client.compressResponses(nodeID, &version.Application{Major: 1, Minor: 11, Patch: 12}))
+	assert.True(t, client.compressResponses(nodeID, CompressedRequestVersion))
+
+	for i := 0; i < maxCompressionFailures; i++ {
+		assert.True(t, client.compressResponses(nodeID, CompressedRequestVersion))
+		_, err := client.decompressResponse(nodeID, []byte("not compressed"), true)
+		assert.Error(t, err)
+	}
+	assert.False(t, client.compressResponses(nodeID, CompressedRequestVersion))
+	assert.True(t, client.compressResponses(ids.GenerateTestNodeID(), CompressedRequestVersion))
+}
+
+func TestAdaptiveLeafsLimit(t *testing.T) {
+	rand.Seed(1)
+
+	trieDB := triedb.NewDatabase(rawdb.NewMemoryDatabase(), nil)
+	root, _, _ := syncutils.GenerateTrie(t, trieDB, 10_000, common.HashLength)
+	handler := handlers.NewLeafsRequestHandler(trieDB, nil, message.Codec, handlerstats.NewNoopHandlerStats())
+
+	nodeID := ids.GenerateTestNodeID()
+	mockNetClient := &mockNetwork{}
+	client := NewClient(&ClientConfig{
+		NetworkClient:      mockNetClient,
+		Codec:              message.Codec,
+		Stats:              clientstats.NewNoOpStats(),
+		StateSyncNodeIDs:   []ids.NodeID{nodeID},
+		BlockParser:        mockBlockParser,
+		AdaptiveLeafsLimit: true,
+	})
+
+	const maxLimit = 1024
+	assert.EqualValues(t, maxLimit, client.leafsLimit(nodeID, maxLimit))
+
+	// Slow responses shrink the limit.
+	client.updateLeafsLimit(nodeID, maxLimit, maxLimit, maxLimit, 100*units.KiB, 4*targetLeafsResponseTime)
+	assert.EqualValues(t, (maxLimit+maxLimit/4)/2, client.leafsLimit(nodeID, maxLimit))
+	// The limit is capped by the limit of the request.
+	assert.EqualValues(t, 100, client.leafsLimit(nodeID, 100))
+
+	// Fast but partial responses do not grow the limit.
+	client.updateLeafsLimit(nodeID, 640, maxLimit, 100, units.KiB, time.Millisecond)
+	assert.EqualValues(t, 640, client.leafsLimit(nodeID, maxLimit))
+
+	// Fast full responses grow the limit, up to the limit of the request.
+	client.updateLeafsLimit(nodeID, 640, maxLimit, 640, 64*units.KiB, targetLeafsResponseTime/10)
+	assert.EqualValues(t, maxLimit, client.leafsLimit(nodeID, maxLimit))
+
+	// Large responses shrink the limit.
+	client.updateLeafsLimit(nodeID, maxLimit, maxLimit, maxLimit, 2*targetLeafsResponseSize, time.Millisecond)
+	assert.EqualValues(t, (maxLimit+maxLimit/2)/2, client.leafsLimit(nodeID, maxLimit))
+
+	// Failed requests halve the limit, down to [minLeafsLimit].
+	client.onLeafsRequestFailed(nodeID, 768, maxLimit)
+	assert.EqualValues(t, 384, client.leafsLimit(nodeID, maxLimit))
+	for i := 0; i < 10; i++ {
+		client.onLeafsRequestFailed(nodeID, client.leafsLimit(nodeID, maxLimit), maxLimit)
+	}
+	assert.EqualValues(t, minLeafsLimit, client.leafsLimit(nodeID, maxLimit))
+
+	// Requests to the peer are sent with its limit.
+	client.setLeafsLimit(nodeID, 640, maxLimit)
+	request := message.LeafsRequest{
+		Root:     root,
+		Start:    bytes.Repeat([]byte{0x00}, common.HashLength),
+		End:      bytes.Repeat([]byte{0xff}, common.HashLength),
+		Limit:    maxLimit,
+		NodeType: message.StateTrieNode,
+	}
+	sentRequest := request
+	sentRequest.Limit = 640
+	response, err := handler.OnLeafsRequest(context.Background(), ids.GenerateTestNodeID(), 1, sentRequest)
+	assert.NoError(t, err)
+	mockNetClient.mockResponse(1, nil, response)
+	res, err := client.GetLeafs(context.Background(), request)
+	assert.NoError(t, err)
+	assert.Len(t, res.Keys, 640)
+	assert.True(t, res.More)
+	req, err := message.BytesToRequest(message.Codec, mockNetClient.request)
+	assert.NoError(t, err)
+	assert.Equal(t, sentRequest, req)
+
+	// The limit is not adapted if disabled.
+	client.adaptiveLeafsLimit = false
+	assert.EqualValues(t, maxLimit, client.leafsLimit(nodeID, maxLimit))
+}
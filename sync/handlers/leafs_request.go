@@ -77,26 +77,96 @@ func (lrh *LeafsRequestHandler) OnLeafsRequest(ctx context.Context, nodeID ids.N
 	startTime := time.Now()
 	lrh.stats.IncLeafsRequest()
 
+	t, keyLength, ok := lrh.openTrie(nodeID, requestID, leafsRequest)
+	if !ok {
+		return nil, nil
+	}
+	// override limit if it is greater than the configured maxLeavesLimit
+	limit := leafsRequest.Limit
+	if limit > maxLeavesLimit {
+		limit = maxLeavesLimit
+	}
+
+	var leafsResponse message.LeafsResponse
+	// pool response's key/val allocations
+	leafsResponse.Keys = lrh.pool.Get().([][]byte)
+	leafsResponse.Vals = lrh.pool.Get().([][]byte)
+	defer func() {
+		for i := range leafsResponse.Keys {
+			// clear out slices before returning them to the pool
+			// to avoid memory leak.
+			leafsResponse.Keys[i] = nil
+			leafsResponse.Vals[i] = nil
+		}
+		lrh.pool.Put(leafsResponse.Keys[:0])
+		lrh.pool.Put(leafsResponse.Vals[:0])
+	}()
+
+	responseBuilder := &responseBuilder{
+		request:   &leafsRequest,
+		response:  &leafsResponse,
+		t:         t,
+		keyLength: keyLength,
+		limit:     limit,
+		stats:     lrh.stats,
+	}
+	// pass snapshot to responseBuilder if non-nil snapshot getter provided
+	if lrh.snapshotProvider != nil {
+		responseBuilder.snap = lrh.snapshotProvider.Snapshots()
+	}
+	err := responseBuilder.handleRequest(ctx)
+
+	// ensure metrics are captured properly on all return paths
+	defer func() {
+		lrh.stats.UpdateLeafsRequestProcessingTime(time.Since(startTime))
+		lrh.stats.UpdateLeafsReturned(uint16(len(leafsResponse.Keys)))
+		lrh.stats.UpdateRangeProofValsReturned(int64(len(leafsResponse.ProofVals)))
+		lrh.stats.UpdateGenerateRangeProofTime(responseBuilder.proofTime)
+		lrh.stats.UpdateReadLeafsTime(responseBuilder.trieReadTime)
+	}()
+	if err != nil {
+		log.Debug("failed to serve leafs request", "nodeID", nodeID, "requestID", requestID, "request", leafsRequest, "err", err)
+		return nil, nil
+	}
+	if len(leafsResponse.Keys) == 0 && ctx.Err() != nil {
+		log.Debug("context err set before any leafs were iterated", "nodeID", nodeID, "requestID", requestID, "request", leafsRequest, "ctxErr", ctx.Err())
+		return nil, nil
+	}
+
+	responseBytes, err := lrh.codec.Marshal(message.Version, leafsResponse)
+	if err != nil {
+		log.Debug("failed to marshal LeafsResponse, dropping request", "nodeID", nodeID, "requestID", requestID, "request", leafsRequest, "err", err)
+		return nil, nil
+	}
+
+	log.Debug("handled leafsRequest", "time", time.Since(startTime), "leafs", len(leafsResponse.Keys), "proofLen", len(leafsResponse.ProofVals))
+	return responseBytes, nil
+}
+
+// openTrie validates [leafsRequest] and opens the requested trie, returning
+// the trie and the length of its keys, or false if the request should be
+// dropped.
+func (lrh *LeafsRequestHandler) openTrie(nodeID ids.NodeID, requestID uint32, leafsRequest message.LeafsRequest) (*trie.Trie, int, bool) {
 	if (len(leafsRequest.End) > 0 && bytes.Compare(leafsRequest.Start, leafsRequest.End) > 0) ||
 		leafsRequest.Root == (common.Hash{}) ||
 		leafsRequest.Root == types.EmptyRootHash ||
 		leafsRequest.Limit == 0 {
 		log.Debug("invalid leafs request, dropping request", "nodeID", nodeID, "requestID", requestID, "request", leafsRequest)
 		lrh.stats.IncInvalidLeafsRequest()
-		return nil, nil
+		return nil, 0, false
 	}
 	keyLength, err := getKeyLength(leafsRequest.NodeType)
 	if err != nil {
 		// Note: LeafsRequest.Handle checks NodeType's validity so clients cannot cause the server to spam this error
 		log.Error("Failed to get key length for leafs request", "err", err)
 		lrh.stats.IncInvalidLeafsRequest()
-		return nil, nil
+		return nil, 0, false
 	}
 	if len(leafsRequest.Start) != 0 && len(leafsRequest.Start) != keyLength ||
 		len(leafsRequest.End) != 0 && len(leafsRequest.End) != keyLength {
 		log.Debug("invalid length for leafs request range, dropping request", "startLen", len(leafsRequest.Start), "endLen", len(leafsRequest.End), "expected", keyLength)
 		lrh.stats.IncInvalidLeafsRequest()
-		return nil, nil
+		return nil, 0, false
 	}
 
 	// TODO: We should know the state root that accounts correspond to,
@@ -107,29 +177,40 @@ func (lrh *LeafsRequestHandler) OnLeafsRequest(ctx context.Context, nodeID ids.N
 	if err != nil {
 		log.Debug("error opening trie when processing request, dropping request", "nodeID", nodeID, "requestID", requestID, "root", leafsRequest.Root, "err", err)
 		lrh.stats.IncMissingRoot()
+		return nil, 0, false
+	}
+	return t, keyLength, true
+}
+
+// OnCompactLeafsRequest returns encoded message.LeafsResponse for a given
+// message.CompactLeafsRequest.
+// Leaves are read from the snapshot without verifying them against the trie if
+// its disk layer is at the requested root, or from the trie otherwise.
+// Verification is left to the client, with the range proof included if Proof is set, which is
+// generated from the trie without reading the leaves it proves.
+// Never returns errors
+func (lrh *LeafsRequestHandler) OnCompactLeafsRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, compactRequest message.CompactLeafsRequest) ([]byte, error) {
+	startTime := time.Now()
+	lrh.stats.IncLeafsRequest()
+
+	leafsRequest := compactRequest.LeafsRequest()
+	t, keyLength, ok := lrh.openTrie(nodeID, requestID, leafsRequest)
+	if !ok {
+		return nil, nil
+	}
+	if compactRequest.Proof &&
+		(len(compactRequest.ProofStart) != 0 && len(compactRequest.ProofStart) != keyLength ||
+			bytes.Compare(compactRequest.ProofStart, leafsRequest.Start) > 0) {
+		log.Debug("invalid proof start for compact leafs request, dropping request", "nodeID", nodeID, "requestID", requestID, "request", compactRequest)
+		lrh.stats.IncInvalidLeafsRequest()
 		return nil, nil
 	}
-	// override limit if it is greater than the configured maxLeavesLimit
 	limit := leafsRequest.Limit
 	if limit > maxLeavesLimit {
 		limit = maxLeavesLimit
 	}
 
 	var leafsResponse message.LeafsResponse
-	// pool response's key/val allocations
-	leafsResponse.Keys = lrh.pool.Get().([][]byte)
-	leafsResponse.Vals = lrh.pool.Get().([][]byte)
-	defer func() {
-		for i := range leafsResponse.Keys {
-			// clear out slices before returning them to the pool
-			// to avoid memory leak.
-			leafsResponse.Keys[i] = nil
-			leafsResponse.Vals[i] = nil
-		}
-		lrh.pool.Put(leafsResponse.Keys[:0])
-		lrh.pool.Put(leafsResponse.Vals[:0])
-	}()
-
 	responseBuilder := &responseBuilder{
 		request:   &leafsRequest,
 		response:  &leafsResponse,
@@ -138,13 +219,11 @@ func (lrh *LeafsRequestHandler) OnLeafsRequest(ctx context.Context, nodeID ids.N
 		limit:     limit,
 		stats:     lrh.stats,
 	}
-	// pass snapshot to responseBuilder if non-nil snapshot getter provided
 	if lrh.snapshotProvider != nil {
 		responseBuilder.snap = lrh.snapshotProvider.Snapshots()
 	}
-	err = responseBuilder.handleRequest(ctx)
+	err := responseBuilder.handleCompactRequest(ctx, compactRequest.Proof, compactRequest.ProofStart)
 
-	// ensure metrics are captured properly on all return paths
 	defer func() {
 		lrh.stats.UpdateLeafsRequestProcessingTime(time.Since(startTime))
 		lrh.stats.UpdateLeafsReturned(uint16(len(leafsResponse.Keys)))
@@ -153,21 +232,21 @@ func (lrh *LeafsRequestHandler) OnLeafsRequest(ctx context.Context, nodeID ids.N
 		lrh.stats.UpdateReadLeafsTime(responseBuilder.trieReadTime)
 	}()
 	if err != nil {
-		log.Debug("failed to serve leafs request", "nodeID", nodeID, "requestID", requestID, "request", leafsRequest, "err", err)
+		log.Debug("failed to serve compact leafs request", "nodeID", nodeID, "requestID", requestID, "request", compactRequest, "err", err)
 		return nil, nil
 	}
 	if len(leafsResponse.Keys) == 0 && ctx.Err() != nil {
-		log.Debug("context err set before any leafs were iterated", "nodeID", nodeID, "requestID", requestID, "request", leafsRequest, "ctxErr", ctx.Err())
+		log.Debug("context err set before any leafs were iterated", "nodeID", nodeID, "requestID", requestID, "request", compactRequest, "ctxErr", ctx.Err())
 		return nil, nil
 	}
 
 	responseBytes, err := lrh.codec.Marshal(message.Version, leafsResponse)
 	if err != nil {
-		log.Debug("failed to marshal LeafsResponse, dropping request", "nodeID", nodeID, "requestID", requestID, "request", leafsRequest, "err", err)
+		log.Debug("failed to marshal LeafsResponse, dropping request", "nodeID", nodeID, "requestID", requestID, "request", compactRequest, "err", err)
 		return nil, nil
 	}
 
-	log.Debug("handled leafsRequest", "time", time.Since(startTime), "leafs", len(leafsResponse.Keys), "proofLen", len(leafsResponse.ProofVals))
+	log.Debug("handled compactLeafsRequest", "time", time.Since(startTime), "leafs", len(leafsResponse.Keys), "proofLen", len(leafsResponse.ProofVals))
 	return responseBytes, nil
 }
 
@@ -226,6 +305,68 @@ func (rb *responseBuilder) handleRequest(ctx context.Context) error {
 	return nil
 }
 
+// handleCompactRequest fills the response to a CompactLeafsRequest, reading the
+// leaves from the snapshot without verifying them if its disk layer is at the
+// requested root, or from the trie otherwise. If [proof] is set, adds the
+// proof of [proofStart] and of the last key in the response.
+func (rb *responseBuilder) handleCompactRequest(ctx context.Context, proof bool, proofStart []byte) error {
+	if rb.snap != nil && rb.snapshotAtRoot() {
+		snapshotReadStart := time.Now()
+		rb.stats.IncSnapshotReadAttempt()
+		keys, vals, err := rb.readLeafsFromSnapshot(ctx)
+		rb.stats.UpdateSnapshotReadTime(time.Since(snapshotReadStart))
+		if err != nil {
+			rb.stats.IncSnapshotReadError()
+			return err
+		}
+		rb.stats.IncSnapshotReadSuccess()
+		rb.response.Keys, rb.response.Vals = keys, vals
+	} else if _, err := rb.fillFromTrie(ctx, rb.request.End); err != nil {
+		rb.stats.IncTrieError()
+		return err
+	}
+	if !proof {
+		return nil
+	}
+
+	proofDB, err := rb.generateRangeProof(proofStart, rb.response.Keys)
+	if err != nil {
+		rb.stats.IncProofError()
+		return err
+	}
+	defer proofDB.Close() // closing memdb does not error
+
+	rb.response.ProofVals, err = iterateVals(proofDB)
+	if err != nil {
+		rb.stats.IncProofError()
+		return err
+	}
+	return nil
+}
+
+// snapshotAtRoot returns whether the disk layer of the snapshot holds the
+// leaves of the requested root: the state root for the account trie, or the
+// storage root of the requested account for a storage trie.
+func (rb *responseBuilder) snapshotAtRoot() bool {
+	diskRoot := rb.snap.DiskRoot()
+	if rb.request.Account == (common.Hash{}) {
+		return diskRoot == rb.request.Root
+	}
+	diskLayer := rb.snap.Snapshot(diskRoot)
+	if diskLayer == nil {
+		return false
+	}
+	account, err := diskLayer.Account(rb.request.Account)
+	if err != nil || account == nil {
+		return false
+	}
+	root := types.EmptyRootHash
+	if len(account.Root) > 0 {
+		root = common.BytesToHash(account.Root)
+	}
+	return root == rb.request.Root
+}
+
 // fillFromSnapshot reads data from snapshot and returns true if the response is complete.
 // Otherwise, the caller should attempt to iterate the trie and determine if a range proof
 // should be added to the response.
@@ -18,6 +18,7 @@ import (
 	"github.com/ava-labs/coreth/sync/syncutils"
 	"github.com/ava-labs/coreth/trie"
 	"github.com/ava-labs/coreth/triedb"
+	"github.com/ava-labs/coreth/utils"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
@@ -723,3 +724,110 @@ func assertRangeProofIsValid(t *testing.T, request *message.LeafsRequest, respon
 	assert.NoError(t, err)
 	assert.Equal(t, expectMore, more)
 }
+
+func TestLeafsRequestHandler_OnCompactLeafsRequest(t *testing.T) {
+	rand.Seed(1)
+	mockHandlerStats := &stats.MockHandlerStats{}
+	memdb := rawdb.NewMemoryDatabase()
+	trieDB := triedb.NewDatabase(memdb, nil)
+	accountTrieRoot, _ := syncutils.FillAccounts(t, trieDB, common.Hash{}, 10_000, nil)
+	snapshotProvider := &TestSnapshotProvider{}
+	leafsHandler := NewLeafsRequestHandler(trieDB, snapshotProvider, message.Codec, mockHandlerStats)
+
+	getPage := func(request message.CompactLeafsRequest) message.LeafsResponse {
+		t.Helper()
+		responseBytes, err := leafsHandler.OnCompactLeafsRequest(context.Background(), ids.GenerateTestNodeID(), 1, request)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, responseBytes)
+		var response message.LeafsResponse
+		_, err = message.Codec.Unmarshal(responseBytes, &response)
+		assert.NoError(t, err)
+		return response
+	}
+	getBatch := func() (message.LeafsRequest, message.LeafsResponse) {
+		t.Helper()
+		request := message.CompactLeafsRequest{
+			Root:     accountTrieRoot,
+			Limit:    maxLeavesLimit,
+			NodeType: message.StateTrieNode,
+		}
+		// The first page is returned without a proof.
+		first := getPage(request)
+		assert.Len(t, first.Keys, int(maxLeavesLimit))
+		assert.Empty(t, first.ProofVals)
+
+		// The second page proves both pages.
+		request.Start = common.CopyBytes(first.Keys[len(first.Keys)-1])
+		utils.IncrOne(request.Start)
+		request.Proof = true
+		second := getPage(request)
+		assert.Len(t, second.Keys, int(maxLeavesLimit))
+		assert.NotEmpty(t, second.ProofVals)
+
+		return message.LeafsRequest{Root: accountTrieRoot}, message.LeafsResponse{
+			Keys:      append(first.Keys, second.Keys...),
+			Vals:      append(first.Vals, second.Vals...),
+			ProofVals: second.ProofVals,
+		}
+	}
+
+	// Without a snapshot, leafs are read from the trie.
+	request, response := getBatch()
+	assertRangeProofIsValid(t, &request, &response, true)
+	assert.EqualValues(t, 2, mockHandlerStats.LeafsRequestCount)
+	assert.Zero(t, mockHandlerStats.SnapshotReadAttemptCount)
+
+	// With a snapshot, leafs are read from it without verifying them, so a
+	// stale snapshot causes the batch to fail verification by the client.
+	mockHandlerStats.Reset()
+	snap, err := snapshot.New(snapshot.Config{CacheSize: 64, SkipVerify: true}, memdb, trieDB, common.Hash{}, accountTrieRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshotProvider.Snapshot = snap
+	t.Cleanup(func() { <-snapshot.WipeSnapshot(memdb, true) })
+
+	request, response = getBatch()
+	assertRangeProofIsValid(t, &request, &response, true)
+	assert.EqualValues(t, 2, mockHandlerStats.SnapshotReadSuccessCount)
+
+	rawdb.DeleteAccountSnapshot(memdb, common.BytesToHash(response.Keys[100]))
+	request, response = getBatch()
+	_, err = trie.VerifyRangeProof(request.Root, bytes.Repeat([]byte{0x00}, common.HashLength), response.Keys, response.Vals, proofDB(t, response.ProofVals))
+	assert.Error(t, err)
+
+	// Leafs of a root other than the root of the snapshot are read from the
+	// trie.
+	mockHandlerStats.Reset()
+	otherRoot, _ := syncutils.FillAccounts(t, trieDB, common.Hash{}, 100, nil)
+	otherRequest := message.LeafsRequest{Root: otherRoot, Limit: maxLeavesLimit, NodeType: message.StateTrieNode}
+	otherResponse := getPage(message.CompactLeafsRequest{Root: otherRoot, Limit: maxLeavesLimit, NodeType: message.StateTrieNode})
+	assert.Len(t, otherResponse.Keys, 100)
+	assertRangeProofIsValid(t, &otherRequest, &otherResponse, false)
+	assert.Zero(t, mockHandlerStats.SnapshotReadAttemptCount)
+
+	// A proof must not start after the requested leafs.
+	mockHandlerStats.Reset()
+	responseBytes, err := leafsHandler.OnCompactLeafsRequest(context.Background(), ids.GenerateTestNodeID(), 1, message.CompactLeafsRequest{
+		Root:       accountTrieRoot,
+		Start:      bytes.Repeat([]byte{0x01}, common.HashLength),
+		Limit:      maxLeavesLimit,
+		NodeType:   message.StateTrieNode,
+		Proof:      true,
+		ProofStart: bytes.Repeat([]byte{0x02}, common.HashLength),
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, responseBytes)
+	assert.EqualValues(t, 1, mockHandlerStats.InvalidLeafsRequestCount)
+}
+
+func proofDB(t *testing.T, proofVals [][]byte) ethdb.Database {
+	t.Helper()
+	proof := rawdb.NewMemoryDatabase()
+	for _, proofVal := range proofVals {
+		if err := proof.Put(crypto.Keccak256(proofVal), proofVal); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return proof
+}
@@ -0,0 +1,195 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package statesync
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/utils/set"
+	"github.com/ava-labs/coreth/core/rawdb"
+	"github.com/ava-labs/coreth/core/types"
+	"github.com/ava-labs/coreth/plugin/evm/message"
+	statesyncclient "github.com/ava-labs/coreth/sync/client"
+	"github.com/ava-labs/coreth/trie"
+	"github.com/ava-labs/coreth/triedb"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// ReadCodeQueue returns up to [limit] of the code hashes marked in [db] as
+// pending to be fetched, and the total number of them. A [limit] of 0 returns
+// all of them.
+func ReadCodeQueue(db ethdb.Iteratee, limit int) ([]common.Hash, int, error) {
+	it := rawdb.NewCodeToFetchIterator(db)
+	defer it.Release()
+
+	var (
+		codeHashes []common.Hash
+		total      int
+	)
+	for it.Next() {
+		total++
+		if limit == 0 || len(codeHashes) < limit {
+			codeHashes = append(codeHashes, common.BytesToHash(it.Key()[len(rawdb.CodeToFetchPrefix):]))
+		}
+	}
+	if err := it.Error(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate code entries to fetch: %w", err)
+	}
+	return codeHashes, total, nil
+}
+
+// CodeVerification is the result of [VerifyCode].
+type CodeVerification struct {
+	Checked int           // Number of code entries checked
+	Invalid []common.Hash // Code hashes whose code does not hash to them
+	Missing []common.Hash // Code hashes requested that have no code in the database
+}
+
+// VerifyCode checks that the code stored in [db] for each of [codeHashes], or
+// for all the code hashes if [codeHashes] is empty, hashes to its code hash.
+func VerifyCode(ctx context.Context, db ethdb.Database, codeHashes []common.Hash) (CodeVerification, error) {
+	var result CodeVerification
+	verify := func(codeHash common.Hash, code []byte) {
+		result.Checked++
+		if crypto.Keccak256Hash(code) != codeHash {
+			result.Invalid = append(result.Invalid, codeHash)
+		}
+	}
+
+	if len(codeHashes) > 0 {
+		for _, codeHash := range codeHashes {
+			if err := ctx.Err(); err != nil {
+				return CodeVerification{}, err
+			}
+			code := rawdb.ReadCode(db, codeHash)
+			if len(code) == 0 {
+				result.Missing = append(result.Missing, codeHash)
+				continue
+			}
+			verify(codeHash, code)
+		}
+		return result, nil
+	}
+
+	it := rawdb.NewKeyLengthIterator(db.NewIterator(rawdb.CodePrefix, nil), len(rawdb.CodePrefix)+common.HashLength)
+	defer it.Release()
+	for it.Next() {
+		if err := ctx.Err(); err != nil {
+			return CodeVerification{}, err
+		}
+		verify(common.BytesToHash(it.Key()[len(rawdb.CodePrefix):]), it.Value())
+	}
+	if err := it.Error(); err != nil {
+		return CodeVerification{}, fmt.Errorf("failed to iterate code: %w", err)
+	}
+	return result, nil
+}
+
+// RefetchCode fetches the code of [codeHashes] from the network with
+// [client] and writes it to [db], replacing any code already stored for
+// them. The code hashes are marked as pending to be fetched until their code
+// is written. If fetching fails, the markers added for the code hashes whose
+// code was not written are removed, while the code hashes that were already
+// pending stay in the code queue.
+func RefetchCode(ctx context.Context, client statesyncclient.Client, db ethdb.Database, codeHashes []common.Hash) (err error) {
+	var (
+		batch = db.NewBatch()
+		added = set.NewSet[common.Hash](len(codeHashes))
+	)
+	for _, codeHash := range codeHashes {
+		if rawdb.HasCodeToFetch(db, codeHash) {
+			continue
+		}
+		rawdb.AddCodeToFetch(batch, codeHash)
+		added.Add(codeHash)
+	}
+	if err := batch.Write(); err != nil {
+		return fmt.Errorf("failed to write code to fetch markers: %w", err)
+	}
+	defer func() {
+		if err == nil || added.Len() == 0 {
+			return
+		}
+		batch.Reset()
+		for codeHash := range added {
+			rawdb.DeleteCodeToFetch(batch, codeHash)
+		}
+		if writeErr := batch.Write(); writeErr != nil {
+			log.Error("failed to remove code to fetch markers", "codeHashes", added.Len(), "err", writeErr)
+		}
+	}()
+
+	for start := 0; start < len(codeHashes); start += message.MaxCodeHashesPerRequest {
+		end := min(start+message.MaxCodeHashesPerRequest, len(codeHashes))
+		request := codeHashes[start:end]
+		// GetCode verifies that the code hashes to the requested code hashes.
+		code, err := client.GetCode(ctx, request)
+		if err != nil {
+			return fmt.Errorf("failed to fetch code: %w", err)
+		}
+
+		batch.Reset()
+		for i, codeHash := range request {
+			rawdb.WriteCode(batch, codeHash, code[i])
+			rawdb.DeleteCodeToFetch(batch, codeHash)
+		}
+		if err := batch.Write(); err != nil {
+			return fmt.Errorf("failed to write fetched code: %w", err)
+		}
+		for _, codeHash := range request {
+			added.Remove(codeHash)
+		}
+	}
+	log.Info("refetched code", "codeHashes", len(codeHashes))
+	return nil
+}
+
+// FindMissingCode scans the accounts of the trie at [root] in [trieDB] and
+// returns the number of accounts scanned and the code hashes of the accounts
+// whose code is missing from [db].
+func FindMissingCode(ctx context.Context, trieDB *triedb.Database, root common.Hash, db ethdb.KeyValueReader) (int, []common.Hash, error) {
+	tr, err := trie.New(trie.StateTrieID(root), trieDB)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to open account trie %s: %w", root, err)
+	}
+	nodeIt, err := tr.NodeIterator(nil)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var (
+		it       = trie.NewIterator(nodeIt)
+		accounts int
+		checked  set.Set[common.Hash]
+		missing  []common.Hash
+	)
+	for it.Next() {
+		if err := ctx.Err(); err != nil {
+			return 0, nil, err
+		}
+		accounts++
+
+		var acc types.StateAccount
+		if err := rlp.DecodeBytes(it.Value, &acc); err != nil {
+			return 0, nil, fmt.Errorf("failed to decode account %x: %w", it.Key, err)
+		}
+		codeHash := common.BytesToHash(acc.CodeHash)
+		if codeHash == (common.Hash{}) || codeHash == types.EmptyCodeHash || checked.Contains(codeHash) {
+			continue
+		}
+		checked.Add(codeHash)
+		if !rawdb.HasCode(db, codeHash) {
+			missing = append(missing, codeHash)
+		}
+	}
+	if it.Err != nil {
+		return 0, nil, fmt.Errorf("failed to iterate account trie %s: %w", root, it.Err)
+	}
+	return accounts, missing, nil
+}
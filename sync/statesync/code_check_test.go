@@ -0,0 +1,137 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package statesync
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ava-labs/coreth/core/rawdb"
+	"github.com/ava-labs/coreth/core/types"
+	"github.com/ava-labs/coreth/plugin/evm/message"
+	statesyncclient "github.com/ava-labs/coreth/sync/client"
+	"github.com/ava-labs/coreth/sync/handlers"
+	handlerstats "github.com/ava-labs/coreth/sync/handlers/stats"
+	"github.com/ava-labs/coreth/sync/syncutils"
+	"github.com/ava-labs/coreth/triedb"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadCodeQueue(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	codeHashes := make([]common.Hash, 5)
+	for i := range codeHashes {
+		codeHashes[i] = crypto.Keccak256Hash([]byte{byte(i)})
+		rawdb.AddCodeToFetch(db, codeHashes[i])
+	}
+
+	queued, total, err := ReadCodeQueue(db, 0)
+	require.NoError(t, err)
+	require.Equal(t, len(codeHashes), total)
+	require.ElementsMatch(t, codeHashes, queued)
+
+	queued, total, err = ReadCodeQueue(db, 2)
+	require.NoError(t, err)
+	require.Equal(t, len(codeHashes), total)
+	require.Len(t, queued, 2)
+	require.Subset(t, codeHashes, queued)
+}
+
+func TestVerifyCode(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	validCode := []byte("valid code")
+	validHash := crypto.Keccak256Hash(validCode)
+	rawdb.WriteCode(db, validHash, validCode)
+	invalidHash := crypto.Keccak256Hash([]byte("original code"))
+	rawdb.WriteCode(db, invalidHash, []byte("corrupted code"))
+	missingHash := crypto.Keccak256Hash([]byte("missing code"))
+
+	result, err := VerifyCode(context.Background(), db, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, result.Checked)
+	require.Equal(t, []common.Hash{invalidHash}, result.Invalid)
+	require.Empty(t, result.Missing)
+
+	result, err = VerifyCode(context.Background(), db, []common.Hash{validHash, missingHash})
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Checked)
+	require.Empty(t, result.Invalid)
+	require.Equal(t, []common.Hash{missingHash}, result.Missing)
+}
+
+func TestRefetchCode(t *testing.T) {
+	serverDB := rawdb.NewMemoryDatabase()
+	clientDB := rawdb.NewMemoryDatabase()
+	codeHashes := make([]common.Hash, message.MaxCodeHashesPerRequest+1)
+	for i := range codeHashes {
+		code := []byte{byte(i), 0x01}
+		codeHashes[i] = crypto.Keccak256Hash(code)
+		rawdb.WriteCode(serverDB, codeHashes[i], code)
+		// The client has corrupted code for every other code hash.
+		if i%2 == 0 {
+			rawdb.WriteCode(clientDB, codeHashes[i], []byte{byte(i), 0x02})
+		}
+	}
+	codeRequestHandler := handlers.NewCodeRequestHandler(serverDB, message.Codec, handlerstats.NewNoopHandlerStats())
+	client := statesyncclient.NewMockClient(message.Codec, nil, codeRequestHandler, nil)
+
+	require.NoError(t, RefetchCode(context.Background(), client, clientDB, codeHashes))
+
+	result, err := VerifyCode(context.Background(), clientDB, codeHashes)
+	require.NoError(t, err)
+	require.Equal(t, len(codeHashes), result.Checked)
+	require.Empty(t, result.Invalid)
+	require.Empty(t, result.Missing)
+
+	queued, _, err := ReadCodeQueue(clientDB, 0)
+	require.NoError(t, err)
+	require.Empty(t, queued)
+}
+
+func TestRefetchCodeFailure(t *testing.T) {
+	serverDB := rawdb.NewMemoryDatabase()
+	clientDB := rawdb.NewMemoryDatabase()
+	queuedHash := crypto.Keccak256Hash([]byte("queued"))
+	codeHash := crypto.Keccak256Hash([]byte("missing from server"))
+	rawdb.AddCodeToFetch(clientDB, queuedHash)
+	codeRequestHandler := handlers.NewCodeRequestHandler(serverDB, message.Codec, handlerstats.NewNoopHandlerStats())
+	client := statesyncclient.NewMockClient(message.Codec, nil, codeRequestHandler, nil)
+
+	require.Error(t, RefetchCode(context.Background(), client, clientDB, []common.Hash{queuedHash, codeHash}))
+
+	// Only the code hash that was already pending stays in the code queue.
+	queued, _, err := ReadCodeQueue(clientDB, 0)
+	require.NoError(t, err)
+	require.Equal(t, []common.Hash{queuedHash}, queued)
+}
+
+func TestFindMissingCode(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	trieDB := triedb.NewDatabase(db, nil)
+
+	var (
+		sharedCode = []byte("shared code")
+		sharedHash = crypto.Keccak256Hash(sharedCode)
+		missing    []common.Hash
+	)
+	rawdb.WriteCode(db, sharedHash, sharedCode)
+	root, _ := syncutils.FillAccounts(t, trieDB, common.Hash{}, 100, func(t *testing.T, i int, account types.StateAccount) types.StateAccount {
+		switch i % 3 {
+		case 0:
+			account.CodeHash = sharedHash[:]
+		case 1:
+			codeHash := crypto.Keccak256Hash([]byte{byte(i)})
+			account.CodeHash = codeHash[:]
+			missing = append(missing, codeHash)
+		}
+		return account
+	})
+
+	accounts, found, err := FindMissingCode(context.Background(), trieDB, root, db)
+	require.NoError(t, err)
+	require.Equal(t, 100, accounts)
+	require.ElementsMatch(t, missing, found)
+}
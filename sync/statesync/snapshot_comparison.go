@@ -0,0 +1,132 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package statesync
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/ava-labs/coreth/core/rawdb"
+	"github.com/ava-labs/coreth/core/types"
+	"github.com/ava-labs/coreth/trie"
+	"github.com/ava-labs/coreth/triedb"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// SnapshotComparison is the result of [CompareSnapshot].
+type SnapshotComparison struct {
+	Accounts uint64 // Accounts of the account trie compared
+	Slots    uint64 // Storage slots of the storage tries compared
+	// Mismatches is the number of accounts and storage slots that are missing
+	// from the snapshot, that are in the snapshot but not in the tries, or
+	// whose value in the snapshot differs from the tries.
+	Mismatches uint64
+}
+
+// CompareSnapshot compares the account and storage snapshot in [db] with the
+// leafs of the account trie at [root] and of its storage tries, as the
+// snapshot is generated from them. It must be called before blocks are
+// accepted on top of [root], since they update the snapshot.
+func CompareSnapshot(ctx context.Context, db ethdb.Database, root common.Hash) (SnapshotComparison, error) {
+	log.Info("snapshot comparison starting", "root", root)
+	var (
+		result  SnapshotComparison
+		trieDB  = triedb.NewDatabase(db, nil)
+		snapIt  = rawdb.IterateAccountSnapshots(db)
+		onSlots = func(_, trieVal, snapVal []byte) error {
+			if trieVal != nil {
+				result.Slots++
+			}
+			if !bytes.Equal(trieVal, snapVal) {
+				result.Mismatches++
+			}
+			return nil
+		}
+	)
+	defer snapIt.Release()
+
+	err := diffLeafs(ctx, trieDB, trie.StateTrieID(root), snapIt, len(rawdb.SnapshotAccountPrefix), func(key, trieVal, snapVal []byte) error {
+		var (
+			accountHash = common.BytesToHash(key)
+			storageRoot = types.EmptyRootHash
+			slimAccount []byte
+		)
+		if trieVal != nil {
+			var acc types.StateAccount
+			if err := rlp.DecodeBytes(trieVal, &acc); err != nil {
+				return fmt.Errorf("failed to decode account %x: %w", key, err)
+			}
+			result.Accounts++
+			storageRoot = acc.Root
+			slimAccount = types.SlimAccountRLP(acc)
+		}
+		if !bytes.Equal(slimAccount, snapVal) {
+			result.Mismatches++
+		}
+		// The storage of accounts missing from the trie is compared with an
+		// empty trie, so that it is counted as mismatched.
+		storageIt := rawdb.IterateStorageSnapshots(db, accountHash)
+		defer storageIt.Release()
+		return diffLeafs(ctx, trieDB, trie.StorageTrieID(root, accountHash, storageRoot), storageIt, len(rawdb.SnapshotStoragePrefix)+common.HashLength, onSlots)
+	})
+	if err != nil {
+		return SnapshotComparison{}, err
+	}
+	log.Info("snapshot comparison finished", "root", root, "accounts", result.Accounts, "slots", result.Slots, "mismatches", result.Mismatches)
+	return result, nil
+}
+
+// diffLeafs walks the leafs of the trie [id] and the entries of [snapIt],
+// whose keys are the keys of the leafs after [prefixLen] bytes, in key order.
+// It calls [onLeaf] with each key, its value in the trie and its value in the
+// snapshot, either of which is nil if the key is missing from it. The key and
+// the values are only valid until [onLeaf] returns.
+func diffLeafs(ctx context.Context, trieDB *triedb.Database, id *trie.ID, snapIt ethdb.Iterator, prefixLen int, onLeaf func(key, trieVal, snapVal []byte) error) error {
+	tr, err := trie.New(id, trieDB)
+	if err != nil {
+		return err
+	}
+	nodeIt, err := tr.NodeIterator(nil)
+	if err != nil {
+		return err
+	}
+	trieIt := trie.NewIterator(nodeIt)
+	hasTrie, hasSnap := trieIt.Next(), snapIt.Next()
+	for hasTrie || hasSnap {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		cmp := 0
+		switch {
+		case !hasSnap:
+			cmp = -1
+		case !hasTrie:
+			cmp = 1
+		default:
+			cmp = bytes.Compare(trieIt.Key, snapIt.Key()[prefixLen:])
+		}
+		switch {
+		case cmp < 0:
+			err = onLeaf(trieIt.Key, trieIt.Value, nil)
+			hasTrie = trieIt.Next()
+		case cmp > 0:
+			err = onLeaf(snapIt.Key()[prefixLen:], nil, snapIt.Value())
+			hasSnap = snapIt.Next()
+		default:
+			err = onLeaf(trieIt.Key, trieIt.Value, snapIt.Value())
+			hasTrie, hasSnap = trieIt.Next(), snapIt.Next()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if trieIt.Err != nil {
+		return trieIt.Err
+	}
+	return snapIt.Error()
+}
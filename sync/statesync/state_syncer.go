@@ -246,6 +246,20 @@ func (t *stateSync) Start(ctx context.Context) error {
 
 func (t *stateSync) Done() <-chan error { return t.done }
 
+// Progress returns a snapshot of the progress of the sync.
+// Safe to call concurrently with the sync.
+func (t *stateSync) Progress() Progress {
+	var p Progress
+	t.stats.progress(&p)
+
+	t.lock.RLock()
+	p.TriesInProgress = len(t.triesInProgress)
+	t.lock.RUnlock()
+
+	p.CodeHashesPending = t.codeSyncer.pending()
+	return p
+}
+
 // addTrieInProgress tracks the root as being currently synced.
 func (t *stateSync) addTrieInProgress(root common.Hash, trie *trieToSync) {
 	t.lock.Lock()
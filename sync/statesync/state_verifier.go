@@ -0,0 +1,363 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package statesync
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/ava-labs/avalanchego/utils/set"
+	"github.com/ava-labs/coreth/core/rawdb"
+	"github.com/ava-labs/coreth/core/types"
+	"github.com/ava-labs/coreth/plugin/evm/message"
+	statesyncclient "github.com/ava-labs/coreth/sync/client"
+	"github.com/ava-labs/coreth/trie"
+	"github.com/ava-labs/coreth/triedb"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// StateVerifierConfig configures a [StateVerifier].
+type StateVerifierConfig struct {
+	Root        common.Hash    // Root of the account trie to verify
+	DB          ethdb.Database // Database holding the tries and the code
+	BatchSize   int            // Size of the batches of repaired trie nodes written
+	RequestSize uint16         // Number of leafs to request from a peer at a time when repairing
+
+	// Repair fetches the leafs of the broken ranges of the tries and the
+	// missing or invalid code from peers with Client, and rewrites the trie
+	// nodes of the broken ranges and the code.
+	Repair bool
+	Client statesyncclient.Client
+}
+
+// BrokenRange is a range of keys of a trie whose leafs could not be verified,
+// because the trie node holding them is missing or corrupt.
+type BrokenRange struct {
+	Root    common.Hash // Root of the trie
+	Account common.Hash // Account of the storage trie, empty for the account trie
+	Start   []byte      // First key of the range
+	End     []byte      // Last key of the range
+}
+
+// contains returns whether [other] is a range of the same trie within [r].
+func (r BrokenRange) contains(other BrokenRange) bool {
+	return r.Root == other.Root && bytes.Compare(r.Start, other.Start) <= 0 && bytes.Compare(r.End, other.End) >= 0
+}
+
+// StateVerification is the result of a [StateVerifier], or its progress while
+// it is running.
+type StateVerification struct {
+	Accounts     uint64 // Accounts verified
+	StorageTries uint64 // Distinct storage tries verified
+	TrieNodes    uint64 // Trie nodes whose hash was verified
+	// BrokenRanges are the ranges of the tries below missing or corrupt nodes.
+	// The accounts in the broken ranges of the account trie are not verified.
+	BrokenRanges []BrokenRange
+	// InvalidCode are the code hashes whose code is missing or does not hash
+	// to them.
+	InvalidCode []common.Hash
+}
+
+// Gaps returns the number of broken ranges and invalid code found.
+func (v StateVerification) Gaps() uint64 {
+	return uint64(len(v.BrokenRanges) + len(v.InvalidCode))
+}
+
+// StateVerifier verifies that the state at a root is complete: that every node
+// of the account trie and of the storage tries is present and hashes to its
+// hash, and that the code of every account is present.
+// The trie nodes and the code are content addressed, so the state can be
+// verified and repaired while the chain is running.
+type StateVerifier struct {
+	config StateVerifierConfig
+	trieDB *triedb.Database
+
+	lock   sync.Mutex
+	result StateVerification
+
+	verifiedRoots set.Set[common.Hash]
+	checkedCode   set.Set[common.Hash]
+}
+
+func NewStateVerifier(config StateVerifierConfig) *StateVerifier {
+	return &StateVerifier{
+		config: config,
+		trieDB: triedb.NewDatabase(config.DB, nil),
+	}
+}
+
+// Progress returns the results of the verification so far.
+func (v *StateVerifier) Progress() StateVerification {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	result := v.result
+	result.BrokenRanges = slices.Clone(result.BrokenRanges)
+	result.InvalidCode = slices.Clone(result.InvalidCode)
+	return result
+}
+
+func (v *StateVerifier) update(fn func(result *StateVerification)) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	fn(&v.result)
+}
+
+// Verify verifies the state at [v.config.Root] and returns the gaps found.
+// If [v.config.Repair] is set, the gaps are repaired and the state is verified
+// again, since the repaired ranges hold accounts that were not verified, and
+// the gaps left after the repair are returned.
+func (v *StateVerifier) Verify(ctx context.Context) (StateVerification, error) {
+	log.Info("state verification starting", "root", v.config.Root, "repair", v.config.Repair)
+	result, err := v.verify(ctx)
+	if err != nil {
+		return StateVerification{}, err
+	}
+	if v.config.Repair && result.Gaps() > 0 {
+		log.Info("state verification repairing gaps", "root", v.config.Root, "brokenRanges", len(result.BrokenRanges), "invalidCode", len(result.InvalidCode))
+		if err := v.repairRanges(ctx, result.BrokenRanges); err != nil {
+			return StateVerification{}, err
+		}
+		if len(result.InvalidCode) > 0 {
+			if err := RefetchCode(ctx, v.config.Client, v.config.DB, result.InvalidCode); err != nil {
+				return StateVerification{}, err
+			}
+		}
+		if result, err = v.verify(ctx); err != nil {
+			return StateVerification{}, err
+		}
+	}
+	log.Info("state verification finished",
+		"root", v.config.Root,
+		"accounts", result.Accounts,
+		"storageTries", result.StorageTries,
+		"trieNodes", result.TrieNodes,
+		"brokenRanges", len(result.BrokenRanges),
+		"invalidCode", len(result.InvalidCode),
+	)
+	return result, nil
+}
+
+// verify walks the state at [v.config.Root] from the start.
+func (v *StateVerifier) verify(ctx context.Context) (StateVerification, error) {
+	v.update(func(result *StateVerification) { *result = StateVerification{} })
+	v.verifiedRoots = set.Set[common.Hash]{}
+	v.checkedCode = set.Set[common.Hash]{}
+
+	err := v.iterateTrie(ctx, trie.StateTrieID(v.config.Root), common.Hash{}, func(key, value []byte) error {
+		var acc types.StateAccount
+		if err := rlp.DecodeBytes(value, &acc); err != nil {
+			return fmt.Errorf("failed to decode account %x: %w", key, err)
+		}
+		v.update(func(result *StateVerification) { result.Accounts++ })
+		if err := v.verifyStorageTrie(ctx, common.BytesToHash(key), acc.Root); err != nil {
+			return err
+		}
+		v.verifyCode(common.BytesToHash(acc.CodeHash))
+		return nil
+	})
+	if err != nil {
+		return StateVerification{}, err
+	}
+	return v.Progress(), nil
+}
+
+// verifyStorageTrie verifies the storage trie at [root] of [accountHash]. The
+// nodes of each storage trie are only verified for the first account with it.
+func (v *StateVerifier) verifyStorageTrie(ctx context.Context, accountHash common.Hash, root common.Hash) error {
+	if root == (common.Hash{}) || root == types.EmptyRootHash || v.verifiedRoots.Contains(root) {
+		return nil
+	}
+	v.verifiedRoots.Add(root)
+	if err := v.iterateTrie(ctx, trie.StorageTrieID(v.config.Root, accountHash, root), accountHash, nil); err != nil {
+		return err
+	}
+	v.update(func(result *StateVerification) { result.StorageTries++ })
+	return nil
+}
+
+// iterateTrie verifies the hashes of the nodes of the trie [id] of [account]
+// and calls [onLeaf], if set, with its leafs in order. The ranges below
+// missing or corrupt nodes are recorded as broken and skipped.
+func (v *StateVerifier) iterateTrie(ctx context.Context, id *trie.ID, account common.Hash, onLeaf func(key, value []byte) error) error {
+	tr, err := trie.New(id, v.trieDB)
+	if err != nil {
+		v.onBrokenRange(id.Root, account, nil, err)
+		return nil
+	}
+	nodeIt, err := tr.NodeIterator(nil)
+	if err != nil {
+		v.onBrokenRange(id.Root, account, nil, err)
+		return nil
+	}
+	descend := true
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !nodeIt.Next(descend) {
+			err := nodeIt.Error()
+			if err == nil {
+				return nil
+			}
+			// A child of the current node could not be read. The iterator
+			// cannot move past the child to its siblings, so the rest of the
+			// keys below the current node are skipped.
+			path := nodeIt.Path()
+			var missing *trie.MissingNodeError
+			if errors.As(err, &missing) && bytes.HasPrefix(missing.Path, path) {
+				path = missing.Path
+			}
+			v.onBrokenRange(id.Root, account, path, err)
+			descend = false
+			continue
+		}
+		descend = true
+		if hash := nodeIt.Hash(); hash != (common.Hash{}) {
+			if crypto.Keccak256Hash(nodeIt.NodeBlob()) != hash {
+				// A corrupt node may decode to the wrong children, so the
+				// range below it is skipped.
+				v.onBrokenRange(id.Root, account, nodeIt.Path(), fmt.Errorf("trie node %s is corrupt", hash))
+				descend = false
+				continue
+			}
+			v.update(func(result *StateVerification) { result.TrieNodes++ })
+		}
+		if nodeIt.Leaf() && onLeaf != nil {
+			if err := onLeaf(nodeIt.LeafKey(), nodeIt.LeafBlob()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// onBrokenRange records the keys of the trie at [root] below the parent of the
+// missing or corrupt node at hex [path] as broken because of [err]. The range
+// holds the keys skipped by the iterator, and all the keys needed to rebuild
+// the node, since the encoding of a node depends on the path it starts at.
+// A broken range replaces the broken ranges of the same trie it holds.
+func (v *StateVerifier) onBrokenRange(root common.Hash, account common.Hash, path []byte, err error) {
+	if len(path) > 0 {
+		path = path[:len(path)-1]
+	}
+	brokenRange := BrokenRange{
+		Root:    root,
+		Account: account,
+		Start:   make([]byte, common.HashLength),
+		End:     bytes.Repeat([]byte{0xff}, common.HashLength),
+	}
+	for i, nibble := range path {
+		if i%2 == 0 {
+			brokenRange.Start[i/2] = nibble << 4
+			brokenRange.End[i/2] = nibble<<4 | 0x0f
+		} else {
+			brokenRange.Start[i/2] |= nibble
+			brokenRange.End[i/2] = brokenRange.Start[i/2]
+		}
+	}
+	log.Warn("state verification found a broken trie range", "root", root, "account", account, "start", common.Bytes2Hex(brokenRange.Start), "end", common.Bytes2Hex(brokenRange.End), "err", err)
+	v.update(func(result *StateVerification) {
+		if slices.ContainsFunc(result.BrokenRanges, func(r BrokenRange) bool { return r.contains(brokenRange) }) {
+			return
+		}
+		result.BrokenRanges = slices.DeleteFunc(result.BrokenRanges, brokenRange.contains)
+		result.BrokenRanges = append(result.BrokenRanges, brokenRange)
+	})
+}
+
+// verifyCode records [codeHash] as invalid if its code is missing or does not
+// hash to it.
+func (v *StateVerifier) verifyCode(codeHash common.Hash) {
+	if codeHash == (common.Hash{}) || codeHash == types.EmptyCodeHash || v.checkedCode.Contains(codeHash) {
+		return
+	}
+	v.checkedCode.Add(codeHash)
+	if code := rawdb.ReadCode(v.config.DB, codeHash); len(code) == 0 || crypto.Keccak256Hash(code) != codeHash {
+		v.update(func(result *StateVerification) { result.InvalidCode = append(result.InvalidCode, codeHash) })
+	}
+}
+
+// repairRanges fetches the leafs of [ranges] from peers and writes the trie
+// nodes rebuilt from them.
+func (v *StateVerifier) repairRanges(ctx context.Context, ranges []BrokenRange) error {
+	if len(ranges) == 0 {
+		return nil
+	}
+	tasks := make(chan statesyncclient.LeafSyncTask, len(ranges))
+	for _, brokenRange := range ranges {
+		tasks <- newRangeRepairTask(v.config.DB, v.config.BatchSize, brokenRange)
+	}
+	close(tasks)
+
+	syncer := statesyncclient.NewCallbackLeafSyncer(v.config.Client, tasks, v.config.RequestSize)
+	syncer.Start(ctx, defaultNumThreads, func(error) error { return nil })
+	if err := <-syncer.Done(); err != nil {
+		return fmt.Errorf("failed to repair broken trie ranges: %w", err)
+	}
+	return nil
+}
+
+// rangeRepairTask fetches the leafs of a [BrokenRange] and rebuilds the trie
+// nodes below the path its keys share. The range holds every key below that
+// path, so the nodes rebuilt below it are the nodes of the trie.
+type rangeRepairTask struct {
+	brokenRange BrokenRange
+	batch       ethdb.Batch
+	batchSize   int
+	stackTrie   *trie.StackTrie
+}
+
+func newRangeRepairTask(db ethdb.Database, batchSize int, brokenRange BrokenRange) *rangeRepairTask {
+	batch := db.NewBatch()
+	writeFn := func(_ []byte, hash common.Hash, blob []byte) {
+		rawdb.WriteLegacyTrieNode(batch, hash, blob)
+	}
+	return &rangeRepairTask{
+		brokenRange: brokenRange,
+		batch:       batch,
+		batchSize:   batchSize,
+		stackTrie:   trie.NewStackTrie(&trie.StackTrieOptions{Writer: writeFn}),
+	}
+}
+
+// these functions implement the LeafSyncTask interface.
+func (t *rangeRepairTask) Root() common.Hash          { return t.brokenRange.Root }
+func (t *rangeRepairTask) Account() common.Hash       { return t.brokenRange.Account }
+func (t *rangeRepairTask) Start() []byte              { return t.brokenRange.Start }
+func (t *rangeRepairTask) End() []byte                { return t.brokenRange.End }
+func (t *rangeRepairTask) NodeType() message.NodeType { return message.StateTrieNode }
+func (t *rangeRepairTask) OnStart() (bool, error)     { return false, nil }
+
+func (t *rangeRepairTask) OnLeafs(keys, vals [][]byte) error {
+	for i, key := range keys {
+		if err := t.stackTrie.Update(key, vals[i]); err != nil {
+			return err
+		}
+	}
+	if t.batch.ValueSize() < t.batchSize {
+		return nil
+	}
+	if err := t.batch.Write(); err != nil {
+		return fmt.Errorf("failed to write repaired trie nodes: %w", err)
+	}
+	t.batch.Reset()
+	return nil
+}
+
+func (t *rangeRepairTask) OnFinish(context.Context) error {
+	t.stackTrie.Commit()
+	if err := t.batch.Write(); err != nil {
+		return fmt.Errorf("failed to write repaired trie nodes: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+// (c) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package statesync
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/ava-labs/coreth/core/rawdb"
+	"github.com/ava-labs/coreth/core/types"
+	"github.com/ava-labs/coreth/plugin/evm/message"
+	statesyncclient "github.com/ava-labs/coreth/sync/client"
+	"github.com/ava-labs/coreth/sync/handlers"
+	handlerstats "github.com/ava-labs/coreth/sync/handlers/stats"
+	"github.com/ava-labs/coreth/sync/syncutils"
+	"github.com/ava-labs/coreth/trie"
+	"github.com/ava-labs/coreth/triedb"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/require"
+)
+
+const verifierTestAccounts = 100
+
+type verifierTest struct {
+	clientDB     ethdb.Database
+	serverDB     ethdb.Database
+	serverTrieDB *triedb.Database
+	root         common.Hash
+	client       *statesyncclient.MockClient
+}
+
+// newVerifierTest syncs a state with code and storage to a new client
+// database.
+func newVerifierTest(t *testing.T) *verifierTest {
+	rand.Seed(1)
+	serverDB := rawdb.NewMemoryDatabase()
+	serverTrieDB := triedb.NewDatabase(serverDB, nil)
+	test := &verifierTest{
+		clientDB:     rawdb.NewMemoryDatabase(),
+		serverDB:     serverDB,
+		serverTrieDB: serverTrieDB,
+		root:         fillAccountsWithStorage(t, serverDB, serverTrieDB, common.Hash{}, verifierTestAccounts),
+		client: statesyncclient.NewMockClient(
+			message.Codec,
+			handlers.NewLeafsRequestHandler(serverTrieDB, nil, message.Codec, handlerstats.NewNoopHandlerStats()),
+			handlers.NewCodeRequestHandler(serverDB, message.Codec, handlerstats.NewNoopHandlerStats()),
+			nil,
+		),
+	}
+	test.sync(t)
+	return test
+}
+
+func (test *verifierTest) sync(t *testing.T) {
+	testSync(t, syncTest{
+		prepareForTest: func(*testing.T) (ethdb.Database, ethdb.Database, *triedb.Database, common.Hash) {
+			return test.clientDB, test.serverDB, test.serverTrieDB, test.root
+		},
+	})
+}
+
+func (test *verifierTest) verify(t *testing.T, repair bool) StateVerification {
+	result, err := NewStateVerifier(StateVerifierConfig{
+		Root:        test.root,
+		DB:          test.clientDB,
+		BatchSize:   1000,
+		RequestSize: 1024,
+		Repair:      repair,
+		Client:      test.client,
+	}).Verify(context.Background())
+	require.NoError(t, err)
+	return result
+}
+
+// accounts returns the accounts of the client state in order.
+func (test *verifierTest) accounts(t *testing.T) ([]common.Hash, []types.StateAccount) {
+	tr, err := trie.New(trie.StateTrieID(test.root), triedb.NewDatabase(test.clientDB, nil))
+	require.NoError(t, err)
+	nodeIt, err := tr.NodeIterator(nil)
+	require.NoError(t, err)
+	var (
+		it       = trie.NewIterator(nodeIt)
+		hashes   []common.Hash
+		accounts []types.StateAccount
+	)
+	for it.Next() {
+		var acc types.StateAccount
+		require.NoError(t, rlp.DecodeBytes(it.Value, &acc))
+		hashes = append(hashes, common.BytesToHash(it.Key))
+		accounts = append(accounts, acc)
+	}
+	require.NoError(t, it.Err)
+	return hashes, accounts
+}
+
+func TestVerifyStateComplete(t *testing.T) {
+	test := newVerifierTest(t)
+
+	result := test.verify(t, false)
+	require.Zero(t, result.Gaps())
+	require.EqualValues(t, verifierTestAccounts, result.Accounts)
+	require.EqualValues(t, verifierTestAccounts, result.StorageTries)
+	require.NotZero(t, result.TrieNodes)
+}
+
+func TestVerifyStateInvalidCode(t *testing.T) {
+	test := newVerifierTest(t)
+	_, accounts := test.accounts(t)
+	deleted := common.BytesToHash(accounts[0].CodeHash)
+	corrupted := common.BytesToHash(accounts[1].CodeHash)
+	rawdb.DeleteCode(test.clientDB, deleted)
+	rawdb.WriteCode(test.clientDB, corrupted, []byte{0x01})
+
+	// Without repair, the invalid code is reported but not fetched.
+	require.ElementsMatch(t, []common.Hash{deleted, corrupted}, test.verify(t, false).InvalidCode)
+	require.Len(t, test.verify(t, false).InvalidCode, 2)
+	require.Zero(t, test.verify(t, true).Gaps())
+	require.Zero(t, test.verify(t, false).Gaps())
+}
+
+func TestVerifyStateBrokenTries(t *testing.T) {
+	test := newVerifierTest(t)
+	hashes, accounts := test.accounts(t)
+	clientTrieDB := triedb.NewDatabase(test.clientDB, nil)
+
+	// Delete nodes of the first storage trie.
+	missingRoot := accounts[0].Root
+	tr, err := trie.New(trie.TrieID(missingRoot), clientTrieDB)
+	require.NoError(t, err)
+	syncutils.CorruptTrie(t, test.clientDB, tr, 2)
+
+	// Overwrite a node of the second storage trie with another of its nodes.
+	corruptRoot := accounts[1].Root
+	nodes := trieNodes(t, clientTrieDB, trie.TrieID(corruptRoot))
+	require.GreaterOrEqual(t, len(nodes), 3)
+	corruptNode := nodes[1]
+	rawdb.WriteLegacyTrieNode(test.clientDB, corruptNode, rawdb.ReadLegacyTrieNode(test.clientDB, nodes[2]))
+
+	// Delete the deepest node of the account trie holding the last account.
+	accountNodes := trieNodes(t, clientTrieDB, trie.StateTrieID(test.root))
+	missingAccountNode := accountNodes[len(accountNodes)-1]
+	rawdb.DeleteLegacyTrieNode(test.clientDB, missingAccountNode)
+
+	result := test.verify(t, false)
+	var roots []common.Hash
+	for _, brokenRange := range result.BrokenRanges {
+		roots = append(roots, brokenRange.Root)
+		if brokenRange.Root == test.root {
+			// Only the range of keys from the missing node is broken.
+			require.Positive(t, bytes.Compare(brokenRange.Start, hashes[len(hashes)/2].Bytes()))
+			require.LessOrEqual(t, bytes.Compare(brokenRange.Start, hashes[len(hashes)-1].Bytes()), 0)
+			require.GreaterOrEqual(t, bytes.Compare(brokenRange.End, hashes[len(hashes)-1].Bytes()), 0)
+		}
+	}
+	require.Contains(t, roots, missingRoot)
+	require.Contains(t, roots, corruptRoot)
+	require.Contains(t, roots, test.root)
+	require.Less(t, result.Accounts, uint64(verifierTestAccounts))
+	require.Greater(t, result.Accounts, uint64(verifierTestAccounts/2))
+
+	// Repairs only fetch the leafs of the broken ranges.
+	require.Zero(t, test.verify(t, true).Gaps())
+	require.Zero(t, test.verify(t, false).Gaps())
+	require.Equal(t, corruptNode, crypto.Keccak256Hash(rawdb.ReadLegacyTrieNode(test.clientDB, corruptNode)))
+	require.True(t, rawdb.HasLegacyTrieNode(test.clientDB, missingAccountNode))
+	require.Less(t, int(test.client.LeavesReceived()), verifierTestAccounts+countLeafs(t, clientTrieDB, missingRoot)+countLeafs(t, clientTrieDB, corruptRoot))
+}
+
+func TestCompareSnapshot(t *testing.T) {
+	test := newVerifierTest(t)
+	hashes, accounts := test.accounts(t)
+
+	result, err := CompareSnapshot(context.Background(), test.clientDB, test.root)
+	require.NoError(t, err)
+	require.EqualValues(t, verifierTestAccounts, result.Accounts)
+	require.NotZero(t, result.Slots)
+	require.Zero(t, result.Mismatches)
+
+	// Delete an account from the snapshot, change a storage slot of another
+	// and add an account with a storage slot that is not in the tries.
+	rawdb.DeleteAccountSnapshot(test.clientDB, hashes[0])
+	storageIt := rawdb.IterateStorageSnapshots(test.clientDB, hashes[1])
+	require.True(t, storageIt.Next())
+	slot := common.BytesToHash(storageIt.Key()[len(rawdb.SnapshotStoragePrefix)+common.HashLength:])
+	storageIt.Release()
+	rawdb.WriteStorageSnapshot(test.clientDB, hashes[1], slot, []byte{0x01})
+	extra := crypto.Keccak256Hash([]byte("extra"))
+	rawdb.WriteAccountSnapshot(test.clientDB, extra, types.SlimAccountRLP(accounts[0]))
+	rawdb.WriteStorageSnapshot(test.clientDB, extra, common.Hash{0x01}, []byte{0x01})
+
+	result, err = CompareSnapshot(context.Background(), test.clientDB, test.root)
+	require.NoError(t, err)
+	require.EqualValues(t, verifierTestAccounts, result.Accounts)
+	require.EqualValues(t, 4, result.Mismatches)
+}
+
+// trieNodes returns the hashes of the nodes of the trie [id] other than its
+// root, in iteration order.
+func trieNodes(t *testing.T, trieDB *triedb.Database, id *trie.ID) []common.Hash {
+	tr, err := trie.New(id, trieDB)
+	require.NoError(t, err)
+	nodeIt, err := tr.NodeIterator(nil)
+	require.NoError(t, err)
+	var nodes []common.Hash
+	for nodeIt.Next(true) {
+		if hash := nodeIt.Hash(); hash != (common.Hash{}) && hash != id.Root {
+			nodes = append(nodes, hash)
+		}
+	}
+	require.NoError(t, nodeIt.Error())
+	return nodes
+}
+
+// countLeafs returns the number of leafs of the trie at [root].
+func countLeafs(t *testing.T, trieDB *triedb.Database, root common.Hash) int {
+	tr, err := trie.New(trie.TrieID(root), trieDB)
+	require.NoError(t, err)
+	nodeIt, err := tr.NodeIterator(nil)
+	require.NoError(t, err)
+	it := trie.NewIterator(nodeIt)
+	count := 0
+	for it.Next() {
+		count++
+	}
+	require.NoError(t, it.Err)
+	return count
+}
@@ -375,7 +375,11 @@ func (t *trieSegment) OnLeafs(keys, vals [][]byte) error {
 	}
 
 	// update eta
-	t.trie.sync.stats.incLeafs(t, uint64(len(keys)), t.estimateSize())
+	var bytes uint64
+	for i := range keys {
+		bytes += uint64(len(keys[i]) + len(vals[i]))
+	}
+	t.trie.sync.stats.incLeafs(t, uint64(len(keys)), bytes, t.estimateSize())
 
 	if t.trie.root == t.trie.sync.root {
 		return t.trie.createSegmentsIfNeeded(numMainTrieSegments)
@@ -28,11 +28,16 @@ type trieSyncStats struct {
 
 	lastUpdated time.Time
 	leafsRate   utils_math.Averager
+	bytesRate   utils_math.Averager
 
 	triesRemaining   int
 	triesSynced      int
 	triesStartTime   time.Time
 	leafsSinceUpdate uint64
+	bytesSinceUpdate uint64
+	leafs            uint64
+	bytes            uint64
+	eta              time.Duration
 
 	remainingLeafs map[*trieSegment]uint64
 
@@ -60,22 +65,27 @@ func (t *trieSyncStats) incTriesSegmented() {
 	t.triesSegmented.Inc(1) // safe to be called concurrently
 }
 
-// incLeafs takes a lock and adds [count] to the total number of leafs synced.
-// periodically outputs a log message with the number of leafs and tries.
-func (t *trieSyncStats) incLeafs(segment *trieSegment, count uint64, remaining uint64) {
+// incLeafs takes a lock and adds [count] leafs of [bytes] total size to the
+// leafs synced. periodically outputs a log message with the number of leafs
+// and tries.
+func (t *trieSyncStats) incLeafs(segment *trieSegment, count uint64, bytes uint64, remaining uint64) {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
 	t.totalLeafs.Inc(int64(count))
 	t.leafsSinceUpdate += count
+	t.bytesSinceUpdate += bytes
+	t.leafs += count
+	t.bytes += bytes
 	t.remainingLeafs[segment] = remaining
 
 	now := time.Now()
 	sinceUpdate := now.Sub(t.lastUpdated)
 	if sinceUpdate > updateFrequency {
-		t.updateETA(sinceUpdate, now)
+		t.eta = t.updateETA(sinceUpdate, now)
 		t.lastUpdated = now
 		t.leafsSinceUpdate = 0
+		t.bytesSinceUpdate = 0
 	}
 }
 
@@ -117,10 +127,13 @@ func (t *trieSyncStats) trieDone(root common.Hash) {
 // assumes lock is held.
 func (t *trieSyncStats) updateETA(sinceUpdate time.Duration, now time.Time) time.Duration {
 	leafsRate := float64(t.leafsSinceUpdate) / sinceUpdate.Seconds()
+	bytesRate := float64(t.bytesSinceUpdate) / sinceUpdate.Seconds()
 	if t.leafsRate == nil {
 		t.leafsRate = utils_math.NewAverager(leafsRate, leafRateHalfLife, now)
+		t.bytesRate = utils_math.NewAverager(bytesRate, leafRateHalfLife, now)
 	} else {
 		t.leafsRate.Observe(leafsRate, now)
+		t.bytesRate.Observe(bytesRate, now)
 	}
 	t.leafsRateGauge.Update(int64(t.leafsRate.Read()))
 
@@ -150,6 +163,40 @@ func (t *trieSyncStats) setTriesRemaining(triesRemaining int) {
 	t.triesStartTime = time.Now()
 }
 
+// Progress is a snapshot of the progress of an EVM state sync.
+type Progress struct {
+	LeafsFetched uint64 // Leafs fetched across all tries
+	BytesFetched uint64 // Total size of the keys and values of the leafs fetched
+	TriesSynced  int    // Tries completed, including the account trie
+	// TriesInProgress is the number of tries being synced.
+	TriesInProgress int
+	// TriesRemaining is the number of storage tries left to sync, which is
+	// only known once the account trie is synced.
+	TriesRemaining      int
+	SegmentsOutstanding int           // Trie segments being synced
+	CodeHashesPending   int           // Code hashes queued for fetching
+	LeafsPerSecond      float64       // Moving average of the leafs fetched per second
+	BytesPerSecond      float64       // Moving average of the bytes fetched per second
+	ETA                 time.Duration // Estimated time remaining, zero until the first estimate
+}
+
+// progress fills in the fields of [p] tracked by the stats.
+func (t *trieSyncStats) progress(p *Progress) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	p.LeafsFetched = t.leafs
+	p.BytesFetched = t.bytes
+	p.TriesSynced = t.triesSynced
+	p.TriesRemaining = t.triesRemaining
+	p.SegmentsOutstanding = len(t.remainingLeafs)
+	if t.leafsRate != nil {
+		p.LeafsPerSecond = t.leafsRate.Read()
+		p.BytesPerSecond = t.bytesRate.Read()
+	}
+	p.ETA = t.eta
+}
+
 // roundETA rounds [d] to a minute and chops off the "0s" suffix
 // returns "<1m" if [d] rounds to 0 minutes.
 func roundETA(d time.Duration) string {
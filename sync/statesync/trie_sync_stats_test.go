@@ -24,3 +24,30 @@ func TestETAShouldNotOverflow(t *testing.T) {
 	}
 	require.Positive(stats.updateETA(time.Minute, now))
 }
+
+func TestTrieSyncStatsProgress(t *testing.T) {
+	require := require.New(t)
+	stats := newTrieSyncStats()
+	segment := &trieSegment{}
+
+	var p Progress
+	stats.progress(&p)
+	require.Equal(Progress{}, p)
+
+	stats.incLeafs(segment, 10, 640, 90)
+	stats.setTriesRemaining(3)
+	stats.progress(&p)
+	require.Equal(uint64(10), p.LeafsFetched)
+	require.Equal(uint64(640), p.BytesFetched)
+	require.Equal(3, p.TriesRemaining)
+	require.Equal(1, p.SegmentsOutstanding)
+
+	// Rates and the ETA are updated periodically.
+	stats.lastUpdated = time.Now().Add(-2 * updateFrequency)
+	stats.incLeafs(segment, 10, 640, 80)
+	stats.progress(&p)
+	require.Equal(uint64(20), p.LeafsFetched)
+	require.Positive(p.LeafsPerSecond)
+	require.Positive(p.BytesPerSecond)
+	require.Positive(p.ETA)
+}